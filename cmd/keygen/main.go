@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/realdanielursul/simbir-go/config"
+	"github.com/realdanielursul/simbir-go/pkg/jwtkeys"
+	"github.com/realdanielursul/simbir-go/pkg/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// keygen adds a new jwt signing key to the key directory. Running servers
+// publish it on the next reload and sign with it once the activation delay
+// has passed. Remove the previous key once the tokens it signed have expired.
+func main() {
+	configPath := flag.String("config", "./config/local.yaml", "path to config file")
+	algorithm := flag.String("alg", "", "key algorithm, RS256 or EdDSA (default from config)")
+	flag.Parse()
+
+	logger.SetLogrus()
+
+	cfg, err := config.NewConfig(*configPath)
+	if err != nil {
+		log.Fatalf("error loading config: %s", err.Error())
+	}
+
+	if *algorithm == "" {
+		*algorithm = cfg.JWT.KeyAlgorithm
+	}
+
+	if err := os.MkdirAll(cfg.JWT.KeysDir, 0o700); err != nil {
+		log.Fatalf("error creating jwt keys directory: %s", err.Error())
+	}
+
+	keys, err := jwtkeys.NewKeySet(cfg.JWT.KeysDir, cfg.JWT.KeyActivationDelay)
+	if err != nil {
+		log.Fatalf("error loading jwt keys: %s", err.Error())
+	}
+
+	key, err := keys.Generate(*algorithm)
+	if err != nil {
+		log.Fatalf("error generating jwt key: %s", err.Error())
+	}
+
+	logrus.Infof("generated %s key %s, it signs tokens after %s", key.Algorithm, key.ID, cfg.JWT.KeyActivationDelay)
+}
@@ -2,26 +2,35 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	_ "github.com/lib/pq"
 	"github.com/realdanielursul/simbir-go/config"
+	"github.com/realdanielursul/simbir-go/internal/handler"
 	"github.com/realdanielursul/simbir-go/internal/repository"
 	"github.com/realdanielursul/simbir-go/internal/service"
 	"github.com/realdanielursul/simbir-go/pkg/hasher"
+	"github.com/realdanielursul/simbir-go/pkg/httpserver"
+	"github.com/realdanielursul/simbir-go/pkg/jwtkeys"
 	"github.com/realdanielursul/simbir-go/pkg/logger"
+	"github.com/realdanielursul/simbir-go/pkg/mailer"
+	"github.com/realdanielursul/simbir-go/pkg/oidc"
 	"github.com/realdanielursul/simbir-go/pkg/postgres"
+	"github.com/realdanielursul/simbir-go/pkg/storage"
+	"github.com/sirupsen/logrus"
 )
 
 // set IDs to one standart
 
 // deep think what can admin change in rents
 
-// implement validations
-
-// philosophy: admin methods do not require validation
-
 // sql queries to several lines
 
 func main() {
@@ -39,39 +48,154 @@ func main() {
 
 	repositories := repository.NewRepositories(db)
 
+	if err := os.MkdirAll(cfg.JWT.KeysDir, 0o700); err != nil {
+		log.Fatalf("error creating jwt keys directory: %s", err.Error())
+	}
+
+	keys, err := jwtkeys.NewKeySet(cfg.JWT.KeysDir, cfg.JWT.KeyActivationDelay)
+	if err != nil {
+		log.Fatalf("error loading jwt keys: %s", err.Error())
+	}
+
+	// convenient for local runs, replicas must share the key directory
+	if keys.Len() == 0 {
+		key, err := keys.Generate(cfg.JWT.KeyAlgorithm)
+		if err != nil {
+			log.Fatalf("error generating jwt key: %s", err.Error())
+		}
+
+		logrus.Warnf("no jwt keys found, generated %s in %s", key.ID, cfg.JWT.KeysDir)
+	}
+
+	sender, err := newMailSender(cfg.Mail)
+	if err != nil {
+		log.Fatalf("error creating mail sender: %s", err.Error())
+	}
+
+	store, err := storage.NewLocalStore(cfg.Storage.Dir)
+	if err != nil {
+		log.Fatalf("error creating file storage: %s", err.Error())
+	}
+
+	var provider *oidc.Provider
+	if cfg.OIDC.Enabled {
+		provider = oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDC.Issuer,
+			ClientID:     cfg.OIDC.ClientID,
+			ClientSecret: cfg.OIDC.ClientSecret,
+			RedirectURL:  cfg.OIDC.RedirectURL,
+			Scopes:       cfg.OIDC.Scopes,
+		}, &http.Client{Timeout: cfg.OIDC.Timeout})
+	}
+
 	deps := service.ServicesDependencies{
-		Repos:    repositories,
-		Hasher:   hasher.NewSHA1Hasher(cfg.Hasher.Salt),
-		SignKey:  cfg.JWT.SignKey,
-		TokenTTL: cfg.JWT.TokenTTL,
+		Repos: repositories,
+		Hasher: hasher.NewArgon2idHasher(hasher.Argon2idParams{
+			Memory:      cfg.Hasher.Memory,
+			Iterations:  cfg.Hasher.Iterations,
+			Parallelism: cfg.Hasher.Parallelism,
+			SaltLength:  cfg.Hasher.SaltLength,
+			KeyLength:   cfg.Hasher.KeyLength,
+		}, hasher.NewSHA1Hasher(cfg.Hasher.Salt)),
+		Validation: service.ValidationRules{
+			UsernameMinLength: cfg.Validation.UsernameMinLength,
+			UsernameMaxLength: cfg.Validation.UsernameMaxLength,
+			PasswordMinLength: cfg.Validation.PasswordMinLength,
+			PasswordMaxLength: cfg.Validation.PasswordMaxLength,
+			TextMaxLength:     cfg.Validation.TextMaxLength,
+			MaxPrice:          cfg.Validation.MaxPrice,
+			MaxSearchRadius:   cfg.Validation.MaxSearchRadius,
+		},
+		Lockout: service.LockoutPolicy{
+			UsernameAttempts: cfg.Lockout.UsernameAttempts,
+			IPAttempts:       cfg.Lockout.IPAttempts,
+			BaseDelay:        cfg.Lockout.BaseDelay,
+			MaxDelay:         cfg.Lockout.MaxDelay,
+			ResetAfter:       cfg.Lockout.ResetAfter,
+		},
+		Keys:                 keys,
+		TokenTTL:             cfg.JWT.TokenTTL,
+		RefreshTokenTTL:      cfg.JWT.RefreshTokenTTL,
+		TwoFactorIssuer:      cfg.TwoFactor.Issuer,
+		SignInChallengeTTL:   cfg.TwoFactor.ChallengeTTL,
+		Mailer:               sender,
+		MailLinkBaseURL:      cfg.Mail.LinkBaseURL,
+		EmailVerificationTTL: cfg.Mail.VerificationTTL,
+		PasswordResetTTL:     cfg.Mail.ResetTTL,
+		OIDC:                 provider,
+		OIDCStateTTL:         cfg.OIDC.StateTTL,
+		Storage:              store,
+		KYC: service.KYCPolicy{
+			MaxLicenseSize: cfg.KYC.MaxLicenseSize,
+			MinRiderAge:    cfg.KYC.MinRiderAge,
+		},
 	}
 
 	services := service.NewServices(deps)
-	ctx := context.Background()
-
-	go services.Payment.BillingWorker(ctx)
-
-	fmt.Println(services.AdminAccount.CreateAccount(ctx, &service.AdminAccountInput{
-		Username: "danixx",
-		Password: "c094yt178",
-		IsAdmin:  true,
-		Balance:  25000,
-	}))
-
-	fmt.Println(services.Transport.CreateTransport(ctx, 1, &service.TransportInput{
-		CanBeRented:   true,
-		TransportType: "Car",
-		Model:         "model",
-		Color:         "color",
-		Identifier:    "identifier",
-		Description:   nil,
-		Latitude:      15.656,
-		Longitude:     47.123,
-		MinutePrice:   15,
-		DayPrice:      15000,
-	}))
-
-	fmt.Println(services.Rent.StartRent(ctx, 1, 1, "Minutes"))
-
-	select {}
+	handlers := handler.NewHandler(services, cfg.HTTP.TrustedProxies)
+	router, err := handlers.InitRoutes()
+	if err != nil {
+		log.Fatalf("error initializing routes: %s", err.Error())
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	srv := new(httpserver.Server)
+	go func() {
+		if err := srv.Run(cfg.HTTP.Port, router); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logrus.Errorf("error running http server: %s", err.Error())
+			stop()
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		services.Payment.BillingWorker(ctx)
+	}()
+
+	go func() {
+		defer wg.Done()
+		keys.Watch(ctx, cfg.JWT.KeyReloadInterval, func(err error) {
+			logrus.Errorf("error reloading jwt keys: %s", err.Error())
+		})
+	}()
+
+	logrus.Infof("%s started on port %s", cfg.App.Name, cfg.HTTP.Port)
+
+	<-ctx.Done()
+	stop()
+
+	logrus.Info("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		logrus.Errorf("error shutting down http server: %s", err.Error())
+	}
+
+	// wait for in-flight billing to finish
+	wg.Wait()
+
+	if err := db.Close(); err != nil {
+		logrus.Errorf("error closing postgres connection: %s", err.Error())
+	}
+
+	logrus.Info("stopped")
+}
+
+func newMailSender(cfg config.Mail) (mailer.Sender, error) {
+	switch cfg.Driver {
+	case "log":
+		return mailer.NewLogSender(), nil
+	case "file":
+		return mailer.NewFileSender(cfg.Dir, cfg.From)
+	case "smtp":
+		return mailer.NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
 }
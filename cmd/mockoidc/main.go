@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/realdanielursul/simbir-go/pkg/logger"
+	"github.com/realdanielursul/simbir-go/pkg/oidc/oidctest"
+	"github.com/sirupsen/logrus"
+)
+
+// mockoidc runs a local OpenID Connect provider to try and test external
+// sign in without a real one. It signs in the user given by the flags, or
+// another registered user picked with the login_hint parameter.
+func main() {
+	addr := flag.String("addr", ":9000", "address to listen on")
+	issuer := flag.String("issuer", "http://localhost:9000", "issuer url the service reaches the provider at")
+	clientID := flag.String("client-id", "simbir-go", "client id of the service")
+	clientSecret := flag.String("client-secret", "secret", "client secret of the service")
+	subject := flag.String("sub", "mock-user", "subject of the signed in user")
+	username := flag.String("username", "mockuser", "preferred username of the signed in user")
+	email := flag.String("email", "mockuser@example.com", "email of the signed in user")
+	flag.Parse()
+
+	logger.SetLogrus()
+
+	provider, err := oidctest.NewIssuer(*issuer, *clientID, *clientSecret)
+	if err != nil {
+		log.Fatalf("error creating mock oidc issuer: %s", err.Error())
+	}
+
+	provider.AddUser(oidctest.User{
+		Subject:       *subject,
+		Username:      *username,
+		Email:         *email,
+		EmailVerified: true,
+	})
+
+	logrus.Infof("mock oidc issuer %s listening on %s", *issuer, *addr)
+	if err := http.ListenAndServe(*addr, provider); err != nil {
+		log.Fatalf("error running mock oidc issuer: %s", err.Error())
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	_ "github.com/lib/pq"
+	"github.com/realdanielursul/simbir-go/config"
+	"github.com/realdanielursul/simbir-go/internal/repository"
+	"github.com/realdanielursul/simbir-go/internal/service"
+	"github.com/realdanielursul/simbir-go/pkg/hasher"
+	"github.com/realdanielursul/simbir-go/pkg/logger"
+	"github.com/realdanielursul/simbir-go/pkg/mailer"
+	"github.com/realdanielursul/simbir-go/pkg/postgres"
+	"github.com/sirupsen/logrus"
+)
+
+// seed fills a development database with an admin account and a sample transport.
+func main() {
+	configPath := flag.String("config", "./config/local.yaml", "path to config file")
+	username := flag.String("username", "admin", "admin username")
+	password := flag.String("password", "", "admin password")
+	flag.Parse()
+
+	if *password == "" {
+		log.Fatal("admin password is required")
+	}
+
+	logger.SetLogrus()
+
+	cfg, err := config.NewConfig(*configPath)
+	if err != nil {
+		log.Fatalf("error loading config: %s", err.Error())
+	}
+
+	db, err := postgres.New(cfg.Postgres)
+	if err != nil {
+		log.Fatalf("error creating postgres database: %s", err.Error())
+	}
+	defer db.Close()
+
+	services := service.NewServices(service.ServicesDependencies{
+		Repos: repository.NewRepositories(db),
+		Hasher: hasher.NewArgon2idHasher(hasher.Argon2idParams{
+			Memory:      cfg.Hasher.Memory,
+			Iterations:  cfg.Hasher.Iterations,
+			Parallelism: cfg.Hasher.Parallelism,
+			SaltLength:  cfg.Hasher.SaltLength,
+			KeyLength:   cfg.Hasher.KeyLength,
+		}, hasher.NewSHA1Hasher(cfg.Hasher.Salt)),
+		Validation: service.ValidationRules{
+			UsernameMinLength: cfg.Validation.UsernameMinLength,
+			UsernameMaxLength: cfg.Validation.UsernameMaxLength,
+			PasswordMinLength: cfg.Validation.PasswordMinLength,
+			PasswordMaxLength: cfg.Validation.PasswordMaxLength,
+			TextMaxLength:     cfg.Validation.TextMaxLength,
+			MaxPrice:          cfg.Validation.MaxPrice,
+			MaxSearchRadius:   cfg.Validation.MaxSearchRadius,
+		},
+		Lockout: service.LockoutPolicy{
+			UsernameAttempts: cfg.Lockout.UsernameAttempts,
+			IPAttempts:       cfg.Lockout.IPAttempts,
+			BaseDelay:        cfg.Lockout.BaseDelay,
+			MaxDelay:         cfg.Lockout.MaxDelay,
+			ResetAfter:       cfg.Lockout.ResetAfter,
+		},
+		TokenTTL:             cfg.JWT.TokenTTL,
+		RefreshTokenTTL:      cfg.JWT.RefreshTokenTTL,
+		TwoFactorIssuer:      cfg.TwoFactor.Issuer,
+		SignInChallengeTTL:   cfg.TwoFactor.ChallengeTTL,
+		Mailer:               mailer.NewLogSender(),
+		MailLinkBaseURL:      cfg.Mail.LinkBaseURL,
+		EmailVerificationTTL: cfg.Mail.VerificationTTL,
+		PasswordResetTTL:     cfg.Mail.ResetTTL,
+	})
+
+	ctx := service.WithPrincipal(context.Background(), service.SystemPrincipal())
+
+	adminID, err := services.AdminAccount.CreateAccount(ctx, &service.AdminAccountInput{
+		Username: *username,
+		Password: *password,
+		IsAdmin:  true,
+		Balance:  25000,
+	})
+	if err != nil {
+		log.Fatalf("error creating admin account: %s", err.Error())
+	}
+
+	transportID, err := services.AdminTransport.CreateTransport(ctx, &service.AdminTransportInput{
+		OwnerID:       adminID,
+		CanBeRented:   true,
+		TransportType: "Car",
+		Model:         "model",
+		Color:         "color",
+		Identifier:    "identifier",
+		Latitude:      15.656,
+		Longitude:     47.123,
+		MinutePrice:   15,
+		DayPrice:      15000,
+	})
+	if err != nil {
+		log.Fatalf("error creating sample transport: %s", err.Error())
+	}
+
+	logrus.Infof("created admin account %d and transport %d", adminID, transportID)
+}
@@ -9,11 +9,18 @@ import (
 
 type (
 	Config struct {
-		App      `yaml:"app"`
-		HTTP     `yaml:"http"`
-		Postgres `yaml:"postgres"`
-		JWT      `yaml:"jwt"`
-		Hasher   `yaml:"hasher"`
+		App        `yaml:"app"`
+		HTTP       `yaml:"http"`
+		Postgres   `yaml:"postgres"`
+		JWT        `yaml:"jwt"`
+		Hasher     `yaml:"hasher"`
+		Validation `yaml:"validation"`
+		TwoFactor  `yaml:"two_factor"`
+		Lockout    `yaml:"lockout"`
+		Mail       `yaml:"mail"`
+		OIDC       `yaml:"oidc"`
+		Storage    `yaml:"storage"`
+		KYC        `yaml:"kyc"`
 	}
 
 	App struct {
@@ -22,7 +29,11 @@ type (
 	}
 
 	HTTP struct {
-		Port string `yaml:"port"`
+		Port            string        `yaml:"port"`
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+		// TrustedProxies lists the reverse proxies whose client IP headers are
+		// believed, none by default
+		TrustedProxies []string `yaml:"trusted_proxies" env:"HTTP_TRUSTED_PROXIES"`
 	}
 
 	Postgres struct {
@@ -35,12 +46,80 @@ type (
 	}
 
 	JWT struct {
-		SignKey  string        `yaml:"sign_key" env:"SIGN_KEY"`
-		TokenTTL time.Duration `yaml:"token_ttl"`
+		KeysDir            string        `yaml:"keys_dir" env:"JWT_KEYS_DIR" env-default:"keys"`
+		KeyAlgorithm       string        `yaml:"key_algorithm" env-default:"EdDSA"`
+		KeyReloadInterval  time.Duration `yaml:"key_reload_interval" env-default:"1m"`
+		KeyActivationDelay time.Duration `yaml:"key_activation_delay" env-default:"10m"`
+		TokenTTL           time.Duration `yaml:"token_ttl"`
+		RefreshTokenTTL    time.Duration `yaml:"refresh_token_ttl" env-default:"720h"`
 	}
 
 	Hasher struct {
-		Salt string `yaml:"salt" env:"SALT"`
+		Salt        string `yaml:"salt" env:"SALT"`
+		Memory      uint32 `yaml:"memory" env-default:"65536"`
+		Iterations  uint32 `yaml:"iterations" env-default:"3"`
+		Parallelism uint8  `yaml:"parallelism" env-default:"2"`
+		SaltLength  uint32 `yaml:"salt_length" env-default:"16"`
+		KeyLength   uint32 `yaml:"key_length" env-default:"32"`
+	}
+
+	TwoFactor struct {
+		Issuer       string        `yaml:"issuer" env-default:"Simbir.GO"`
+		ChallengeTTL time.Duration `yaml:"challenge_ttl" env-default:"5m"`
+	}
+
+	Lockout struct {
+		UsernameAttempts int           `yaml:"username_attempts" env-default:"5"`
+		IPAttempts       int           `yaml:"ip_attempts" env-default:"20"`
+		BaseDelay        time.Duration `yaml:"base_delay" env-default:"30s"`
+		MaxDelay         time.Duration `yaml:"max_delay" env-default:"1h"`
+		ResetAfter       time.Duration `yaml:"reset_after" env-default:"24h"`
+	}
+
+	Mail struct {
+		// Driver is log, file or smtp
+		Driver          string        `yaml:"driver" env:"MAIL_DRIVER" env-default:"log"`
+		From            string        `yaml:"from" env-default:"Simbir.GO <no-reply@simbir.go>"`
+		Dir             string        `yaml:"dir" env-default:"mail"`
+		SMTPHost        string        `yaml:"smtp_host"`
+		SMTPPort        string        `yaml:"smtp_port" env-default:"587"`
+		SMTPUsername    string        `yaml:"smtp_username"`
+		SMTPPassword    string        `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+		LinkBaseURL     string        `yaml:"link_base_url" env-default:"http://localhost:8080"`
+		VerificationTTL time.Duration `yaml:"verification_ttl" env-default:"24h"`
+		ResetTTL        time.Duration `yaml:"reset_ttl" env-default:"1h"`
+	}
+
+	// OIDC is the external provider users may sign in with
+	OIDC struct {
+		Enabled      bool          `yaml:"enabled" env:"OIDC_ENABLED"`
+		Issuer       string        `yaml:"issuer" env:"OIDC_ISSUER"`
+		ClientID     string        `yaml:"client_id" env:"OIDC_CLIENT_ID"`
+		ClientSecret string        `yaml:"client_secret" env:"OIDC_CLIENT_SECRET"`
+		RedirectURL  string        `yaml:"redirect_url" env-default:"http://localhost:8080/api/Account/OIDC/Callback"`
+		Scopes       []string      `yaml:"scopes" env-default:"openid,profile,email"`
+		StateTTL     time.Duration `yaml:"state_ttl" env-default:"10m"`
+		Timeout      time.Duration `yaml:"timeout" env-default:"10s"`
+	}
+
+	// Storage keeps uploaded files such as license documents
+	Storage struct {
+		Dir string `yaml:"dir" env:"STORAGE_DIR" env-default:"storage"`
+	}
+
+	KYC struct {
+		MaxLicenseSize int64 `yaml:"max_license_size" env-default:"10485760"`
+		MinRiderAge    int   `yaml:"min_rider_age" env-default:"16"`
+	}
+
+	Validation struct {
+		UsernameMinLength int     `yaml:"username_min_length" env-default:"3"`
+		UsernameMaxLength int     `yaml:"username_max_length" env-default:"32"`
+		PasswordMinLength int     `yaml:"password_min_length" env-default:"8"`
+		PasswordMaxLength int     `yaml:"password_max_length" env-default:"72"`
+		TextMaxLength     int     `yaml:"text_max_length" env-default:"255"`
+		MaxPrice          float64 `yaml:"max_price" env-default:"1000000"`
+		MaxSearchRadius   float64 `yaml:"max_search_radius" env-default:"50000"`
 	}
 )
 
@@ -3,11 +3,22 @@ package entity
 import "time"
 
 type Account struct {
-	ID           int64     `db:"id"`
-	Username     string    `db:"username"`
-	PasswordHash string    `db:"password_hash"`
-	IsAdmin      bool      `db:"is_admin"`
-	Balance      int64     `db:"balance"`
-	CreatedAt    time.Time `db:"created_at"`
-	UpdatedAt    time.Time `db:"updated_at"`
+	ID              int64      `db:"id"`
+	Username        string     `db:"username"`
+	PasswordHash    string     `db:"password_hash"`
+	IsAdmin         bool       `db:"is_admin"`
+	Balance         int64      `db:"balance"`
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at"`
+	Email           *string    `db:"email"`
+	EmailVerifiedAt *time.Time `db:"email_verified_at"`
+	ErasedAt        *time.Time `db:"erased_at"`
+	Status          string     `db:"status"`
+	StatusReason    *string    `db:"status_reason"`
+	StatusUntil     *time.Time `db:"status_until"`
+	StatusSetBy     *int64     `db:"status_set_by"`
+	StatusSetAt     *time.Time `db:"status_set_at"`
+	FullName        *string    `db:"full_name"`
+	Phone           *string    `db:"phone"`
+	BirthDate       *time.Time `db:"birth_date"`
 }
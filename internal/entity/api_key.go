@@ -0,0 +1,16 @@
+package entity
+
+import "time"
+
+type APIKey struct {
+	ID         int64      `db:"id"`
+	AccountID  int64      `db:"account_id"`
+	Name       string     `db:"name"`
+	Prefix     string     `db:"prefix"`
+	KeyHash    string     `db:"key_hash"`
+	Scopes     []string   `db:"scopes"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
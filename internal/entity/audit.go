@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+)
+
+// AuditEntry records a change made by an admin or to money. Before and
+// After hold only the fields that changed.
+type AuditEntry struct {
+	ID         int64              `db:"id"`
+	ActorID    *int64             `db:"actor_id"`
+	ActorType  string             `db:"actor_type"`
+	Action     string             `db:"action"`
+	TargetType string             `db:"target_type"`
+	TargetID   *int64             `db:"target_id"`
+	Before     types.NullJSONText `db:"before"`
+	After      types.NullJSONText `db:"after"`
+	IP         string             `db:"ip"`
+	CreatedAt  time.Time          `db:"created_at"`
+}
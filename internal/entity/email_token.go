@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+const (
+	EmailTokenVerifyEmail   = "verify_email"
+	EmailTokenResetPassword = "reset_password"
+)
+
+// EmailToken is a single-use token mailed to an account.
+type EmailToken struct {
+	ID        int64      `db:"id"`
+	AccountID int64      `db:"account_id"`
+	Purpose   string     `db:"purpose"`
+	Email     string     `db:"email"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
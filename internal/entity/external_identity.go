@@ -0,0 +1,26 @@
+package entity
+
+import "time"
+
+// ExternalIdentity links an account to a user of an OpenID Connect provider.
+type ExternalIdentity struct {
+	ID          int64     `db:"id"`
+	AccountID   int64     `db:"account_id"`
+	Issuer      string    `db:"issuer"`
+	Subject     string    `db:"subject"`
+	Email       *string   `db:"email"`
+	CreatedAt   time.Time `db:"created_at"`
+	LastLoginAt time.Time `db:"last_login_at"`
+}
+
+// OIDCLoginState keeps what a login started here needs when the provider
+// redirects back.
+type OIDCLoginState struct {
+	ID           int64      `db:"id"`
+	StateHash    string     `db:"state_hash"`
+	CodeVerifier string     `db:"code_verifier"`
+	Nonce        string     `db:"nonce"`
+	ExpiresAt    time.Time  `db:"expires_at"`
+	UsedAt       *time.Time `db:"used_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
@@ -0,0 +1,24 @@
+package entity
+
+import "time"
+
+const (
+	LicensePending  = "pending"
+	LicenseApproved = "approved"
+	LicenseRejected = "rejected"
+)
+
+// LicenseDocument is a driver license an account uploaded for review. The
+// file is kept in the file storage under StorageKey.
+type LicenseDocument struct {
+	ID           int64      `db:"id"`
+	AccountID    int64      `db:"account_id"`
+	StorageKey   string     `db:"storage_key"`
+	ContentType  string     `db:"content_type"`
+	Size         int64      `db:"size"`
+	Status       string     `db:"status"`
+	RejectReason *string    `db:"reject_reason"`
+	ReviewedBy   *int64     `db:"reviewed_by"`
+	ReviewedAt   *time.Time `db:"reviewed_at"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
@@ -0,0 +1,14 @@
+package entity
+
+import "time"
+
+type RefreshToken struct {
+	ID        int64      `db:"id"`
+	UserID    int64      `db:"user_id"`
+	SessionID int64      `db:"session_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
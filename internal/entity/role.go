@@ -0,0 +1,7 @@
+package entity
+
+type Role struct {
+	Name        string   `db:"name"`
+	Description string   `db:"description"`
+	Permissions []string `db:"permissions"`
+}
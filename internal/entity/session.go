@@ -0,0 +1,13 @@
+package entity
+
+import "time"
+
+type Session struct {
+	ID         int64      `db:"id"`
+	UserID     int64      `db:"user_id"`
+	UserAgent  string     `db:"user_agent"`
+	IP         string     `db:"ip"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt time.Time  `db:"last_used_at"`
+	RevokedAt  *time.Time `db:"revoked_at"`
+}
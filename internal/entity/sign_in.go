@@ -0,0 +1,23 @@
+package entity
+
+import "time"
+
+// SignInFailure counts failed sign ins for a username or an IP address, and
+// those still being checked.
+type SignInFailure struct {
+	Key           string     `db:"key"`
+	Failures      int        `db:"failures"`
+	LastFailureAt time.Time  `db:"last_failure_at"`
+	LockedUntil   *time.Time `db:"locked_until"`
+}
+
+// SignInAttempt is an audit record of a refused sign in.
+type SignInAttempt struct {
+	ID        int64     `db:"id"`
+	Username  string    `db:"username"`
+	AccountID *int64    `db:"account_id"`
+	IP        string    `db:"ip"`
+	UserAgent string    `db:"user_agent"`
+	Reason    string    `db:"reason"`
+	CreatedAt time.Time `db:"created_at"`
+}
@@ -1,7 +1,12 @@
 package entity
 
+import "time"
+
 type Token struct {
-	UserID      int64  `db:"user_id"`
-	TokenString string `db:"token_string"`
-	IsValid     bool   `db:"is_valid"`
+	ID          int64     `db:"id"`
+	UserID      int64     `db:"user_id"`
+	SessionID   *int64    `db:"session_id"`
+	TokenString string    `db:"token_string"`
+	IsValid     bool      `db:"is_valid"`
+	CreatedAt   time.Time `db:"created_at"`
 }
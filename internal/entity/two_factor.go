@@ -0,0 +1,25 @@
+package entity
+
+import "time"
+
+// TwoFactor holds the TOTP secret of an account. It is pending until the
+// first code is confirmed and EnabledAt is set.
+type TwoFactor struct {
+	AccountID    int64      `db:"account_id"`
+	Secret       string     `db:"secret"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+// SignInChallenge is the second step of a sign in with two-factor
+// authentication.
+type SignInChallenge struct {
+	ID        int64      `db:"id"`
+	AccountID int64      `db:"account_id"`
+	TokenHash string     `db:"token_hash"`
+	Attempts  int        `db:"attempts"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
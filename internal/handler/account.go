@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+func (h *Handler) signUp(c *gin.Context) {
+	var input service.AccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	id, err := h.services.Account.SignUp(c.Request.Context(), &input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, idResponse{ID: id})
+}
+
+func (h *Handler) signIn(c *gin.Context) {
+	var input service.AccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	tokens, err := h.services.Account.SignIn(c.Request.Context(), &input, &service.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	})
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) refresh(c *gin.Context) {
+	var input service.RefreshInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	tokens, err := h.services.Account.Refresh(c.Request.Context(), input.RefreshToken)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) signOut(c *gin.Context) {
+	if err := h.services.Account.SignOut(c.Request.Context(), c.GetString(tokenCtx)); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) me(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	account, err := h.services.Account.GetAccount(c.Request.Context(), userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+func (h *Handler) updateAccount(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	var input service.AccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	if err := h.services.Account.UpdateAccount(c.Request.Context(), userID, &input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) listSessions(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	sessions, err := h.services.Account.ListSessions(c.Request.Context(), userID, c.GetInt64(sessionCtx))
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *Handler) revokeSession(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	sessionID, err := getIDParam(c, "sessionId")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.services.Account.RevokeSession(c.Request.Context(), userID, sessionID); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) verifySignIn(c *gin.Context) {
+	var input service.TwoFactorSignInInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	tokens, err := h.services.Account.VerifySignIn(c.Request.Context(), &input, &service.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	})
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) twoFactorStatus(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	status, err := h.services.Account.TwoFactorStatus(c.Request.Context(), userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+func (h *Handler) enrollTwoFactor(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	enrollment, err := h.services.Account.EnrollTwoFactor(c.Request.Context(), userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *Handler) confirmTwoFactor(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	var input service.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	codes, err := h.services.Account.ConfirmTwoFactor(c.Request.Context(), userID, input.Code)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+func (h *Handler) regenerateRecoveryCodes(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	var input service.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	codes, err := h.services.Account.RegenerateRecoveryCodes(c.Request.Context(), userID, input.Code, &service.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	})
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+func (h *Handler) disableTwoFactor(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	var input service.TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	err = h.services.Account.DisableTwoFactor(c.Request.Context(), userID, input.Code, &service.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	})
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
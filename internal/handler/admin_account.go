@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+func (h *Handler) adminListAccounts(c *gin.Context) {
+	start, count, err := getPagination(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	accounts, err := h.services.AdminAccount.ListAccounts(c.Request.Context(), count, start)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, accounts)
+}
+
+func (h *Handler) adminGetAccount(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	account, err := h.services.AdminAccount.GetAccount(c.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, account)
+}
+
+func (h *Handler) adminCreateAccount(c *gin.Context) {
+	var input service.AdminAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	id, err := h.services.AdminAccount.CreateAccount(c.Request.Context(), &input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, idResponse{ID: id})
+}
+
+func (h *Handler) adminUpdateAccount(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	var input service.AdminAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	if err := h.services.AdminAccount.UpdateAccount(c.Request.Context(), id, &input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) adminDeleteAccount(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.services.AdminAccount.DeleteAccount(c.Request.Context(), id); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) adminListSessions(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	sessions, err := h.services.AdminAccount.ListSessions(c.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *Handler) adminRevokeSessions(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.services.AdminAccount.RevokeSessions(c.Request.Context(), id); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) adminUpdateBalance(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	var input service.AdminBalanceInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	if err := h.services.AdminAccount.UpdateBalance(c.Request.Context(), id, &input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) adminUnlockAccount(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.services.AdminAccount.Unlock(c.Request.Context(), id); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) adminSetAccountStatus(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	var input service.AccountStatusInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	if err := h.services.AdminAccount.SetStatus(c.Request.Context(), id, &input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) adminListSignInAttempts(c *gin.Context) {
+	start, count, err := getPagination(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	attempts, err := h.services.AdminAccount.ListSignInAttempts(c.Request.Context(), c.Query("username"), c.Query("ip"), count, start)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, attempts)
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+func (h *Handler) adminListAuditLog(c *gin.Context) {
+	start, count, err := getPagination(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	input := service.AuditLogQuery{
+		Action:     c.Query("action"),
+		TargetType: c.Query("targetType"),
+	}
+
+	if input.ActorID, err = getOptionalIDQuery(c, "actorId"); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if input.TargetID, err = getOptionalIDQuery(c, "targetId"); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if input.From, err = getOptionalTimeQuery(c, "from"); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if input.To, err = getOptionalTimeQuery(c, "to"); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	entries, err := h.services.AdminAudit.ListAuditLog(c.Request.Context(), &input, count, start)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+func (h *Handler) adminListLicenses(c *gin.Context) {
+	start, count, err := getPagination(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	licenses, err := h.services.AdminLicense.ListLicenses(c.Request.Context(), c.Query("status"), count, start)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, licenses)
+}
+
+func (h *Handler) adminGetLicenseFile(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	file, err := h.services.AdminLicense.GetLicenseFile(c.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+	defer file.Content.Close()
+
+	c.DataFromReader(http.StatusOK, file.Size, file.ContentType, file.Content, map[string]string{
+		"Content-Disposition":    `attachment; filename="` + file.Name + `"`,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+func (h *Handler) adminApproveLicense(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.services.AdminLicense.ApproveLicense(c.Request.Context(), id); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) adminRejectLicense(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	var input service.LicenseRejectInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	if err := h.services.AdminLicense.RejectLicense(c.Request.Context(), id, &input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
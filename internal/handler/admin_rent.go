@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+func (h *Handler) adminGetRent(c *gin.Context) {
+	id, err := getIDParam(c, "rentId")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	rent, err := h.services.AdminRent.GetRent(c.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rent)
+}
+
+func (h *Handler) adminUserHistory(c *gin.Context) {
+	userID, err := getIDParam(c, "userId")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	rents, err := h.services.AdminRent.ListRentsByUser(c.Request.Context(), userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rents)
+}
+
+func (h *Handler) adminTransportHistory(c *gin.Context) {
+	transportID, err := getIDParam(c, "transportId")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	rents, err := h.services.AdminRent.ListRentsByTransport(c.Request.Context(), transportID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rents)
+}
+
+func (h *Handler) adminStartRent(c *gin.Context) {
+	var input service.AdminRentInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	id, err := h.services.AdminRent.StartRent(c.Request.Context(), &input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, idResponse{ID: id})
+}
+
+func (h *Handler) adminEndRent(c *gin.Context) {
+	id, err := getIDParam(c, "rentId")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	lat, long, err := getPositionQuery(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.services.AdminRent.EndRent(c.Request.Context(), id, lat, long); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) adminDeleteRent(c *gin.Context) {
+	id, err := getIDParam(c, "rentId")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.services.AdminRent.DeleteRent(c.Request.Context(), id); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
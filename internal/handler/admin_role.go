@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+func (h *Handler) adminListRoles(c *gin.Context) {
+	roles, err := h.services.AdminRole.ListRoles(c.Request.Context())
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+func (h *Handler) adminListAccountRoles(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	roles, err := h.services.AdminRole.ListAccountRoles(c.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+func (h *Handler) adminAssignRole(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	var input service.RoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	if err := h.services.AdminRole.AssignRole(c.Request.Context(), id, input.Role); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) adminUnassignRole(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.services.AdminRole.UnassignRole(c.Request.Context(), id, c.Param("role")); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
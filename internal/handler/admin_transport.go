@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+func (h *Handler) adminListTransport(c *gin.Context) {
+	start, count, err := getPagination(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	transportType := c.DefaultQuery("transportType", "All")
+
+	transports, err := h.services.AdminTransport.ListTransport(c.Request.Context(), transportType, count, start)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transports)
+}
+
+func (h *Handler) adminGetTransport(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	transport, err := h.services.AdminTransport.GetTransport(c.Request.Context(), id)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transport)
+}
+
+func (h *Handler) adminCreateTransport(c *gin.Context) {
+	var input service.AdminTransportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	id, err := h.services.AdminTransport.CreateTransport(c.Request.Context(), &input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, idResponse{ID: id})
+}
+
+func (h *Handler) adminUpdateTransport(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	var input service.AdminTransportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	if err := h.services.AdminTransport.UpdateTransport(c.Request.Context(), id, &input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) adminDeleteTransport(c *gin.Context) {
+	id, err := getIDParam(c, "id")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.services.AdminTransport.DeleteTransport(c.Request.Context(), id); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+func (h *Handler) listAPIKeys(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	keys, err := h.services.APIKey.ListAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+func (h *Handler) createAPIKey(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	var input service.APIKeyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	key, err := h.services.APIKey.CreateAPIKey(c.Request.Context(), userID, &input)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+func (h *Handler) revokeAPIKey(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	keyID, err := getIDParam(c, "keyId")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	if err := h.services.APIKey.RevokeAPIKey(c.Request.Context(), userID, keyID); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -1 +1,164 @@
 package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+type Handler struct {
+	services *service.Services
+	// addresses or CIDR ranges of the reverse proxies in front of the API,
+	// only their X-Forwarded-For and X-Real-IP headers are believed
+	trustedProxies []string
+}
+
+func NewHandler(services *service.Services, trustedProxies []string) *Handler {
+	return &Handler{services: services, trustedProxies: trustedProxies}
+}
+
+func (h *Handler) InitRoutes() (*gin.Engine, error) {
+	router := gin.New()
+
+	// the client IP keys the sign in lockout, a header anyone can send must
+	// not choose it
+	if err := router.SetTrustedProxies(h.trustedProxies); err != nil {
+		return nil, fmt.Errorf("trusted proxies: %w", err)
+	}
+
+	router.Use(gin.CustomRecovery(h.recovery))
+	router.NoRoute(h.noRoute)
+
+	api := router.Group("/api")
+	{
+		account := api.Group("/Account")
+		{
+			account.POST("/SignUp", h.signUp)
+			account.POST("/SignIn", h.signIn)
+			account.POST("/SignIn/TwoFactor", h.verifySignIn)
+			account.POST("/Refresh", h.refresh)
+			account.GET("/OIDC/Login", h.startOIDCLogin)
+			account.GET("/OIDC/Callback", h.completeOIDCLogin)
+			account.POST("/Email/Verify", h.verifyEmail)
+			account.POST("/ForgotPassword", h.forgotPassword)
+			account.POST("/ResetPassword", h.resetPassword)
+
+			authorized := account.Group("", h.userIdentity)
+			{
+				authorized.POST("/SignOut", h.signOut)
+				authorized.GET("/Me", h.me)
+				authorized.PUT("/Update", h.updateAccount)
+				authorized.GET("/Sessions", h.listSessions)
+				authorized.DELETE("/Sessions/:sessionId", h.revokeSession)
+				authorized.GET("/Identities", h.listExternalIdentities)
+				authorized.PUT("/Email", h.setEmail)
+				authorized.POST("/Email/Resend", h.resendEmailVerification)
+				authorized.GET("/TwoFactor", h.twoFactorStatus)
+				authorized.POST("/TwoFactor/Enroll", h.enrollTwoFactor)
+				authorized.POST("/TwoFactor/Confirm", h.confirmTwoFactor)
+				authorized.POST("/TwoFactor/RecoveryCodes", h.regenerateRecoveryCodes)
+				authorized.POST("/TwoFactor/Disable", h.disableTwoFactor)
+				authorized.GET("/ApiKeys", h.listAPIKeys)
+				authorized.POST("/ApiKeys", h.createAPIKey)
+				authorized.DELETE("/ApiKeys/:keyId", h.revokeAPIKey)
+				authorized.GET("/Export", h.exportAccount)
+				authorized.POST("/Erase", h.eraseAccount)
+				authorized.GET("/Profile", h.getProfile)
+				authorized.PUT("/Profile", h.updateProfile)
+				authorized.GET("/License", h.getLicense)
+				authorized.POST("/License", h.uploadLicense)
+			}
+		}
+
+		transport := api.Group("/Transport")
+		{
+			transport.GET("/:id", h.getTransport)
+
+			authorized := transport.Group("", h.userIdentity)
+			{
+				authorized.POST("", h.createTransport)
+				authorized.PUT("/:id", h.updateTransport)
+				authorized.PUT("/:id/Position", h.updateTransportPosition)
+				authorized.DELETE("/:id", h.deleteTransport)
+			}
+		}
+
+		rent := api.Group("/Rent")
+		{
+			rent.GET("/Transport", h.searchTransport)
+
+			authorized := rent.Group("", h.userIdentity)
+			{
+				authorized.GET("/:rentId", h.getRent)
+				authorized.GET("/MyHistory", h.myHistory)
+				authorized.GET("/TransportHistory/:transportId", h.transportHistory)
+				authorized.POST("/New/:transportId", h.startRent)
+				authorized.POST("/End/:rentId", h.endRent)
+			}
+		}
+
+		payment := api.Group("/Payment", h.userIdentity)
+		{
+			payment.POST("/Hesoyam/:accountId", h.hesoyam)
+		}
+
+		admin := api.Group("/Admin", h.userIdentity, h.adminIdentity)
+		{
+			adminAccount := admin.Group("/Account")
+			{
+				adminAccount.GET("", h.adminListAccounts)
+				adminAccount.GET("/:id", h.adminGetAccount)
+				adminAccount.POST("", h.adminCreateAccount)
+				adminAccount.PUT("/:id", h.adminUpdateAccount)
+				adminAccount.DELETE("/:id", h.adminDeleteAccount)
+				adminAccount.GET("/:id/Sessions", h.adminListSessions)
+				adminAccount.DELETE("/:id/Sessions", h.adminRevokeSessions)
+				adminAccount.PUT("/:id/Balance", h.adminUpdateBalance)
+				adminAccount.POST("/:id/Unlock", h.adminUnlockAccount)
+				adminAccount.PUT("/:id/Status", h.adminSetAccountStatus)
+				adminAccount.GET("/:id/Roles", h.adminListAccountRoles)
+				adminAccount.POST("/:id/Roles", h.adminAssignRole)
+				adminAccount.DELETE("/:id/Roles/:role", h.adminUnassignRole)
+			}
+
+			admin.GET("/Role", h.adminListRoles)
+			admin.GET("/SignInAttempts", h.adminListSignInAttempts)
+			admin.GET("/AuditLog", h.adminListAuditLog)
+
+			adminLicense := admin.Group("/License")
+			{
+				adminLicense.GET("", h.adminListLicenses)
+				adminLicense.GET("/:id/File", h.adminGetLicenseFile)
+				adminLicense.POST("/:id/Approve", h.adminApproveLicense)
+				adminLicense.POST("/:id/Reject", h.adminRejectLicense)
+			}
+
+			adminTransport := admin.Group("/Transport")
+			{
+				adminTransport.GET("", h.adminListTransport)
+				adminTransport.GET("/:id", h.adminGetTransport)
+				adminTransport.POST("", h.adminCreateTransport)
+				adminTransport.PUT("/:id", h.adminUpdateTransport)
+				adminTransport.DELETE("/:id", h.adminDeleteTransport)
+			}
+
+			admin.GET("/UserHistory/:userId", h.adminUserHistory)
+			admin.GET("/TransportHistory/:transportId", h.adminTransportHistory)
+
+			adminRent := admin.Group("/Rent")
+			{
+				adminRent.GET("/:rentId", h.adminGetRent)
+				adminRent.POST("", h.adminStartRent)
+				adminRent.POST("/End/:rentId", h.adminEndRent)
+				adminRent.DELETE("/:rentId", h.adminDeleteRent)
+			}
+		}
+	}
+
+	router.GET("/.well-known/jwks.json", h.jwks)
+
+	h.initDocs(router)
+
+	return router, nil
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+// signInRecorder keeps the client of the last sign in, whose IP keys the
+// lockout.
+type signInRecorder struct {
+	service.Account
+
+	client *service.ClientInfo
+}
+
+func (s *signInRecorder) SignIn(_ context.Context, _ *service.AccountInput, client *service.ClientInfo) (*service.SignInOutput, error) {
+	s.client = client
+	return &service.SignInOutput{}, nil
+}
+
+func TestSignInClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		remoteAddr     string
+		headers        map[string]string
+		want           string
+	}{
+		{
+			name:       "direct",
+			remoteAddr: "198.51.100.7:40000",
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "forged X-Forwarded-For",
+			remoteAddr: "198.51.100.7:40000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.1"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "forged X-Real-IP",
+			remoteAddr: "198.51.100.7:40000",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.1"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:           "forged behind a proxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "198.51.100.7:40000",
+			headers:        map[string]string{"X-Forwarded-For": "203.0.113.1"},
+			want:           "198.51.100.7",
+		},
+		{
+			name:           "through the trusted proxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:40000",
+			headers:        map[string]string{"X-Forwarded-For": "203.0.113.1"},
+			want:           "203.0.113.1",
+		},
+		{
+			name:           "forged before the trusted proxy",
+			trustedProxies: []string{"10.0.0.0/8"},
+			remoteAddr:     "10.0.0.2:40000",
+			headers:        map[string]string{"X-Forwarded-For": "192.0.2.99, 203.0.113.1"},
+			want:           "203.0.113.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := &signInRecorder{}
+			router, err := NewHandler(&service.Services{Account: accounts}, tt.trustedProxies).InitRoutes()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/Account/SignIn", strings.NewReader(`{"username":"jane","password":"correct horse"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", rec.Code, rec.Body)
+			}
+
+			if accounts.client == nil || accounts.client.IP != tt.want {
+				t.Errorf("signed in from %+v, want IP %s", accounts.client, tt.want)
+			}
+		})
+	}
+}
+
+func TestInitRoutesRejectsInvalidProxies(t *testing.T) {
+	if _, err := NewHandler(&service.Services{}, []string{"not an address"}).InitRoutes(); err == nil {
+		t.Error("InitRoutes accepted an invalid trusted proxy")
+	}
+}
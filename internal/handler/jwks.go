@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jwksMaxAge is below the key activation delay, so verifiers see a new key
+// before any token is signed with it.
+const jwksMaxAge = "public, max-age=300"
+
+func (h *Handler) jwks(c *gin.Context) {
+	c.Header("Cache-Control", jwksMaxAge)
+	c.JSON(http.StatusOK, h.services.Account.PublicKeys())
+}
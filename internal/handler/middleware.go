@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	apiKeyHeader        = "X-API-Key"
+	userCtx             = "userID"
+	adminCtx            = "isAdmin"
+	tokenCtx            = "token"
+	sessionCtx          = "sessionID"
+)
+
+func (h *Handler) userIdentity(c *gin.Context) {
+	if key := c.GetHeader(apiKeyHeader); key != "" {
+		h.apiKeyIdentity(c, key)
+		return
+	}
+
+	header := c.GetHeader(authorizationHeader)
+	if header == "" {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, "empty auth header")
+		return
+	}
+
+	headerParts := strings.Split(header, " ")
+	if len(headerParts) != 2 || headerParts[0] != "Bearer" || headerParts[1] == "" {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, "invalid auth header")
+		return
+	}
+
+	claims, err := h.services.Account.ValidateToken(c.Request.Context(), headerParts[1])
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	// roles may change while the token is valid, so permissions are loaded per request
+	principal, err := h.services.Access.Principal(c.Request.Context(), claims.UserID)
+	if errors.Is(err, service.ErrAccountNotFound) {
+		newServiceErrorResponse(c, service.ErrInvalidToken)
+		return
+	}
+
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	principal.IP = c.ClientIP()
+	c.Request = c.Request.WithContext(service.WithPrincipal(c.Request.Context(), principal))
+	c.Set(userCtx, claims.UserID)
+	c.Set(adminCtx, principal.IsStaff())
+	c.Set(tokenCtx, headerParts[1])
+	c.Set(sessionCtx, claims.SessionID)
+}
+
+// apiKeyIdentity authenticates machine clients. Keys only open routes that
+// declare a scope in the specification, and only if the key was granted it.
+func (h *Handler) apiKeyIdentity(c *gin.Context, key string) {
+	principal, err := h.services.APIKey.Authenticate(c.Request.Context(), key)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	scope := routeScopes[c.Request.Method+" "+c.FullPath()]
+	if scope == "" {
+		newErrorResponse(c, http.StatusForbidden, codeForbidden, "route is not available to api keys")
+		return
+	}
+
+	if !principal.HasScope(scope) {
+		newErrorResponse(c, http.StatusForbidden, codeForbidden, "api key lacks the "+scope+" scope")
+		return
+	}
+
+	principal.IP = c.ClientIP()
+	c.Request = c.Request.WithContext(service.WithPrincipal(c.Request.Context(), principal))
+	c.Set(userCtx, principal.UserID)
+	c.Set(adminCtx, false)
+}
+
+// adminIdentity must be chained after userIdentity. It only lets staff in,
+// services check the permission of each admin method.
+func (h *Handler) adminIdentity(c *gin.Context) {
+	if principal, ok := service.PrincipalFromContext(c.Request.Context()); ok && principal.TwoFactorRequired {
+		newServiceErrorResponse(c, service.ErrTwoFactorRequired)
+		return
+	}
+
+	if !c.GetBool(adminCtx) {
+		newErrorResponse(c, http.StatusForbidden, codeForbidden, "staff role required")
+		return
+	}
+}
+
+func getUserID(c *gin.Context) (int64, error) {
+	id, ok := c.Get(userCtx)
+	if !ok {
+		return 0, errors.New("user id not found")
+	}
+
+	idInt, ok := id.(int64)
+	if !ok {
+		return 0, errors.New("user id is of invalid type")
+	}
+
+	return idInt, nil
+}
+
+func hasPermission(c *gin.Context, permission string) bool {
+	principal, ok := service.PrincipalFromContext(c.Request.Context())
+	return ok && principal.Can(permission)
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+func (h *Handler) startOIDCLogin(c *gin.Context) {
+	login, err := h.services.OIDC.StartLogin(c.Request.Context())
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, login)
+}
+
+// completeOIDCLogin is the redirect URL registered at the provider.
+func (h *Handler) completeOIDCLogin(c *gin.Context) {
+	input := &service.OIDCCallbackInput{
+		Code:  c.Query("code"),
+		State: c.Query("state"),
+		Error: c.Query("error"),
+	}
+
+	tokens, err := h.services.OIDC.CompleteLogin(c.Request.Context(), input, &service.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	})
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, tokens)
+}
+
+func (h *Handler) listExternalIdentities(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	identities, err := h.services.OIDC.ListIdentities(c.Request.Context(), userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, identities)
+}
@@ -0,0 +1,479 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/realdanielursul/simbir-go/internal/service"
+	"github.com/realdanielursul/simbir-go/pkg/jwtkeys"
+)
+
+const apiVersion = "1.0.0"
+
+type access int
+
+const (
+	public access = iota
+	user
+	admin
+)
+
+type queryParam struct {
+	name     string
+	typ      string
+	required bool
+	enum     []string
+}
+
+// fileUpload documents a multipart/form-data body carrying a single file.
+type fileUpload struct {
+	field string
+}
+
+// fileDownload documents a response that is a file of one of the types.
+type fileDownload struct {
+	contentTypes []string
+}
+
+// route documents a single endpoint registered in InitRoutes. The
+// specification is generated from this table, openapi_test.go checks it
+// against the router.
+type route struct {
+	method  string
+	path    string
+	tag     string
+	summary string
+	access  access
+	// scope lets API keys granted it call the route
+	scope    string
+	query    []queryParam
+	body     any
+	status   int
+	response any
+}
+
+var (
+	positionQuery = []queryParam{
+		{name: "lat", typ: "number", required: true},
+		{name: "long", typ: "number", required: true},
+	}
+	paginationQuery = []queryParam{
+		{name: "start", typ: "integer"},
+		{name: "count", typ: "integer"},
+	}
+	transportTypes   = []string{"All", "Car", "Bike", "Scooter"}
+	rentTypes        = []string{"Minutes", "Days"}
+	auditTargetTypes = []string{service.AuditTargetAccount, service.AuditTargetTransport, service.AuditTargetRent, service.AuditTargetLicense}
+	licenseStatuses  = []string{service.LicenseStatusPending, service.LicenseStatusApproved, service.LicenseStatusRejected}
+)
+
+var routes = []route{
+	{method: http.MethodPost, path: "/api/Account/SignUp", tag: "Account", summary: "Register a new account", body: service.AccountInput{}, status: http.StatusCreated, response: idResponse{}},
+	{method: http.MethodPost, path: "/api/Account/SignIn", tag: "Account", summary: "Get an access and a refresh token, or a challenge if two-factor authentication is enabled", body: service.AccountInput{}, status: http.StatusOK, response: service.SignInOutput{}},
+	{method: http.MethodPost, path: "/api/Account/SignIn/TwoFactor", tag: "Account", summary: "Complete a sign in challenge with a TOTP or recovery code", body: service.TwoFactorSignInInput{}, status: http.StatusOK, response: service.TokenOutput{}},
+	{method: http.MethodPost, path: "/api/Account/Refresh", tag: "Account", summary: "Rotate the refresh token and get a new access token", body: service.RefreshInput{}, status: http.StatusOK, response: service.TokenOutput{}},
+	{method: http.MethodGet, path: "/api/Account/OIDC/Login", tag: "Account", summary: "Start a sign in with the external OpenID Connect provider", status: http.StatusOK, response: service.OIDCLoginOutput{}},
+	{method: http.MethodGet, path: "/api/Account/OIDC/Callback", tag: "Account", summary: "Complete an external sign in, the provider redirects here. The account is created on the first sign in", query: []queryParam{{name: "code", typ: "string"}, {name: "state", typ: "string", required: true}, {name: "error", typ: "string"}}, status: http.StatusOK, response: service.SignInOutput{}},
+	{method: http.MethodPost, path: "/api/Account/Email/Verify", tag: "Account", summary: "Verify an email with the token from the verification mail", body: service.EmailTokenInput{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/Account/ForgotPassword", tag: "Account", summary: "Mail a password reset link if the email is verified, the response does not tell whether it is", body: service.EmailInput{}, status: http.StatusAccepted},
+	{method: http.MethodPost, path: "/api/Account/ResetPassword", tag: "Account", summary: "Set a new password with the token from the reset mail and sign out everywhere", body: service.ResetPasswordInput{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/Account/SignOut", tag: "Account", summary: "Invalidate the current token and its refresh token", access: user, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Account/Me", tag: "Account", summary: "Get the current account", access: user, scope: service.ScopeAccountRead, status: http.StatusOK, response: service.AccountOutput{}},
+	{method: http.MethodPut, path: "/api/Account/Update", tag: "Account", summary: "Update the current account", access: user, body: service.AccountInput{}, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Account/Sessions", tag: "Account", summary: "List active sessions of the current account", access: user, status: http.StatusOK, response: []service.SessionOutput{}},
+	{method: http.MethodDelete, path: "/api/Account/Sessions/:sessionId", tag: "Account", summary: "Revoke a session", access: user, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Account/Identities", tag: "Account", summary: "List external identities linked to the current account", access: user, status: http.StatusOK, response: []service.ExternalIdentityOutput{}},
+	{method: http.MethodPut, path: "/api/Account/Email", tag: "Account", summary: "Set the email of the current account and mail a verification link", access: user, body: service.EmailInput{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/Account/Email/Resend", tag: "Account", summary: "Mail a new verification link", access: user, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Account/TwoFactor", tag: "Account", summary: "Get the two-factor authentication status", access: user, status: http.StatusOK, response: service.TwoFactorStatus{}},
+	{method: http.MethodPost, path: "/api/Account/TwoFactor/Enroll", tag: "Account", summary: "Generate a TOTP secret to confirm", access: user, status: http.StatusOK, response: service.TwoFactorEnrollment{}},
+	{method: http.MethodPost, path: "/api/Account/TwoFactor/Confirm", tag: "Account", summary: "Enable two-factor authentication with a code of the new secret", access: user, body: service.TwoFactorCodeInput{}, status: http.StatusOK, response: service.RecoveryCodesOutput{}},
+	{method: http.MethodPost, path: "/api/Account/TwoFactor/RecoveryCodes", tag: "Account", summary: "Replace the recovery codes", access: user, body: service.TwoFactorCodeInput{}, status: http.StatusOK, response: service.RecoveryCodesOutput{}},
+	{method: http.MethodPost, path: "/api/Account/TwoFactor/Disable", tag: "Account", summary: "Disable two-factor authentication, not allowed for staff", access: user, body: service.TwoFactorCodeInput{}, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Account/ApiKeys", tag: "Account", summary: "List API keys of the current account", access: user, status: http.StatusOK, response: []service.APIKeyOutput{}},
+	{method: http.MethodPost, path: "/api/Account/ApiKeys", tag: "Account", summary: "Create an API key, the key is only returned once", access: user, body: service.APIKeyInput{}, status: http.StatusCreated, response: service.APIKeyOutput{}},
+	{method: http.MethodDelete, path: "/api/Account/ApiKeys/:keyId", tag: "Account", summary: "Revoke an API key", access: user, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Account/Export", tag: "Account", summary: "Download the data kept about the current account, as JSON or a ZIP of JSON files", access: user, query: []queryParam{{name: "format", typ: "string", enum: exportFormats}}, status: http.StatusOK, response: service.AccountExport{}},
+	{method: http.MethodPost, path: "/api/Account/Erase", tag: "Account", summary: "Erase the personal data of the current account, rents and payments are kept anonymized", access: user, body: service.EraseAccountInput{}, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Account/Profile", tag: "Account", summary: "Get the profile of the current account", access: user, status: http.StatusOK, response: service.ProfileOutput{}},
+	{method: http.MethodPut, path: "/api/Account/Profile", tag: "Account", summary: "Replace the profile of the current account, the birth date is locked once a license is approved", access: user, body: service.ProfileInput{}, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Account/License", tag: "Account", summary: "Get the review status of the last uploaded driver license", access: user, status: http.StatusOK, response: service.LicenseOutput{}},
+	{method: http.MethodPost, path: "/api/Account/License", tag: "Account", summary: "Upload a driver license as JPEG, PNG or PDF for review, renting cars needs an approved one", access: user, body: fileUpload{field: licenseFileField}, status: http.StatusCreated, response: service.LicenseOutput{}},
+
+	{method: http.MethodGet, path: "/api/Transport/:id", tag: "Transport", summary: "Get transport by id", status: http.StatusOK, response: service.TransportOutput{}},
+	{method: http.MethodPost, path: "/api/Transport", tag: "Transport", summary: "Add own transport", access: user, scope: service.ScopeTransportWrite, body: service.TransportInput{}, status: http.StatusCreated, response: idResponse{}},
+	{method: http.MethodPut, path: "/api/Transport/:id", tag: "Transport", summary: "Update own transport", access: user, scope: service.ScopeTransportWrite, body: service.TransportInput{}, status: http.StatusOK},
+	{method: http.MethodPut, path: "/api/Transport/:id/Position", tag: "Transport", summary: "Report the position of own transport", access: user, scope: service.ScopeTransportPosition, body: service.PositionInput{}, status: http.StatusOK},
+	{method: http.MethodDelete, path: "/api/Transport/:id", tag: "Transport", summary: "Delete own transport that is not rented, its rents are kept", access: user, scope: service.ScopeTransportWrite, status: http.StatusOK},
+
+	{method: http.MethodGet, path: "/api/Rent/Transport", tag: "Rent", summary: "Search transport available for rent within radius meters of the position, nearest first", query: append(positionQuery, queryParam{name: "radius", typ: "number", required: true}, queryParam{name: "type", typ: "string", enum: transportTypes}), status: http.StatusOK, response: []service.TransportOutput{}},
+	{method: http.MethodGet, path: "/api/Rent/:rentId", tag: "Rent", summary: "Get rent made by or on transport owned by the caller", access: user, scope: service.ScopeRentRead, status: http.StatusOK, response: service.RentOutput{}},
+	{method: http.MethodGet, path: "/api/Rent/MyHistory", tag: "Rent", summary: "Get rent history of the caller", access: user, scope: service.ScopeRentRead, status: http.StatusOK, response: []service.RentOutput{}},
+	{method: http.MethodGet, path: "/api/Rent/TransportHistory/:transportId", tag: "Rent", summary: "Get rent history of own transport", access: user, scope: service.ScopeRentRead, status: http.StatusOK, response: []service.RentOutput{}},
+	{method: http.MethodPost, path: "/api/Rent/New/:transportId", tag: "Rent", summary: "Start a rent", access: user, scope: service.ScopeRentWrite, query: []queryParam{{name: "rentType", typ: "string", required: true, enum: rentTypes}}, status: http.StatusCreated, response: idResponse{}},
+	{method: http.MethodPost, path: "/api/Rent/End/:rentId", tag: "Rent", summary: "End a rent at the given position", access: user, scope: service.ScopeRentWrite, query: positionQuery, status: http.StatusOK},
+
+	{method: http.MethodPost, path: "/api/Payment/Hesoyam/:accountId", tag: "Payment", summary: "Add 250 000 to own balance, or to any balance for admins", access: user, status: http.StatusOK},
+
+	{method: http.MethodGet, path: "/api/Admin/Account", tag: "AdminAccount", summary: "List accounts", access: admin, query: paginationQuery, status: http.StatusOK, response: []service.AdminAccountOutput{}},
+	{method: http.MethodGet, path: "/api/Admin/Account/:id", tag: "AdminAccount", summary: "Get account by id", access: admin, status: http.StatusOK, response: service.AdminAccountOutput{}},
+	{method: http.MethodPost, path: "/api/Admin/Account", tag: "AdminAccount", summary: "Create an account", access: admin, body: service.AdminAccountInput{}, status: http.StatusCreated, response: idResponse{}},
+	{method: http.MethodPut, path: "/api/Admin/Account/:id", tag: "AdminAccount", summary: "Update an account", access: admin, body: service.AdminAccountInput{}, status: http.StatusOK},
+	{method: http.MethodDelete, path: "/api/Admin/Account/:id", tag: "AdminAccount", summary: "Erase an account, its rents and payments are kept anonymized", access: admin, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Admin/Account/:id/Sessions", tag: "AdminAccount", summary: "List active sessions of an account", access: admin, status: http.StatusOK, response: []service.SessionOutput{}},
+	{method: http.MethodPut, path: "/api/Admin/Account/:id/Balance", tag: "AdminAccount", summary: "Set the balance of an account", access: admin, body: service.AdminBalanceInput{}, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/Admin/Account/:id/Unlock", tag: "AdminAccount", summary: "Lift a sign in lockout of an account", access: admin, status: http.StatusOK},
+	{method: http.MethodPut, path: "/api/Admin/Account/:id/Status", tag: "AdminAccount", summary: "Suspend, ban or reactivate an account, blocking signs it out and ends its rents", access: admin, body: service.AccountStatusInput{}, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Admin/Account/:id/Roles", tag: "AdminAccount", summary: "List roles of an account", access: admin, status: http.StatusOK, response: []string{}},
+	{method: http.MethodPost, path: "/api/Admin/Account/:id/Roles", tag: "AdminAccount", summary: "Assign a role to an account", access: admin, body: service.RoleInput{}, status: http.StatusOK},
+	{method: http.MethodDelete, path: "/api/Admin/Account/:id/Roles/:role", tag: "AdminAccount", summary: "Remove a role from an account", access: admin, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Admin/Role", tag: "AdminAccount", summary: "List roles and their permissions", access: admin, status: http.StatusOK, response: []service.RoleOutput{}},
+	{method: http.MethodDelete, path: "/api/Admin/Account/:id/Sessions", tag: "AdminAccount", summary: "Sign an account out on every device", access: admin, status: http.StatusOK},
+	{method: http.MethodGet, path: "/api/Admin/SignInAttempts", tag: "AdminAccount", summary: "List refused sign in attempts, newest first", access: admin, query: append(paginationQuery, queryParam{name: "username", typ: "string"}, queryParam{name: "ip", typ: "string"}), status: http.StatusOK, response: []service.SignInAttemptOutput{}},
+
+	{method: http.MethodGet, path: "/api/Admin/Transport", tag: "AdminTransport", summary: "List transport", access: admin, query: append(paginationQuery, queryParam{name: "transportType", typ: "string", enum: transportTypes}), status: http.StatusOK, response: []service.TransportOutput{}},
+	{method: http.MethodGet, path: "/api/Admin/Transport/:id", tag: "AdminTransport", summary: "Get transport by id", access: admin, status: http.StatusOK, response: service.TransportOutput{}},
+	{method: http.MethodPost, path: "/api/Admin/Transport", tag: "AdminTransport", summary: "Create transport", access: admin, body: service.AdminTransportInput{}, status: http.StatusCreated, response: idResponse{}},
+	{method: http.MethodPut, path: "/api/Admin/Transport/:id", tag: "AdminTransport", summary: "Update transport", access: admin, body: service.AdminTransportInput{}, status: http.StatusOK},
+	{method: http.MethodDelete, path: "/api/Admin/Transport/:id", tag: "AdminTransport", summary: "Delete transport that is not rented, its rents are kept", access: admin, status: http.StatusOK},
+
+	{method: http.MethodGet, path: "/api/Admin/UserHistory/:userId", tag: "AdminRent", summary: "Get rent history of an account", access: admin, status: http.StatusOK, response: []service.RentOutput{}},
+	{method: http.MethodGet, path: "/api/Admin/TransportHistory/:transportId", tag: "AdminRent", summary: "Get rent history of transport", access: admin, status: http.StatusOK, response: []service.RentOutput{}},
+	{method: http.MethodGet, path: "/api/Admin/Rent/:rentId", tag: "AdminRent", summary: "Get rent by id", access: admin, status: http.StatusOK, response: service.RentOutput{}},
+	{method: http.MethodPost, path: "/api/Admin/Rent", tag: "AdminRent", summary: "Start a rent for an account", access: admin, body: service.AdminRentInput{}, status: http.StatusCreated, response: idResponse{}},
+	{method: http.MethodPost, path: "/api/Admin/Rent/End/:rentId", tag: "AdminRent", summary: "End a rent at the given position", access: admin, query: positionQuery, status: http.StatusOK},
+	{method: http.MethodDelete, path: "/api/Admin/Rent/:rentId", tag: "AdminRent", summary: "Delete a rent", access: admin, status: http.StatusOK},
+
+	{method: http.MethodGet, path: "/api/Admin/AuditLog", tag: "AdminAudit", summary: "Search the audit log of admin and money operations, newest first", access: admin, query: append(paginationQuery, queryParam{name: "actorId", typ: "integer"}, queryParam{name: "action", typ: "string"}, queryParam{name: "targetType", typ: "string", enum: auditTargetTypes}, queryParam{name: "targetId", typ: "integer"}, queryParam{name: "from", typ: "string"}, queryParam{name: "to", typ: "string"}), status: http.StatusOK, response: []service.AuditEntryOutput{}},
+
+	{method: http.MethodGet, path: "/api/Admin/License", tag: "AdminLicense", summary: "List uploaded driver licenses, oldest first", access: admin, query: append(paginationQuery, queryParam{name: "status", typ: "string", enum: licenseStatuses}), status: http.StatusOK, response: []service.LicenseOutput{}},
+	{method: http.MethodGet, path: "/api/Admin/License/:id/File", tag: "AdminLicense", summary: "Download an uploaded driver license", access: admin, status: http.StatusOK, response: fileDownload{contentTypes: service.LicenseContentTypes}},
+	{method: http.MethodPost, path: "/api/Admin/License/:id/Approve", tag: "AdminLicense", summary: "Approve a pending driver license", access: admin, status: http.StatusOK},
+	{method: http.MethodPost, path: "/api/Admin/License/:id/Reject", tag: "AdminLicense", summary: "Reject a pending driver license with a reason shown to the user", access: admin, body: service.LicenseRejectInput{}, status: http.StatusOK},
+
+	{method: http.MethodGet, path: "/.well-known/jwks.json", tag: "Keys", summary: "Public keys that verify access tokens", status: http.StatusOK, response: jwtkeys.JWKS{}},
+}
+
+// routeScopes maps "METHOD path" to the scope API keys need for the route.
+var routeScopes = func() map[string]string {
+	scopes := make(map[string]string)
+	for _, r := range routes {
+		if r.scope != "" {
+			scopes[r.method+" "+r.path] = r.scope
+		}
+	}
+
+	return scopes
+}()
+
+func buildSpec() ([]byte, error) {
+	schemas := map[string]any{}
+	paths := map[string]map[string]any{}
+
+	schemas["Problem"] = problemSchema()
+
+	for _, r := range routes {
+		path, pathParams := openAPIPath(r.path)
+
+		parameters := make([]any, 0, len(pathParams)+len(r.query))
+		for _, name := range pathParams {
+			// ids are numeric, other params (e.g. role names) are strings
+			schema := map[string]any{"type": "string"}
+			if strings.HasSuffix(strings.ToLower(name), "id") {
+				schema = map[string]any{"type": "integer", "format": "int64"}
+			}
+
+			parameters = append(parameters, map[string]any{
+				"name":     name,
+				"in":       "path",
+				"required": true,
+				"schema":   schema,
+			})
+		}
+
+		for _, q := range r.query {
+			schema := map[string]any{"type": q.typ}
+			if len(q.enum) > 0 {
+				schema["enum"] = q.enum
+			}
+
+			parameters = append(parameters, map[string]any{
+				"name":     q.name,
+				"in":       "query",
+				"required": q.required,
+				"schema":   schema,
+			})
+		}
+
+		success := map[string]any{"description": http.StatusText(r.status)}
+		if download, ok := r.response.(fileDownload); ok {
+			content := map[string]any{}
+			for _, contentType := range download.contentTypes {
+				content[contentType] = map[string]any{"schema": map[string]any{"type": "string", "format": "binary"}}
+			}
+
+			success["content"] = content
+		} else if r.response != nil {
+			success["content"] = map[string]any{
+				"application/json": map[string]any{"schema": schemaRef(reflect.TypeOf(r.response), schemas)},
+			}
+		}
+
+		responses := map[string]any{
+			fmt.Sprint(r.status): success,
+			"default":            problemResponse("Error"),
+		}
+
+		if len(parameters) > 0 || r.body != nil {
+			responses["400"] = problemResponse("Invalid request")
+		}
+
+		op := map[string]any{
+			"tags":        []string{r.tag},
+			"summary":     r.summary,
+			"operationId": operationID(r),
+			"responses":   responses,
+		}
+
+		if len(parameters) > 0 {
+			op["parameters"] = parameters
+		}
+
+		if upload, ok := r.body.(fileUpload); ok {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"multipart/form-data": map[string]any{"schema": map[string]any{
+						"type":       "object",
+						"required":   []string{upload.field},
+						"properties": map[string]any{upload.field: map[string]any{"type": "string", "format": "binary"}},
+					}},
+				},
+			}
+		} else if r.body != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaRef(reflect.TypeOf(r.body), schemas)},
+				},
+			}
+		}
+
+		if r.access != public {
+			op["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+			responses["401"] = problemResponse("Missing or invalid token")
+		}
+
+		if r.scope != "" {
+			op["security"] = []any{map[string]any{"bearerAuth": []string{}}, map[string]any{"apiKeyAuth": []string{}}}
+			op["description"] = fmt.Sprintf("API keys need the %s scope.", r.scope)
+			responses["403"] = problemResponse("Access denied or API key scope missing")
+		}
+
+		if r.access == admin {
+			responses["403"] = problemResponse("Admin rights required")
+		}
+
+		if paths[path] == nil {
+			paths[path] = map[string]any{}
+		}
+
+		paths[path][strings.ToLower(r.method)] = op
+	}
+
+	return json.Marshal(map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Simbir.GO API",
+			"version": apiVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+				"apiKeyAuth": map[string]any{"type": "apiKey", "in": "header", "name": apiKeyHeader},
+			},
+		},
+	})
+}
+
+// openAPIPath converts gin path params (:id) to OpenAPI templates ({id}).
+func openAPIPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	params := make([]string, 0)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+func operationID(r route) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(r.method))
+	for _, segment := range strings.Split(strings.TrimPrefix(r.path, "/api/"), "/") {
+		segment = strings.TrimPrefix(segment, ":")
+		if segment == "" {
+			continue
+		}
+
+		b.WriteString(exportedName(segment))
+	}
+
+	return b.String()
+}
+
+func problemResponse(description string) map[string]any {
+	return map[string]any{
+		"description": description,
+		"content": map[string]any{
+			problemContentType: map[string]any{"schema": map[string]any{"$ref": "#/components/schemas/Problem"}},
+		},
+	}
+}
+
+func problemSchema() map[string]any {
+	codes := []string{codeInvalidBody, codeInvalidParameter, codeUnauthorized, codeForbidden, codeNotFound, codeInternal, codeValidation, codeInvalidValue}
+	for _, se := range serviceErrors {
+		codes = append(codes, se.code)
+	}
+
+	sort.Strings(codes)
+	unique := codes[:0]
+	for i, code := range codes {
+		if i == 0 || code != codes[i-1] {
+			unique = append(unique, code)
+		}
+	}
+
+	return map[string]any{
+		"type":     "object",
+		"required": []string{"type", "title", "status", "code"},
+		"properties": map[string]any{
+			"type":     map[string]any{"type": "string"},
+			"title":    map[string]any{"type": "string"},
+			"status":   map[string]any{"type": "integer"},
+			"detail":   map[string]any{"type": "string"},
+			"instance": map[string]any{"type": "string"},
+			"code":     map[string]any{"type": "string", "enum": unique},
+			"errors": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":     "object",
+					"required": []string{"field", "message", "code"},
+					"properties": map[string]any{
+						"field":   map[string]any{"type": "string"},
+						"message": map[string]any{"type": "string"},
+						"code":    map[string]any{"type": "string", "enum": unique},
+					},
+				},
+			},
+		},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaRef returns a schema for t, registering named structs in schemas.
+func schemaRef(t reflect.Type, schemas map[string]any) map[string]any {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema map[string]any
+	switch {
+	case t == timeType:
+		schema = map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct:
+		name := exportedName(t.Name())
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = nil // guard against recursion
+			schemas[name] = structSchema(t, schemas)
+		}
+
+		ref := map[string]any{"$ref": "#/components/schemas/" + name}
+		if nullable {
+			return map[string]any{"allOf": []any{ref}, "nullable": true}
+		}
+
+		return ref
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		schema = map[string]any{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case t.Kind() == reflect.Bool:
+		schema = map[string]any{"type": "boolean"}
+	case t.Kind() == reflect.String:
+		schema = map[string]any{"type": "string"}
+	case t.Kind() == reflect.Int64:
+		schema = map[string]any{"type": "integer", "format": "int64"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema = map[string]any{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema = map[string]any{"type": "number", "format": "double"}
+	case t.Kind() == reflect.Map:
+		schema = map[string]any{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	default:
+		schema = map[string]any{}
+	}
+
+	if nullable {
+		schema["nullable"] = true
+	}
+
+	return schema
+}
+
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	properties := map[string]any{}
+	required := make([]string, 0)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		properties[name] = schemaRef(field.Type, schemas)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+
+	return schema
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
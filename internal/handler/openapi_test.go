@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+// docsRoutes serve the specification itself and are not documented in it.
+var docsRoutes = map[string]bool{
+	"GET /openapi.json":      true,
+	"GET /swagger":           true,
+	"GET /swagger/*filepath": true,
+}
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router, err := NewHandler(&service.Services{}, nil).InitRoutes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return router
+}
+
+func TestSpecMatchesRouter(t *testing.T) {
+	router := newTestRouter(t)
+
+	documented := make(map[string]bool, len(routes))
+	for _, r := range routes {
+		key := r.method + " " + r.path
+		if documented[key] {
+			t.Errorf("route %s is documented twice", key)
+		}
+
+		documented[key] = true
+	}
+
+	registered := make(map[string]bool)
+	var undocumented []string
+	for _, r := range router.Routes() {
+		key := r.Method + " " + r.Path
+		registered[key] = true
+
+		if !docsRoutes[key] && !documented[key] {
+			undocumented = append(undocumented, key)
+		}
+	}
+
+	var stale []string
+	for key := range documented {
+		if !registered[key] {
+			stale = append(stale, key)
+		}
+	}
+
+	sort.Strings(undocumented)
+	sort.Strings(stale)
+
+	for _, key := range undocumented {
+		t.Errorf("route %s is registered but missing from the specification", key)
+	}
+
+	for _, key := range stale {
+		t.Errorf("route %s is documented but not registered", key)
+	}
+}
+
+func TestServeSpec(t *testing.T) {
+	router := newTestRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	var spec struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode spec: %v", err)
+	}
+
+	operations := 0
+	for _, methods := range spec.Paths {
+		operations += len(methods)
+	}
+
+	if operations != len(routes) {
+		t.Errorf("spec has %d operations, want %d", operations, len(routes))
+	}
+}
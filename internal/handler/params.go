@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultCount = 20
+	maxCount     = 100
+)
+
+func getIDParam(c *gin.Context, name string) (int64, error) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, errors.New("invalid " + name + " param")
+	}
+
+	return id, nil
+}
+
+// getOptionalIDQuery returns nil if the query param is not set.
+func getOptionalIDQuery(c *gin.Context, name string) (*int64, error) {
+	value, ok := c.GetQuery(name)
+	if !ok {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, errors.New("invalid " + name + " param")
+	}
+
+	return &id, nil
+}
+
+// getOptionalTimeQuery parses an RFC 3339 query param, nil if it is not set.
+func getOptionalTimeQuery(c *gin.Context, name string) (*time.Time, error) {
+	value, ok := c.GetQuery(name)
+	if !ok {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.New("invalid " + name + " param")
+	}
+
+	return &t, nil
+}
+
+func getPositionQuery(c *gin.Context) (float64, float64, error) {
+	lat, err := strconv.ParseFloat(c.Query("lat"), 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid lat param")
+	}
+
+	long, err := strconv.ParseFloat(c.Query("long"), 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid long param")
+	}
+
+	return lat, long, nil
+}
+
+func getPagination(c *gin.Context) (int, int, error) {
+	start, err := strconv.Atoi(c.DefaultQuery("start", "0"))
+	if err != nil || start < 0 {
+		return 0, 0, errors.New("invalid start param")
+	}
+
+	count, err := strconv.Atoi(c.DefaultQuery("count", strconv.Itoa(defaultCount)))
+	if err != nil || count <= 0 || count > maxCount {
+		return 0, 0, errors.New("invalid count param")
+	}
+
+	return start, count, nil
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+const hesoyamAmount = 250000
+
+func (h *Handler) hesoyam(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	accountID, err := getIDParam(c, "accountId")
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, err.Error())
+		return
+	}
+
+	// users can top up only their own balance
+	if accountID != userID && !hasPermission(c, service.PermAccountsBalance) {
+		newServiceErrorResponse(c, service.ErrAccessDenied)
+		return
+	}
+
+	if err := h.services.Payment.UpdateBalance(c.Request.Context(), accountID, hesoyamAmount); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+const (
+	exportFormatJSON = "json"
+	exportFormatZIP  = "zip"
+)
+
+var exportFormats = []string{exportFormatJSON, exportFormatZIP}
+
+func (h *Handler) exportAccount(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	format := c.DefaultQuery("format", exportFormatJSON)
+	if format != exportFormatJSON && format != exportFormatZIP {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidParameter, "invalid format param")
+		return
+	}
+
+	export, err := h.services.Privacy.ExportAccount(c.Request.Context(), userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("account-%d-export.%s", userID, format)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	if format == exportFormatJSON {
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	archive, err := exportArchive(export)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// exportArchive puts every part of the export in its own file.
+func exportArchive(export *service.AccountExport) ([]byte, error) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"account.json", export.Account},
+		{"profile.json", export.Profile},
+		{"licenses.json", export.Licenses},
+		{"rents.json", export.Rents},
+		{"payments.json", export.Payments},
+		{"sessions.json", export.Sessions},
+		{"transports.json", export.Transports},
+		{"identities.json", export.Identities},
+	}
+
+	for _, file := range files {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(f)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (h *Handler) eraseAccount(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	var input service.EraseAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	err = h.services.Privacy.EraseAccount(c.Request.Context(), userID, &input, &service.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IP:        c.ClientIP(),
+	})
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+)
+
+// licenseFileField is the multipart form field holding the license document.
+const licenseFileField = "file"
+
+func (h *Handler) getProfile(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	profile, err := h.services.Profile.GetProfile(c.Request.Context(), userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (h *Handler) updateProfile(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	var input service.ProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+		return
+	}
+
+	if err := h.services.Profile.UpdateProfile(c.Request.Context(), userID, &input); err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) getLicense(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	license, err := h.services.Profile.GetLicense(c.Request.Context(), userID)
+	if err != nil {
+		newServiceErrorResponse(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, license)
+}
+
+// uploadLicense streams the file part of the multipart body to the service,
+// which enforces the size limit, so nothing is buffered on disk.
+func (h *Handler) uploadLicense(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		newErrorResponse(c, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		return
+	}
+
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, "expected a multipart/form-data body")
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, "missing "+licenseFileField+" part")
+			return
+		}
+
+		if err != nil {
+			newErrorResponse(c, http.StatusBadRequest, codeInvalidBody, err.Error())
+			return
+		}
+
+		if part.FormName() != licenseFileField {
+			continue
+		}
+
+		license, err := h.services.Profile.UploadLicense(c.Request.Context(), userID, part)
+		if err != nil {
+			newServiceErrorResponse(c, err)
+			return
+		}
+
+		c.JSON(http.StatusCreated, license)
+		return
+	}
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/realdanielursul/simbir-go/internal/service"
+	"github.com/sirupsen/logrus"
+)
+
+const problemContentType = "application/problem+json"
+
+// Stable machine-readable error codes, clients branch on these.
+const (
+	codeInvalidBody      = "invalid_body"
+	codeInvalidParameter = "invalid_parameter"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeNotFound         = "not_found"
+	codeInternal         = "internal_error"
+	codeValidation       = "validation_failed"
+	codeInvalidValue     = "invalid_value"
+)
+
+// problem is an RFC 7807 problem details object extended with a code member.
+type problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Errors   []fieldProblem `json:"errors,omitempty"`
+}
+
+// fieldProblem describes a single invalid field of a validation problem.
+type fieldProblem struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+type serviceError struct {
+	err    error
+	status int
+	code   string
+}
+
+var serviceErrors = []serviceError{
+	{service.ErrUsernameAlreadyExists, http.StatusConflict, "username_already_exists"},
+	{service.ErrIdentifierAlreadyExists, http.StatusConflict, "identifier_already_exists"},
+	{service.ErrEmailAlreadyExists, http.StatusConflict, "email_already_exists"},
+	{service.ErrInvalidUsername, http.StatusBadRequest, "invalid_username"},
+	{service.ErrInvalidPassword, http.StatusBadRequest, "invalid_password"},
+	{service.ErrInvalidEmail, http.StatusBadRequest, "invalid_email"},
+	{service.ErrRequiredField, http.StatusBadRequest, "required_field"},
+	{service.ErrInvalidValue, http.StatusBadRequest, codeInvalidValue},
+	{service.ErrInvalidTransportType, http.StatusBadRequest, "invalid_transport_type"},
+	{service.ErrInvalidCoordinates, http.StatusBadRequest, "invalid_coordinates"},
+	{service.ErrInvalidPrice, http.StatusBadRequest, "invalid_price"},
+	{service.ErrInvalidAmount, http.StatusBadRequest, "invalid_amount"},
+	{service.ErrInvalidRadius, http.StatusBadRequest, "invalid_radius"},
+	{service.ErrValidation, http.StatusBadRequest, codeValidation},
+	{service.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials"},
+	{service.ErrCannotSignToken, http.StatusInternalServerError, "cannot_sign_token"},
+	{service.ErrCannotParseToken, http.StatusUnauthorized, "invalid_token"},
+	{service.ErrInvalidToken, http.StatusUnauthorized, "invalid_token"},
+	{service.ErrInvalidRefreshToken, http.StatusUnauthorized, "invalid_refresh_token"},
+	{service.ErrTooManyAttempts, http.StatusTooManyRequests, "too_many_attempts"},
+	{service.ErrInvalidEmailToken, http.StatusBadRequest, "invalid_email_token"},
+	{service.ErrEmailNotSet, http.StatusConflict, "email_not_set"},
+	{service.ErrEmailAlreadyVerified, http.StatusConflict, "email_already_verified"},
+	{service.ErrOIDCNotConfigured, http.StatusNotFound, "oidc_not_configured"},
+	{service.ErrOIDCProviderUnavailable, http.StatusBadGateway, "oidc_provider_unavailable"},
+	{service.ErrInvalidOIDCState, http.StatusBadRequest, "invalid_oidc_state"},
+	{service.ErrOIDCLoginFailed, http.StatusUnauthorized, "oidc_login_failed"},
+	{service.ErrInvalidChallenge, http.StatusUnauthorized, "invalid_challenge"},
+	{service.ErrInvalidTwoFactorCode, http.StatusUnauthorized, "invalid_two_factor_code"},
+	{service.ErrTwoFactorRequired, http.StatusForbidden, "two_factor_required"},
+	{service.ErrTwoFactorEnabled, http.StatusConflict, "two_factor_enabled"},
+	{service.ErrTwoFactorNotEnabled, http.StatusConflict, "two_factor_not_enabled"},
+	{service.ErrInvalidAPIKey, http.StatusUnauthorized, "invalid_api_key"},
+	{service.ErrInvalidScope, http.StatusBadRequest, "invalid_scope"},
+	{service.ErrAccountNotFound, http.StatusNotFound, "account_not_found"},
+	{service.ErrSessionNotFound, http.StatusNotFound, "session_not_found"},
+	{service.ErrAPIKeyNotFound, http.StatusNotFound, "api_key_not_found"},
+	{service.ErrRoleNotFound, http.StatusNotFound, "role_not_found"},
+	{service.ErrTransportNotFound, http.StatusNotFound, "transport_not_found"},
+	{service.ErrAccessDenied, http.StatusForbidden, "access_denied"},
+	{service.ErrNotEnoughMoney, http.StatusPaymentRequired, "not_enough_money"},
+	{service.ErrInvalidRentType, http.StatusBadRequest, "invalid_rent_type"},
+	{service.ErrRentNotFound, http.StatusNotFound, "rent_not_found"},
+	{service.ErrRentAlreadyEnded, http.StatusConflict, "rent_already_ended"},
+	{service.ErrActiveRent, http.StatusConflict, "active_rent"},
+	{service.ErrTransportRented, http.StatusConflict, "transport_rented"},
+	{service.ErrAccountSuspended, http.StatusForbidden, "account_suspended"},
+	{service.ErrAccountBanned, http.StatusForbidden, "account_banned"},
+	{service.ErrInvalidAccountStatus, http.StatusBadRequest, "invalid_account_status"},
+	{service.ErrTransportUnavailable, http.StatusConflict, "transport_unavailable"},
+	{service.ErrBirthDateLocked, http.StatusConflict, "birth_date_locked"},
+	{service.ErrLicenseNotFound, http.StatusNotFound, "license_not_found"},
+	{service.ErrLicensePending, http.StatusConflict, "license_pending"},
+	{service.ErrLicenseReviewed, http.StatusConflict, "license_already_reviewed"},
+	{service.ErrLicenseTooLarge, http.StatusRequestEntityTooLarge, "license_too_large"},
+	{service.ErrInvalidLicenseFile, http.StatusUnsupportedMediaType, "invalid_license_file"},
+	{service.ErrLicenseRequired, http.StatusForbidden, "license_required"},
+	{service.ErrBirthDateRequired, http.StatusForbidden, "birth_date_required"},
+	{service.ErrAgeRequirement, http.StatusForbidden, "age_requirement"},
+}
+
+type idResponse struct {
+	ID int64 `json:"id"`
+}
+
+func newErrorResponse(c *gin.Context, statusCode int, code, detail string) {
+	logrus.Debug(detail)
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(statusCode, problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     code,
+	})
+}
+
+// newServiceErrorResponse translates errors returned by services. Unknown
+// errors (e.g. from the database driver) are logged and hidden from clients.
+func newServiceErrorResponse(c *gin.Context, err error) {
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		newValidationErrorResponse(c, validationErr)
+		return
+	}
+
+	var lockedErr *service.LockedError
+	if errors.As(err, &lockedErr) {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(lockedErr.RetryAfter.Seconds()))))
+	}
+
+	// blocked callers learn why and for how long
+	var blockedErr *service.BlockedError
+	if errors.As(err, &blockedErr) {
+		newErrorResponse(c, http.StatusForbidden, serviceErrorCode(err), blockedErr.Error())
+		return
+	}
+
+	for _, se := range serviceErrors {
+		if errors.Is(err, se.err) {
+			newErrorResponse(c, se.status, se.code, se.err.Error())
+			return
+		}
+	}
+
+	logrus.WithField("path", c.Request.URL.Path).Error(err)
+	newErrorResponse(c, http.StatusInternalServerError, codeInternal, "internal server error")
+}
+
+// newValidationErrorResponse reports every invalid field at once.
+func newValidationErrorResponse(c *gin.Context, err *service.ValidationError) {
+	fields := make([]fieldProblem, 0, len(err.Fields))
+	for _, field := range err.Fields {
+		fields = append(fields, fieldProblem{
+			Field:   field.Field,
+			Message: field.Message,
+			Code:    serviceErrorCode(field.Err),
+		})
+	}
+
+	logrus.Debug(err)
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(http.StatusBadRequest, problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(http.StatusBadRequest),
+		Status:   http.StatusBadRequest,
+		Detail:   service.ErrValidation.Error(),
+		Instance: c.Request.URL.Path,
+		Code:     codeValidation,
+		Errors:   fields,
+	})
+}
+
+func serviceErrorCode(err error) string {
+	for _, se := range serviceErrors {
+		if errors.Is(err, se.err) {
+			return se.code
+		}
+	}
+
+	return codeInvalidValue
+}
+
+func (h *Handler) recovery(c *gin.Context, recovered any) {
+	logrus.WithField("path", c.Request.URL.Path).Errorf("panic recovered: %v", recovered)
+	newErrorResponse(c, http.StatusInternalServerError, codeInternal, "internal server error")
+}
+
+func (h *Handler) noRoute(c *gin.Context) {
+	newErrorResponse(c, http.StatusNotFound, codeNotFound, "route not found")
+}
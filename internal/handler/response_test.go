@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/realdanielursul/simbir-go/pkg/client"
+)
+
+// TestClientKnowsErrorCodes keeps the errors of pkg/client, which cannot
+// import the services, in step with the codes the API responds with.
+func TestClientKnowsErrorCodes(t *testing.T) {
+	for _, serviceErr := range serviceErrors {
+		apiErr := &client.APIError{Status: serviceErr.status, Code: serviceErr.code}
+		if len(apiErr.Unwrap()) == 0 {
+			t.Errorf("pkg/client has no error for code %q", serviceErr.code)
+		}
+	}
+}
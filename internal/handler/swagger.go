@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed swagger
+var swaggerFiles embed.FS
+
+func (h *Handler) initDocs(router *gin.Engine) {
+	spec, err := buildSpec()
+	if err != nil {
+		panic(err)
+	}
+
+	ui, err := fs.Sub(swaggerFiles, "swagger")
+	if err != nil {
+		panic(err)
+	}
+
+	uiFS := http.FS(ui)
+
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", spec)
+	})
+
+	router.GET("/swagger", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/swagger/")
+	})
+
+	router.GET("/swagger/*filepath", func(c *gin.Context) {
+		c.FileFromFS(c.Param("filepath"), uiFS)
+	})
+}
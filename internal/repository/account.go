@@ -3,8 +3,10 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/realdanielursul/simbir-go/internal/entity"
 )
 
@@ -63,13 +65,14 @@ func (r *AccountRepository) GetByUsername(ctx context.Context, username string)
 	return &account, nil
 }
 
-func (r *AccountRepository) GetByUsernameAndPassword(ctx context.Context, username, passwordHash string) (*entity.Account, error) {
+// GetByEmail matches email case-insensitively.
+func (r *AccountRepository) GetByEmail(ctx context.Context, email string) (*entity.Account, error) {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
 	var account entity.Account
-	query := `SELECT * FROM accounts WHERE username = $1 AND password_hash = $2`
-	if err := r.QueryRowxContext(ctx, query, username, passwordHash).StructScan(&account); err != nil {
+	query := `SELECT * FROM accounts WHERE LOWER(email) = LOWER($1)`
+	if err := r.QueryRowxContext(ctx, query, email).StructScan(&account); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
@@ -120,14 +123,202 @@ func (r *AccountRepository) Update(ctx context.Context, account *entity.Account)
 	return nil
 }
 
-func (r *AccountRepository) Delete(ctx context.Context, id int64) error {
+func (r *AccountRepository) UpdatePasswordHash(ctx context.Context, id int64, passwordHash string) error {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
-	query := `DELETE FROM accounts WHERE id = $1`
-	if _, err := r.ExecContext(ctx, query, id); err != nil {
+	query := `UPDATE accounts SET password_hash = $1 WHERE id = $2`
+	if _, err := r.ExecContext(ctx, query, passwordHash, id); err != nil {
 		return err
 	}
 
 	return nil
 }
+
+// SetBalance replaces the balance and audits the change in one transaction.
+func (r *AccountRepository) SetBalance(ctx context.Context, id, balance int64, audit BalanceAudit) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// locked, so the audit sees the balance this change replaces
+	var before int64
+	query := `SELECT balance FROM accounts WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRowxContext(ctx, query, id).Scan(&before); err != nil {
+		return fmt.Errorf("lock account: %w", err)
+	}
+
+	query = `UPDATE accounts SET balance = $1, updated_at = NOW() WHERE id = $2`
+	if _, err := tx.ExecContext(ctx, query, balance, id); err != nil {
+		return fmt.Errorf("set balance: %w", err)
+	}
+
+	if err := auditBalance(ctx, tx, audit, before, balance); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit balance: %w", err)
+	}
+
+	return nil
+}
+
+// SetEmail changes the email, which has to be verified again.
+func (r *AccountRepository) SetEmail(ctx context.Context, id int64, email string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE accounts SET email = $1, email_verified_at = NULL, updated_at = NOW() WHERE id = $2`
+	if _, err := r.ExecContext(ctx, query, email, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// VerifyEmail marks the email verified and reports false if the account has
+// changed it since.
+func (r *AccountRepository) VerifyEmail(ctx context.Context, id int64, email string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE accounts SET email_verified_at = NOW() WHERE id = $1 AND email = $2`
+	result, err := r.ExecContext(ctx, query, id, email)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// SetStatus stores the status of the account together with who set it.
+func (r *AccountRepository) SetStatus(ctx context.Context, account *entity.Account) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE accounts
+		SET status = $1, status_reason = $2, status_until = $3, status_set_by = $4, status_set_at = NOW(), updated_at = NOW()
+		WHERE id = $5
+	`
+	if _, err := r.ExecContext(ctx, query, account.Status, account.StatusReason, account.StatusUntil, account.StatusSetBy, account.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetProfile stores the personal details of the account.
+func (r *AccountRepository) SetProfile(ctx context.Context, account *entity.Account) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE accounts SET full_name = $1, phone = $2, birth_date = $3, updated_at = NOW() WHERE id = $4`
+	if _, err := r.ExecContext(ctx, query, account.FullName, account.Phone, account.BirthDate, account.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// erasedData lists the statements removing everything that identifies an
+// account or signs it in, sessions take their tokens along.
+var erasedData = []string{
+	`DELETE FROM sign_in_attempts WHERE account_id = $1 OR username = (SELECT username FROM accounts WHERE id = $1)`,
+	`DELETE FROM sessions WHERE user_id = $1`,
+	`DELETE FROM tokens WHERE user_id = $1`,
+	`DELETE FROM refresh_tokens WHERE user_id = $1`,
+	`DELETE FROM api_keys WHERE account_id = $1`,
+	`DELETE FROM two_factor WHERE account_id = $1`,
+	`DELETE FROM recovery_codes WHERE account_id = $1`,
+	`DELETE FROM sign_in_challenges WHERE account_id = $1`,
+	`DELETE FROM email_tokens WHERE account_id = $1`,
+	`DELETE FROM external_identities WHERE account_id = $1`,
+	`DELETE FROM account_roles WHERE account_id = $1`,
+	`DELETE FROM license_documents WHERE account_id = $1`,
+	`UPDATE transports SET can_be_rented = FALSE, description = NULL, updated_at = NOW() WHERE owner_id = $1`,
+}
+
+// auditPersonalFields are the fields of account snapshots in the audit log
+// that identify the person.
+var auditPersonalFields = []string{"username", "email"}
+
+// Erase anonymizes the account. The row stays with its balance for the rents
+// referencing it, the username is replaced with one that cannot sign up and
+// the password with one nothing matches. Audit log entries about the account
+// lose its username and email, those made by it the IP. It reports false if
+// the account does not exist or was already erased.
+func (r *AccountRepository) Erase(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	// sign in attempts are matched by the username, which is replaced last
+	for _, query := range erasedData {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return false, fmt.Errorf("erase account data: %w", err)
+		}
+	}
+
+	// the audit log keeps the entries, but neither who the person was nor
+	// where they connected from
+	if _, err := tx.ExecContext(ctx, `SET LOCAL audit_log.redact = 'on'`); err != nil {
+		return false, fmt.Errorf("redact audit log: %w", err)
+	}
+
+	query := `
+		UPDATE audit_log SET before = before - $2::TEXT[], after = after - $2::TEXT[]
+		WHERE target_type = 'account' AND target_id = $1 AND (before ?| $2::TEXT[] OR after ?| $2::TEXT[])
+	`
+	if _, err := tx.ExecContext(ctx, query, id, pq.Array(auditPersonalFields)); err != nil {
+		return false, fmt.Errorf("redact audit log: %w", err)
+	}
+
+	query = `UPDATE audit_log SET ip = '' WHERE actor_id = $1 AND ip <> ''`
+	if _, err := tx.ExecContext(ctx, query, id); err != nil {
+		return false, fmt.Errorf("redact audit log: %w", err)
+	}
+
+	query = `
+		UPDATE accounts
+		SET username = 'erased:' || id, password_hash = '', is_admin = FALSE, email = NULL,
+		    email_verified_at = NULL, full_name = NULL, phone = NULL, birth_date = NULL,
+		    erased_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND erased_at IS NULL
+	`
+	result, err := tx.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, fmt.Errorf("erase account: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if affected != 1 {
+		return false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit account erasure: %w", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+const apiKeyColumns = `id, account_id, name, prefix, key_hash, scopes, expires_at, last_used_at, revoked_at, created_at`
+
+type APIKeyRepository struct {
+	*sqlx.DB
+}
+
+func NewAPIKeyRepository(db *sqlx.DB) *APIKeyRepository {
+	return &APIKeyRepository{db}
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *entity.APIKey) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var id int64
+	query := `INSERT INTO api_keys (account_id, name, prefix, key_hash, scopes, expires_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	if err := r.QueryRowContext(ctx, query, key.AccountID, key.Name, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt).Scan(&id); err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+func (r *APIKeyRepository) GetByID(ctx context.Context, id int64) (*entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+	key, err := scanAPIKey(r.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+	key, err := scanAPIKey(r.QueryRowContext(ctx, query, keyHash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (r *APIKeyRepository) ListByAccount(ctx context.Context, accountID int64) ([]entity.APIKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	keys := make([]entity.APIKey, 0)
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE account_id = $1 AND revoked_at IS NULL ORDER BY created_at`
+	rows, err := r.QueryContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (r *APIKeyRepository) Touch(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE api_keys SET last_used_at = NOW() WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '` + touchInterval + `')`
+	if _, err := r.ExecContext(ctx, query, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *APIKeyRepository) Revoke(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	if _, err := r.ExecContext(ctx, query, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row rowScanner) (*entity.APIKey, error) {
+	var key entity.APIKey
+	err := row.Scan(&key.ID, &key.AccountID, &key.Name, &key.Prefix, &key.KeyHash, pq.Array(&key.Scopes),
+		&key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+// AuditFilter narrows an audit log search, zero values match everything.
+type AuditFilter struct {
+	ActorID    *int64
+	Action     string
+	TargetType string
+	TargetID   *int64
+	From       *time.Time
+	To         *time.Time
+}
+
+type AuditLogRepository struct {
+	*sqlx.DB
+}
+
+func NewAuditLogRepository(db *sqlx.DB) *AuditLogRepository {
+	return &AuditLogRepository{db}
+}
+
+func (r *AuditLogRepository) Create(ctx context.Context, entry *entity.AuditEntry) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	return createAuditEntry(ctx, r, entry)
+}
+
+// createAuditEntry stores the entry with db, which is a transaction when it
+// audits a change made in it.
+func createAuditEntry(ctx context.Context, db sqlx.ExecerContext, entry *entity.AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, actor_type, action, target_type, target_id, before, after, ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	_, err := db.ExecContext(ctx, query, entry.ActorID, entry.ActorType, entry.Action, entry.TargetType, entry.TargetID, entry.Before, entry.After, entry.IP)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// auditBalance stores the audit of a balance change in the transaction that
+// made it.
+func auditBalance(ctx context.Context, tx *sqlx.Tx, audit BalanceAudit, before, after int64) error {
+	if audit == nil {
+		return nil
+	}
+
+	entry, err := audit(before, after)
+	if err != nil {
+		return fmt.Errorf("audit balance: %w", err)
+	}
+
+	if err := createAuditEntry(ctx, tx, entry); err != nil {
+		return fmt.Errorf("audit balance: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AuditLogRepository) List(ctx context.Context, filter *AuditFilter, count, start int) ([]entity.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	entries := make([]entity.AuditEntry, 0, count)
+	query := `
+		SELECT * FROM audit_log
+		WHERE ($1::BIGINT IS NULL OR actor_id = $1)
+			AND ($2 = '' OR action = $2)
+			AND ($3 = '' OR target_type = $3)
+			AND ($4::BIGINT IS NULL OR target_id = $4)
+			AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+			AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $7 OFFSET $8
+	`
+	rows, err := r.QueryxContext(ctx, query, filter.ActorID, filter.Action, filter.TargetType, filter.TargetID, filter.From, filter.To, count, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry entity.AuditEntry
+		if err := rows.StructScan(&entry); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// ListByTarget returns the entries of the given actions on a target, oldest
+// first.
+func (r *AuditLogRepository) ListByTarget(ctx context.Context, targetType string, targetID int64, actions []string) ([]entity.AuditEntry, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	entries := make([]entity.AuditEntry, 0)
+	query := `
+		SELECT * FROM audit_log
+		WHERE target_type = $1 AND target_id = $2 AND action = ANY($3)
+		ORDER BY created_at, id
+	`
+	rows, err := r.QueryxContext(ctx, query, targetType, targetID, pq.Array(actions))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry entity.AuditEntry
+		if err := rows.StructScan(&entry); err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
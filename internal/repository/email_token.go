@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+type EmailTokenRepository struct {
+	*sqlx.DB
+}
+
+func NewEmailTokenRepository(db *sqlx.DB) *EmailTokenRepository {
+	return &EmailTokenRepository{db}
+}
+
+// Create stores the token and voids earlier unused tokens of the account with
+// the same purpose, so only the latest mail works.
+func (r *EmailTokenRepository) Create(ctx context.Context, token *entity.EmailToken) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE email_tokens SET used_at = NOW() WHERE account_id = $1 AND purpose = $2 AND used_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, token.AccountID, token.Purpose); err != nil {
+		return fmt.Errorf("void email tokens: %w", err)
+	}
+
+	query = `INSERT INTO email_tokens (account_id, purpose, email, token_hash, expires_at) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := tx.ExecContext(ctx, query, token.AccountID, token.Purpose, token.Email, token.TokenHash, token.ExpiresAt); err != nil {
+		return fmt.Errorf("insert email token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit email token: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EmailTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*entity.EmailToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var token entity.EmailToken
+	query := `SELECT * FROM email_tokens WHERE token_hash = $1`
+	if err := r.QueryRowxContext(ctx, query, tokenHash).StructScan(&token); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Use marks the token as used and reports false if it already was.
+func (r *EmailTokenRepository) Use(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE email_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+	result, err := r.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+type LicenseRepository struct {
+	*sqlx.DB
+}
+
+func NewLicenseRepository(db *sqlx.DB) *LicenseRepository {
+	return &LicenseRepository{db}
+}
+
+func (r *LicenseRepository) Create(ctx context.Context, document *entity.LicenseDocument) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var id int64
+	query := `INSERT INTO license_documents (account_id, storage_key, content_type, size) VALUES ($1, $2, $3, $4) RETURNING id`
+	if err := r.QueryRowContext(ctx, query, document.AccountID, document.StorageKey, document.ContentType, document.Size).Scan(&id); err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+func (r *LicenseRepository) GetByID(ctx context.Context, id int64) (*entity.LicenseDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var document entity.LicenseDocument
+	query := `SELECT * FROM license_documents WHERE id = $1`
+	if err := r.QueryRowxContext(ctx, query, id).StructScan(&document); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+// GetLatest returns the document the account uploaded last.
+func (r *LicenseRepository) GetLatest(ctx context.Context, accountID int64) (*entity.LicenseDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var document entity.LicenseDocument
+	query := `SELECT * FROM license_documents WHERE account_id = $1 ORDER BY created_at DESC, id DESC LIMIT 1`
+	if err := r.QueryRowxContext(ctx, query, accountID).StructScan(&document); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &document, nil
+}
+
+func (r *LicenseRepository) ListByAccount(ctx context.Context, accountID int64) ([]entity.LicenseDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	documents := make([]entity.LicenseDocument, 0)
+	query := `SELECT * FROM license_documents WHERE account_id = $1 ORDER BY created_at, id`
+	rows, err := r.QueryxContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var document entity.LicenseDocument
+		if err := rows.StructScan(&document); err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, document)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// List returns documents in the given status, or in any if it is empty,
+// oldest first so reviews are done in order.
+func (r *LicenseRepository) List(ctx context.Context, status string, count, start int) ([]entity.LicenseDocument, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	documents := make([]entity.LicenseDocument, 0, count)
+	query := `
+		SELECT * FROM license_documents
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3
+	`
+	rows, err := r.QueryxContext(ctx, query, status, count, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var document entity.LicenseDocument
+		if err := rows.StructScan(&document); err != nil {
+			return nil, err
+		}
+
+		documents = append(documents, document)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+func (r *LicenseRepository) HasApproved(ctx context.Context, accountID int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var approved bool
+	query := `SELECT EXISTS (SELECT 1 FROM license_documents WHERE account_id = $1 AND status = 'approved')`
+	if err := r.QueryRowContext(ctx, query, accountID).Scan(&approved); err != nil {
+		return false, err
+	}
+
+	return approved, nil
+}
+
+// Review stores the decision on a pending document and reports false if it
+// was already reviewed.
+func (r *LicenseRepository) Review(ctx context.Context, document *entity.LicenseDocument) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE license_documents
+		SET status = $1, reject_reason = $2, reviewed_by = $3, reviewed_at = NOW()
+		WHERE id = $4 AND status = 'pending'
+	`
+	result, err := r.ExecContext(ctx, query, document.Status, document.RejectReason, document.ReviewedBy, document.ID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
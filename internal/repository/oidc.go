@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+type ExternalIdentityRepository struct {
+	*sqlx.DB
+}
+
+func NewExternalIdentityRepository(db *sqlx.DB) *ExternalIdentityRepository {
+	return &ExternalIdentityRepository{db}
+}
+
+// CreateWithAccount creates the account of a first external login together
+// with its identity.
+func (r *ExternalIdentityRepository) CreateWithAccount(ctx context.Context, account *entity.Account, identity *entity.ExternalIdentity) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return -1, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var accountID int64
+	query := `INSERT INTO accounts (username, password_hash, is_admin, balance, email, email_verified_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	err = tx.QueryRowContext(ctx, query, account.Username, account.PasswordHash, account.IsAdmin, account.Balance, account.Email, account.EmailVerifiedAt).Scan(&accountID)
+	if err != nil {
+		return -1, fmt.Errorf("insert account: %w", err)
+	}
+
+	query = `INSERT INTO external_identities (account_id, issuer, subject, email) VALUES ($1, $2, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, accountID, identity.Issuer, identity.Subject, identity.Email); err != nil {
+		return -1, fmt.Errorf("insert external identity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return -1, fmt.Errorf("commit external identity: %w", err)
+	}
+
+	return accountID, nil
+}
+
+func (r *ExternalIdentityRepository) GetBySubject(ctx context.Context, issuer, subject string) (*entity.ExternalIdentity, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var identity entity.ExternalIdentity
+	query := `SELECT * FROM external_identities WHERE issuer = $1 AND subject = $2`
+	if err := r.QueryRowxContext(ctx, query, issuer, subject).StructScan(&identity); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (r *ExternalIdentityRepository) ListByAccount(ctx context.Context, accountID int64) ([]entity.ExternalIdentity, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	identities := make([]entity.ExternalIdentity, 0)
+	query := `SELECT * FROM external_identities WHERE account_id = $1 ORDER BY created_at`
+	rows, err := r.QueryxContext(ctx, query, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var identity entity.ExternalIdentity
+		if err := rows.StructScan(&identity); err != nil {
+			return nil, err
+		}
+
+		identities = append(identities, identity)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// Touch records a login and the email the provider reported with it.
+func (r *ExternalIdentityRepository) Touch(ctx context.Context, id int64, email *string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE external_identities SET email = $1, last_login_at = NOW() WHERE id = $2`
+	if _, err := r.ExecContext(ctx, query, email, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type OIDCLoginStateRepository struct {
+	*sqlx.DB
+}
+
+func NewOIDCLoginStateRepository(db *sqlx.DB) *OIDCLoginStateRepository {
+	return &OIDCLoginStateRepository{db}
+}
+
+func (r *OIDCLoginStateRepository) Create(ctx context.Context, state *entity.OIDCLoginState) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `INSERT INTO oidc_login_states (state_hash, code_verifier, nonce, expires_at) VALUES ($1, $2, $3, $4)`
+	if _, err := r.ExecContext(ctx, query, state.StateHash, state.CodeVerifier, state.Nonce, state.ExpiresAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *OIDCLoginStateRepository) GetByHash(ctx context.Context, stateHash string) (*entity.OIDCLoginState, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var state entity.OIDCLoginState
+	query := `SELECT * FROM oidc_login_states WHERE state_hash = $1`
+	if err := r.QueryRowxContext(ctx, query, stateHash).StructScan(&state); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &state, nil
+}
+
+// Use marks the state as consumed and reports false if it already was.
+func (r *OIDCLoginStateRepository) Use(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE oidc_login_states SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+	result, err := r.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -14,14 +15,31 @@ func NewPaymentRepository(db *sqlx.DB) *PaymentRepository {
 	return &PaymentRepository{db}
 }
 
-func (r *PaymentRepository) UpdateBalance(ctx context.Context, accountID, amount int64) error {
+// UpdateBalance adds amount to the balance and audits the change in one
+// transaction.
+func (r *PaymentRepository) UpdateBalance(ctx context.Context, accountID, amount int64, audit BalanceAudit) error {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
-	query := `UPDATE accounts SET balance = balance + $1 WHERE id = $2`
-	if _, err := r.ExecContext(ctx, query, amount, accountID); err != nil {
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var before, after int64
+	query := `UPDATE accounts SET balance = balance + $1 WHERE id = $2 RETURNING balance - $1, balance`
+	if err := tx.QueryRowxContext(ctx, query, amount, accountID).Scan(&before, &after); err != nil {
+		return fmt.Errorf("update balance: %w", err)
+	}
+
+	if err := auditBalance(ctx, tx, audit, before, after); err != nil {
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit balance: %w", err)
+	}
+
 	return nil
 }
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+type RefreshTokenRepository struct {
+	*sqlx.DB
+}
+
+func NewRefreshTokenRepository(db *sqlx.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at) VALUES ($1, $2, $3, $4)`
+	if _, err := r.ExecContext(ctx, query, token.UserID, token.SessionID, token.TokenHash, token.ExpiresAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RefreshTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var token entity.RefreshToken
+	query := `SELECT * FROM refresh_tokens WHERE token_hash = $1`
+	if err := r.QueryRowxContext(ctx, query, tokenHash).StructScan(&token); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// Use marks the token as used and reports false if it was already used or
+// revoked, so concurrent refreshes with the same token cannot both succeed.
+func (r *RefreshTokenRepository) Use(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE refresh_tokens SET used_at = NOW() WHERE id = $1 AND used_at IS NULL AND revoked_at IS NULL`
+	result, err := r.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
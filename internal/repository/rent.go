@@ -17,20 +17,71 @@ func NewRentRepository(db *sqlx.DB) *RentRepository {
 	return &RentRepository{db}
 }
 
-func (r *RentRepository) StartRent(ctx context.Context, rent *entity.Rent) (int64, error) {
+// StartRent claims the transport, records the rent and charges the renter the
+// first unit of its price in one transaction, so a transport is never rented
+// twice. The price of the unit is taken from the claimed transport and set on
+// rent, the charge is audited with the balance it left. It fails with
+// ErrTransportUnavailable if the transport cannot be rented and, unless
+// allowOverdraft, with ErrInsufficientBalance if the renter cannot pay the
+// first unit.
+func (r *RentRepository) StartRent(ctx context.Context, rent *entity.Rent, allowOverdraft bool, audit BalanceAudit) (int64, error) {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var minutePrice, dayPrice int64
+	query := `UPDATE transports SET can_be_rented = FALSE WHERE id = $1 AND can_be_rented AND deleted_at IS NULL RETURNING minute_price, day_price`
+	if err := tx.QueryRowxContext(ctx, query, rent.TransportID).Scan(&minutePrice, &dayPrice); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrTransportUnavailable
+		}
+
+		return 0, fmt.Errorf("claim transport: %w", err)
+	}
+
+	rent.PriceOfUnit = dayPrice
+	if rent.PriceType == "Minutes" {
+		rent.PriceOfUnit = minutePrice
+	}
+
 	var id int64
-	query := `INSERT INTO rents (transport_id, user_id, time_start, time_end, price_of_unit, price_type, final_price) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
-	if err := r.QueryRowxContext(ctx, query, rent.TransportID, rent.UserID, rent.TimeStart, rent.TimeEnd, rent.PriceOfUnit, rent.PriceType, rent.FinalPrice).Scan(&id); err != nil {
+	query = `INSERT INTO rents (transport_id, user_id, time_start, time_end, price_of_unit, price_type, final_price) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	if err := tx.QueryRowxContext(ctx, query, rent.TransportID, rent.UserID, rent.TimeStart, rent.TimeEnd, rent.PriceOfUnit, rent.PriceType, rent.FinalPrice).Scan(&id); err != nil {
+		return 0, fmt.Errorf("insert rent: %w", err)
+	}
+
+	var before, after int64
+	query = `UPDATE accounts SET balance = balance - $1 WHERE id = $2 AND ($3 OR balance >= $1) RETURNING balance + $1, balance`
+	if err := tx.QueryRowxContext(ctx, query, rent.PriceOfUnit, rent.UserID, allowOverdraft).Scan(&before, &after); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrInsufficientBalance
+		}
+
+		return 0, fmt.Errorf("charge renter: %w", err)
+	}
+
+	if err := auditBalance(ctx, tx, audit, before, after); err != nil {
 		return 0, err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit rent: %w", err)
+	}
+
+	rent.ID = id
 	return id, nil
 }
 
-func (r *RentRepository) EndRent(ctx context.Context, id int64, lat, long float64) error {
+// EndRent ends the rent, prices it, returns its transport for rent where the
+// trip ended and audits the ended rent in one transaction. It fails with
+// ErrRentAlreadyEnded if the rent has already ended, so a rent is never ended
+// twice.
+func (r *RentRepository) EndRent(ctx context.Context, id int64, lat, long float64, audit RentAudit) error {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
@@ -40,7 +91,6 @@ func (r *RentRepository) EndRent(ctx context.Context, id int64, lat, long float6
 	}
 	defer tx.Rollback()
 
-	var rentID int64
 	query := `
 		UPDATE rents
 		SET time_end = NOW(),
@@ -48,13 +98,35 @@ func (r *RentRepository) EndRent(ctx context.Context, id int64, lat, long float6
 		        WHEN 'Minutes' THEN CEIL(EXTRACT(EPOCH FROM (NOW() - time_start)) / 60) * price_of_unit
 		        ELSE CEIL(EXTRACT(EPOCH FROM (NOW() - time_start)) / 86400) * price_of_unit
 		    END
-		WHERE id = $1
-		RETURNING id
+		WHERE id = $1 AND time_end IS NULL
+		RETURNING *
 	`
-	if err := tx.QueryRowxContext(ctx, query, id).Scan(&rentID); err != nil {
+	var ended entity.Rent
+	if err := tx.QueryRowxContext(ctx, query, id).StructScan(&ended); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrRentAlreadyEnded
+		}
+
 		return fmt.Errorf("update rent: %w", err)
 	}
 
+	// transport stays where the trip ended
+	query = `UPDATE transports SET can_be_rented = TRUE, latitude = $1, longitude = $2, updated_at = NOW() WHERE id = $3`
+	if _, err := tx.ExecContext(ctx, query, lat, long, ended.TransportID); err != nil {
+		return fmt.Errorf("release transport: %w", err)
+	}
+
+	if audit != nil {
+		entry, err := audit(&ended)
+		if err != nil {
+			return fmt.Errorf("audit rent: %w", err)
+		}
+
+		if err := createAuditEntry(ctx, tx, entry); err != nil {
+			return fmt.Errorf("audit rent: %w", err)
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("commit rent: %w", err)
 	}
@@ -163,6 +235,55 @@ func (r *RentRepository) ListActive(ctx context.Context) ([]entity.Rent, error)
 	return rents, nil
 }
 
+func (r *RentRepository) ListActiveByUser(ctx context.Context, userID int64) ([]entity.Rent, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	rents := make([]entity.Rent, 0)
+	query := `SELECT * FROM rents WHERE user_id = $1 AND time_end IS NULL`
+	rows, err := r.QueryxContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rent entity.Rent
+		if err := rows.StructScan(&rent); err != nil {
+			return nil, err
+		}
+
+		rents = append(rents, rent)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return rents, nil
+}
+
+// HasActive reports whether the account rides, or owns the transport of, a
+// rent that has not ended.
+func (r *RentRepository) HasActive(ctx context.Context, accountID int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var active bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM rents r
+			JOIN transports t ON t.id = r.transport_id
+			WHERE r.time_end IS NULL AND (r.user_id = $1 OR t.owner_id = $1)
+		)
+	`
+	if err := r.QueryRowContext(ctx, query, accountID).Scan(&active); err != nil {
+		return false, err
+	}
+
+	return active, nil
+}
+
 func (r *RentRepository) Update(ctx context.Context, rent *entity.Rent) error {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -10,14 +11,36 @@ import (
 
 const operationTimeout = 3 * time.Second
 
+// Conditions checked inside a transaction, the transaction is rolled back
+// when one fails.
+var (
+	ErrTransportUnavailable = errors.New("transport is not available for rent")
+	ErrInsufficientBalance  = errors.New("insufficient balance")
+	ErrRentAlreadyEnded     = errors.New("rent already ended")
+)
+
+// Audit entries of a change, built from what the transaction making it read
+// and wrote and stored in that transaction, so the change is never committed
+// without them. A nil audit stores nothing.
+type (
+	BalanceAudit func(before, after int64) (*entity.AuditEntry, error)
+	RentAudit    func(ended *entity.Rent) (*entity.AuditEntry, error)
+)
+
 type Account interface {
 	Create(ctx context.Context, account *entity.Account) (int64, error)
 	GetByID(ctx context.Context, id int64) (*entity.Account, error)
 	GetByUsername(ctx context.Context, username string) (*entity.Account, error)
-	GetByUsernameAndPassword(ctx context.Context, username, password string) (*entity.Account, error)
+	GetByEmail(ctx context.Context, email string) (*entity.Account, error)
 	List(ctx context.Context, count, start int) ([]entity.Account, error)
 	Update(ctx context.Context, account *entity.Account) error
-	Delete(ctx context.Context, id int64) error
+	UpdatePasswordHash(ctx context.Context, id int64, passwordHash string) error
+	SetBalance(ctx context.Context, id, balance int64, audit BalanceAudit) error
+	SetEmail(ctx context.Context, id int64, email string) error
+	VerifyEmail(ctx context.Context, id int64, email string) (bool, error)
+	SetStatus(ctx context.Context, account *entity.Account) error
+	SetProfile(ctx context.Context, account *entity.Account) error
+	Erase(ctx context.Context, id int64) (bool, error)
 }
 
 type Token interface {
@@ -27,37 +50,153 @@ type Token interface {
 	InvalidateAll(ctx context.Context, id int64) error
 }
 
+type RefreshToken interface {
+	Create(ctx context.Context, token *entity.RefreshToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+	Use(ctx context.Context, id int64) (bool, error)
+}
+
+type Session interface {
+	Create(ctx context.Context, session *entity.Session) (int64, error)
+	GetByID(ctx context.Context, id int64) (*entity.Session, error)
+	ListActive(ctx context.Context, userID int64) ([]entity.Session, error)
+	ListByUser(ctx context.Context, userID int64) ([]entity.Session, error)
+	Touch(ctx context.Context, id int64) error
+	Revoke(ctx context.Context, id int64) error
+	RevokeAll(ctx context.Context, userID int64) error
+}
+
+type TwoFactor interface {
+	Get(ctx context.Context, accountID int64) (*entity.TwoFactor, error)
+	SetSecret(ctx context.Context, accountID int64, secret string) error
+	Enable(ctx context.Context, accountID, step int64, codeHashes []string) error
+	UseStep(ctx context.Context, accountID, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, accountID int64, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, accountID int64, codeHash string) (bool, error)
+	CountRecoveryCodes(ctx context.Context, accountID int64) (int, error)
+	Delete(ctx context.Context, accountID int64) error
+}
+
+type SignInChallenge interface {
+	Create(ctx context.Context, challenge *entity.SignInChallenge) error
+	GetByHash(ctx context.Context, tokenHash string) (*entity.SignInChallenge, error)
+	Attempt(ctx context.Context, id int64, maxAttempts int) (bool, error)
+	Use(ctx context.Context, id int64) (bool, error)
+}
+
+type EmailToken interface {
+	Create(ctx context.Context, token *entity.EmailToken) error
+	GetByHash(ctx context.Context, tokenHash string) (*entity.EmailToken, error)
+	Use(ctx context.Context, id int64) (bool, error)
+}
+
+type ExternalIdentity interface {
+	CreateWithAccount(ctx context.Context, account *entity.Account, identity *entity.ExternalIdentity) (int64, error)
+	GetBySubject(ctx context.Context, issuer, subject string) (*entity.ExternalIdentity, error)
+	ListByAccount(ctx context.Context, accountID int64) ([]entity.ExternalIdentity, error)
+	Touch(ctx context.Context, id int64, email *string) error
+}
+
+type OIDCLoginState interface {
+	Create(ctx context.Context, state *entity.OIDCLoginState) error
+	GetByHash(ctx context.Context, stateHash string) (*entity.OIDCLoginState, error)
+	Use(ctx context.Context, id int64) (bool, error)
+}
+
+type SignInFailure interface {
+	Get(ctx context.Context, key string) (*entity.SignInFailure, error)
+	Attempt(ctx context.Context, key string, resetAfter time.Duration, lockFor func(attempts int) time.Duration) (bool, error)
+	Refund(ctx context.Context, key string) error
+	Reset(ctx context.Context, key string) error
+}
+
+type SignInAttempt interface {
+	Create(ctx context.Context, attempt *entity.SignInAttempt) error
+	List(ctx context.Context, username, ip string, count, start int) ([]entity.SignInAttempt, error)
+}
+
+type APIKey interface {
+	Create(ctx context.Context, key *entity.APIKey) (int64, error)
+	GetByID(ctx context.Context, id int64) (*entity.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*entity.APIKey, error)
+	ListByAccount(ctx context.Context, accountID int64) ([]entity.APIKey, error)
+	Touch(ctx context.Context, id int64) error
+	Revoke(ctx context.Context, id int64) error
+}
+
+type License interface {
+	Create(ctx context.Context, document *entity.LicenseDocument) (int64, error)
+	GetByID(ctx context.Context, id int64) (*entity.LicenseDocument, error)
+	GetLatest(ctx context.Context, accountID int64) (*entity.LicenseDocument, error)
+	ListByAccount(ctx context.Context, accountID int64) ([]entity.LicenseDocument, error)
+	List(ctx context.Context, status string, count, start int) ([]entity.LicenseDocument, error)
+	HasApproved(ctx context.Context, accountID int64) (bool, error)
+	Review(ctx context.Context, document *entity.LicenseDocument) (bool, error)
+}
+
+type AuditLog interface {
+	Create(ctx context.Context, entry *entity.AuditEntry) error
+	List(ctx context.Context, filter *AuditFilter, count, start int) ([]entity.AuditEntry, error)
+	ListByTarget(ctx context.Context, targetType string, targetID int64, actions []string) ([]entity.AuditEntry, error)
+}
+
+type Role interface {
+	GetByName(ctx context.Context, name string) (*entity.Role, error)
+	List(ctx context.Context) ([]entity.Role, error)
+	ListByAccount(ctx context.Context, accountID int64) ([]string, error)
+	ListPermissions(ctx context.Context, accountID int64) ([]string, error)
+	Assign(ctx context.Context, accountID int64, role string) error
+	Unassign(ctx context.Context, accountID int64, role string) error
+}
+
 type Transport interface {
 	Create(ctx context.Context, transport *entity.Transport) (int64, error)
 	GetByID(ctx context.Context, id int64) (*entity.Transport, error)
 	GetByIdentifier(ctx context.Context, identifier string) (*entity.Transport, error)
+	List(ctx context.Context, transportType string, count, start int) ([]entity.Transport, error)
 	ListByType(ctx context.Context, transportType string, count, start int) ([]entity.Transport, error)
 	ListByOwner(ctx context.Context, ownerID int64, count, start int) ([]entity.Transport, error)
-	ListByAvailability(ctx context.Context, lat, long, radius float64, transportType string) ([]entity.Transport, error)
+	ListByAvailability(ctx context.Context, lat, long, radius float64, transportType string) ([]entity.NearbyTransport, error)
 	Update(ctx context.Context, transport *entity.Transport) error
 	ChangeAvailability(ctx context.Context, id int64, can_be_rented bool) error
-	Delete(ctx context.Context, id int64) error
+	UpdatePosition(ctx context.Context, id int64, lat, long float64) error
+	Delete(ctx context.Context, id int64) (bool, error)
 }
 
 type Rent interface {
-	StartRent(ctx context.Context, rent *entity.Rent) (int64, error)
-	EndRent(ctx context.Context, id int64, lat, long float64) error
+	StartRent(ctx context.Context, rent *entity.Rent, allowOverdraft bool, audit BalanceAudit) (int64, error)
+	EndRent(ctx context.Context, id int64, lat, long float64, audit RentAudit) error
 	GetByID(ctx context.Context, id int64) (*entity.Rent, error)
 	GetHistoryByUser(ctx context.Context, userID int64) ([]entity.Rent, error)
 	GetHistoryByTransport(ctx context.Context, transportID int64) ([]entity.Rent, error)
 	ListActive(ctx context.Context) ([]entity.Rent, error)
+	ListActiveByUser(ctx context.Context, userID int64) ([]entity.Rent, error)
+	HasActive(ctx context.Context, accountID int64) (bool, error)
 	Update(ctx context.Context, rent *entity.Rent) error
 	UpdateLastBilledTime(ctx context.Context, id int64) error
 	Delete(ctx context.Context, id int64) error
 }
 
 type Payment interface {
-	UpdateBalance(ctx context.Context, accountID, amount int64) error
+	UpdateBalance(ctx context.Context, accountID, amount int64, audit BalanceAudit) error
 }
 
 type Repositories struct {
 	Account
 	Token
+	RefreshToken
+	Session
+	TwoFactor
+	SignInChallenge
+	SignInFailure
+	SignInAttempt
+	EmailToken
+	ExternalIdentity
+	OIDCLoginState
+	APIKey
+	License
+	AuditLog
+	Role
 	Transport
 	Rent
 	Payment
@@ -65,10 +204,23 @@ type Repositories struct {
 
 func NewRepositories(db *sqlx.DB) *Repositories {
 	return &Repositories{
-		Account:   NewAccountRepository(db),
-		Token:     NewTokenRepository(db),
-		Transport: NewTransportRepository(db),
-		Rent:      NewRentRepository(db),
-		Payment:   NewPaymentRepository(db),
+		Account:          NewAccountRepository(db),
+		Token:            NewTokenRepository(db),
+		RefreshToken:     NewRefreshTokenRepository(db),
+		Session:          NewSessionRepository(db),
+		TwoFactor:        NewTwoFactorRepository(db),
+		SignInChallenge:  NewSignInChallengeRepository(db),
+		SignInFailure:    NewSignInFailureRepository(db),
+		SignInAttempt:    NewSignInAttemptRepository(db),
+		EmailToken:       NewEmailTokenRepository(db),
+		ExternalIdentity: NewExternalIdentityRepository(db),
+		OIDCLoginState:   NewOIDCLoginStateRepository(db),
+		APIKey:           NewAPIKeyRepository(db),
+		License:          NewLicenseRepository(db),
+		AuditLog:         NewAuditLogRepository(db),
+		Role:             NewRoleRepository(db),
+		Transport:        NewTransportRepository(db),
+		Rent:             NewRentRepository(db),
+		Payment:          NewPaymentRepository(db),
 	}
 }
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+type RoleRepository struct {
+	*sqlx.DB
+}
+
+func NewRoleRepository(db *sqlx.DB) *RoleRepository {
+	return &RoleRepository{db}
+}
+
+func (r *RoleRepository) GetByName(ctx context.Context, name string) (*entity.Role, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var role entity.Role
+	query := `
+		SELECT r.name, r.description, COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		WHERE r.name = $1
+		GROUP BY r.name
+	`
+	if err := r.QueryRowContext(ctx, query, name).Scan(&role.Name, &role.Description, pq.Array(&role.Permissions)); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+func (r *RoleRepository) List(ctx context.Context) ([]entity.Role, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	roles := make([]entity.Role, 0)
+	query := `
+		SELECT r.name, r.description, COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		GROUP BY r.name
+		ORDER BY r.name
+	`
+	rows, err := r.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role entity.Role
+		if err := rows.Scan(&role.Name, &role.Description, pq.Array(&role.Permissions)); err != nil {
+			return nil, err
+		}
+
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepository) ListByAccount(ctx context.Context, accountID int64) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	roles := make([]string, 0)
+	query := `SELECT role FROM account_roles WHERE account_id = $1 ORDER BY role`
+	if err := r.SelectContext(ctx, &roles, query, accountID); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (r *RoleRepository) ListPermissions(ctx context.Context, accountID int64) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	permissions := make([]string, 0)
+	query := `
+		SELECT DISTINCT rp.permission
+		FROM account_roles ar
+		JOIN role_permissions rp ON rp.role = ar.role
+		WHERE ar.account_id = $1
+	`
+	if err := r.SelectContext(ctx, &permissions, query, accountID); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (r *RoleRepository) Assign(ctx context.Context, accountID int64, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `INSERT INTO account_roles (account_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	if _, err := r.ExecContext(ctx, query, accountID, role); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *RoleRepository) Unassign(ctx context.Context, accountID int64, role string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `DELETE FROM account_roles WHERE account_id = $1 AND role = $2`
+	if _, err := r.ExecContext(ctx, query, accountID, role); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+// touchInterval limits how often last_used_at is written for a busy session.
+const touchInterval = "1 minute"
+
+type SessionRepository struct {
+	*sqlx.DB
+}
+
+func NewSessionRepository(db *sqlx.DB) *SessionRepository {
+	return &SessionRepository{db}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *entity.Session) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var id int64
+	query := `INSERT INTO sessions (user_id, user_agent, ip) VALUES ($1, $2, $3) RETURNING id`
+	if err := r.QueryRowContext(ctx, query, session.UserID, session.UserAgent, session.IP).Scan(&id); err != nil {
+		return -1, err
+	}
+
+	return id, nil
+}
+
+func (r *SessionRepository) GetByID(ctx context.Context, id int64) (*entity.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var session entity.Session
+	query := `SELECT * FROM sessions WHERE id = $1`
+	if err := r.QueryRowxContext(ctx, query, id).StructScan(&session); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// ListActive returns sessions that were not revoked and still hold a usable
+// refresh token.
+func (r *SessionRepository) ListActive(ctx context.Context, userID int64) ([]entity.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	sessions := make([]entity.Session, 0)
+	query := `
+		SELECT s.* FROM sessions s
+		WHERE s.user_id = $1 AND s.revoked_at IS NULL AND EXISTS (
+			SELECT 1 FROM refresh_tokens rt
+			WHERE rt.session_id = s.id AND rt.used_at IS NULL AND rt.revoked_at IS NULL AND rt.expires_at > NOW()
+		)
+		ORDER BY s.last_used_at DESC
+	`
+	rows, err := r.QueryxContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session entity.Session
+		if err := rows.StructScan(&session); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// ListByUser returns every session of the user, revoked ones included.
+func (r *SessionRepository) ListByUser(ctx context.Context, userID int64) ([]entity.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	sessions := make([]entity.Session, 0)
+	query := `SELECT * FROM sessions WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := r.QueryxContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var session entity.Session
+		if err := rows.StructScan(&session); err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepository) Touch(ctx context.Context, id int64) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE sessions SET last_used_at = NOW() WHERE id = $1 AND last_used_at < NOW() - INTERVAL '` + touchInterval + `'`
+	if _, err := r.ExecContext(ctx, query, id); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Revoke revokes the session together with its access and refresh tokens.
+func (r *SessionRepository) Revoke(ctx context.Context, id int64) error {
+	return r.revoke(ctx, `id = $1`, id)
+}
+
+func (r *SessionRepository) RevokeAll(ctx context.Context, userID int64) error {
+	return r.revoke(ctx, `user_id = $1`, userID)
+}
+
+func (r *SessionRepository) revoke(ctx context.Context, condition string, arg int64) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE sessions SET revoked_at = NOW() WHERE ` + condition + ` AND revoked_at IS NULL`
+	if _, err := tx.ExecContext(ctx, query, arg); err != nil {
+		return fmt.Errorf("revoke sessions: %w", err)
+	}
+
+	query = `UPDATE refresh_tokens SET revoked_at = NOW() WHERE revoked_at IS NULL AND session_id IN (SELECT id FROM sessions WHERE ` + condition + `)`
+	if _, err := tx.ExecContext(ctx, query, arg); err != nil {
+		return fmt.Errorf("revoke refresh tokens: %w", err)
+	}
+
+	query = `UPDATE tokens SET is_valid = FALSE WHERE is_valid AND session_id IN (SELECT id FROM sessions WHERE ` + condition + `)`
+	if _, err := tx.ExecContext(ctx, query, arg); err != nil {
+		return fmt.Errorf("invalidate tokens: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit revoke: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+type SignInFailureRepository struct {
+	*sqlx.DB
+}
+
+func NewSignInFailureRepository(db *sqlx.DB) *SignInFailureRepository {
+	return &SignInFailureRepository{db}
+}
+
+func (r *SignInFailureRepository) Get(ctx context.Context, key string) (*entity.SignInFailure, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var failure entity.SignInFailure
+	query := `SELECT * FROM sign_in_failures WHERE key = $1`
+	if err := r.QueryRowxContext(ctx, query, key).StructScan(&failure); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &failure, nil
+}
+
+// Attempt counts an attempt as a failure until it is refunded and reports
+// false, counting nothing, while the key is locked. The count starts over
+// once resetAfter passed since the previous attempt. The key is then locked
+// for lockFor of the attempts in a row, if not zero, before the row is
+// released, so attempts made at the same time cannot all find it unlocked.
+func (r *SignInFailureRepository) Attempt(ctx context.Context, key string, resetAfter time.Duration, lockFor func(attempts int) time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var attempts int
+	query := `
+		INSERT INTO sign_in_failures (key, failures, last_failure_at) VALUES ($1, 1, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN sign_in_failures.last_failure_at < NOW() - make_interval(secs => $2) THEN 1 ELSE sign_in_failures.failures + 1 END,
+			last_failure_at = NOW()
+		WHERE sign_in_failures.locked_until IS NULL OR sign_in_failures.locked_until <= NOW()
+		RETURNING failures
+	`
+	if err := tx.QueryRowContext(ctx, query, key, resetAfter.Seconds()).Scan(&attempts); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("count attempt: %w", err)
+	}
+
+	if delay := lockFor(attempts); delay > 0 {
+		query = `UPDATE sign_in_failures SET locked_until = NOW() + make_interval(secs => $1) WHERE key = $2`
+		if _, err := tx.ExecContext(ctx, query, delay.Seconds(), key); err != nil {
+			return false, fmt.Errorf("lock: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit attempt: %w", err)
+	}
+
+	return true, nil
+}
+
+// Refund takes back an attempt that succeeded and lifts the lock it may have
+// set. Any other attempt still locks the key again once it is counted.
+func (r *SignInFailureRepository) Refund(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE sign_in_failures SET failures = GREATEST(failures - 1, 0), locked_until = NULL WHERE key = $1`
+	if _, err := r.ExecContext(ctx, query, key); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *SignInFailureRepository) Reset(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `DELETE FROM sign_in_failures WHERE key = $1`
+	if _, err := r.ExecContext(ctx, query, key); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type SignInAttemptRepository struct {
+	*sqlx.DB
+}
+
+func NewSignInAttemptRepository(db *sqlx.DB) *SignInAttemptRepository {
+	return &SignInAttemptRepository{db}
+}
+
+func (r *SignInAttemptRepository) Create(ctx context.Context, attempt *entity.SignInAttempt) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `INSERT INTO sign_in_attempts (username, account_id, ip, user_agent, reason) VALUES ($1, $2, $3, $4, $5)`
+	if _, err := r.ExecContext(ctx, query, attempt.Username, attempt.AccountID, attempt.IP, attempt.UserAgent, attempt.Reason); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// List returns the newest attempts first, empty filters match everything.
+func (r *SignInAttemptRepository) List(ctx context.Context, username, ip string, count, start int) ([]entity.SignInAttempt, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	attempts := make([]entity.SignInAttempt, 0, count)
+	query := `
+		SELECT * FROM sign_in_attempts
+		WHERE ($1 = '' OR username = $1) AND ($2 = '' OR ip = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`
+	rows, err := r.QueryxContext(ctx, query, username, ip, count, start)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var attempt entity.SignInAttempt
+		if err := rows.StructScan(&attempt); err != nil {
+			return nil, err
+		}
+
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+type SignInChallengeRepository struct {
+	*sqlx.DB
+}
+
+func NewSignInChallengeRepository(db *sqlx.DB) *SignInChallengeRepository {
+	return &SignInChallengeRepository{db}
+}
+
+func (r *SignInChallengeRepository) Create(ctx context.Context, challenge *entity.SignInChallenge) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `INSERT INTO sign_in_challenges (account_id, token_hash, expires_at) VALUES ($1, $2, $3)`
+	if _, err := r.ExecContext(ctx, query, challenge.AccountID, challenge.TokenHash, challenge.ExpiresAt); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *SignInChallengeRepository) GetByHash(ctx context.Context, tokenHash string) (*entity.SignInChallenge, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var challenge entity.SignInChallenge
+	query := `SELECT * FROM sign_in_challenges WHERE token_hash = $1`
+	if err := r.QueryRowxContext(ctx, query, tokenHash).StructScan(&challenge); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// Attempt counts a code submitted for the challenge and reports false once
+// maxAttempts were made or the challenge was used.
+func (r *SignInChallengeRepository) Attempt(ctx context.Context, id int64, maxAttempts int) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE sign_in_challenges SET attempts = attempts + 1 WHERE id = $1 AND used_at IS NULL AND attempts < $2`
+	result, err := r.ExecContext(ctx, query, id, maxAttempts)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// Use marks the challenge as completed and reports false if it already was.
+func (r *SignInChallengeRepository) Use(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE sign_in_challenges SET used_at = NOW() WHERE id = $1 AND used_at IS NULL`
+	result, err := r.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/realdanielursul/simbir-go/internal/entity"
@@ -19,8 +20,8 @@ func (r *TokenRepository) Create(ctx context.Context, token *entity.Token) error
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
-	query := `INSERT INTO tokens (id, token_string) VALUES ($1, $2)`
-	if _, err := r.ExecContext(ctx, query, token.UserID, token.TokenString); err != nil {
+	query := `INSERT INTO tokens (user_id, session_id, token_string) VALUES ($1, $2, $3)`
+	if _, err := r.ExecContext(ctx, query, token.UserID, token.SessionID, token.TokenString); err != nil {
 		return err
 	}
 
@@ -34,6 +35,10 @@ func (r *TokenRepository) Get(ctx context.Context, tokenString string) (*entity.
 	var token entity.Token
 	query := `SELECT * FROM tokens WHERE token_string = $1`
 	if err := r.QueryRowxContext(ctx, query, tokenString).StructScan(&token); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
 		return nil, err
 	}
 
@@ -56,7 +61,7 @@ func (r *TokenRepository) InvalidateAll(ctx context.Context, id int64) error {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
-	query := `UPDATE tokens SET is_valid = FALSE WHERE id = $1`
+	query := `UPDATE tokens SET is_valid = FALSE WHERE user_id = $1`
 	if _, err := r.ExecContext(ctx, query, id); err != nil {
 		return err
 	}
@@ -3,10 +3,10 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"math"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/pkg/geo"
 )
 
 type TransportRepository struct {
@@ -35,7 +35,7 @@ func (r *TransportRepository) GetByID(ctx context.Context, id int64) (*entity.Tr
 	defer cancel()
 
 	var transport entity.Transport
-	query := `SELECT * FROM transports WHERE id = $1`
+	query := `SELECT * FROM transports WHERE id = $1 AND deleted_at IS NULL`
 	if err := r.QueryRowxContext(ctx, query, id).StructScan(&transport); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -52,7 +52,7 @@ func (r *TransportRepository) GetByIdentifier(ctx context.Context, identifier st
 	defer cancel()
 
 	var transport entity.Transport
-	query := `SELECT * FROM transports WHERE identifier = $1`
+	query := `SELECT * FROM transports WHERE identifier = $1 AND deleted_at IS NULL`
 	if err := r.QueryRowxContext(ctx, query, identifier).StructScan(&transport); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -64,6 +64,45 @@ func (r *TransportRepository) GetByIdentifier(ctx context.Context, identifier st
 	return &transport, nil
 }
 
+func (r *TransportRepository) List(ctx context.Context, transportType string, count, start int) ([]entity.Transport, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	transports := make([]entity.Transport, 0, count)
+
+	var query string
+	var rows *sqlx.Rows
+	var err error
+
+	if transportType == "All" {
+		query = `SELECT * FROM transports WHERE deleted_at IS NULL ORDER BY id LIMIT $1 OFFSET $2`
+		rows, err = r.QueryxContext(ctx, query, count, start)
+	} else {
+		query = `SELECT * FROM transports WHERE deleted_at IS NULL AND transport_type = $1 ORDER BY id LIMIT $2 OFFSET $3`
+		rows, err = r.QueryxContext(ctx, query, transportType, count, start)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transport entity.Transport
+		if err := rows.StructScan(&transport); err != nil {
+			return nil, err
+		}
+
+		transports = append(transports, transport)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transports, nil
+}
+
 func (r *TransportRepository) ListByType(ctx context.Context, transportType string, count, start int) ([]entity.Transport, error) {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
@@ -75,11 +114,11 @@ func (r *TransportRepository) ListByType(ctx context.Context, transportType stri
 	var err error
 
 	if transportType == "All" {
-		query = `SELECT * FROM transports WHERE can_be_rented = TRUE ORDER BY id`
-		rows, err = r.QueryxContext(ctx, query)
+		query = `SELECT * FROM transports WHERE can_be_rented = TRUE AND deleted_at IS NULL ORDER BY id LIMIT $1 OFFSET $2`
+		rows, err = r.QueryxContext(ctx, query, count, start)
 	} else {
-		query = `SELECT * FROM transports WHERE can_be_rented = TRUE AND transport_type = $1 ORDER BY id`
-		rows, err = r.QueryxContext(ctx, query, transportType)
+		query = `SELECT * FROM transports WHERE can_be_rented = TRUE AND deleted_at IS NULL AND transport_type = $1 ORDER BY id LIMIT $2 OFFSET $3`
+		rows, err = r.QueryxContext(ctx, query, transportType, count, start)
 	}
 
 	if err != nil {
@@ -108,7 +147,7 @@ func (r *TransportRepository) ListByOwner(ctx context.Context, ownerID int64, co
 	defer cancel()
 
 	transports := make([]entity.Transport, 0, count)
-	query := `SELECT * FROM transports WHERE owner_id = $1 LIMIT $2 OFFSET $3`
+	query := `SELECT * FROM transports WHERE owner_id = $1 AND deleted_at IS NULL LIMIT $2 OFFSET $3`
 	rows, err := r.QueryxContext(ctx, query, ownerID, count, start)
 	if err != nil {
 		return nil, err
@@ -131,38 +170,51 @@ func (r *TransportRepository) ListByOwner(ctx context.Context, ownerID int64, co
 	return transports, nil
 }
 
-func (r *TransportRepository) ListByAvailability(ctx context.Context, lat, long, radius float64, transportType string) ([]entity.Transport, error) {
+// ListByAvailability returns transport that can be rented within radius
+// meters of the point, nearest first. The bounding box of the circle lets the
+// position indexes skip transport far away, the exact distance is computed
+// for what is left.
+func (r *TransportRepository) ListByAvailability(ctx context.Context, lat, long, radius float64, transportType string) ([]entity.NearbyTransport, error) {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
-	transports := make([]entity.Transport, 0, 100)
-
-	var query string
-	var rows *sqlx.Rows
-	var err error
+	box := geo.BoundingBox(lat, long, radius)
 
-	if transportType == "All" {
-		query = `SELECT * FROM transports WHERE can_be_rented = TRUE ORDER BY id`
-		rows, err = r.QueryxContext(ctx, query)
-	} else {
-		query = `SELECT * FROM transports WHERE can_be_rented = TRUE AND transport_type = $1 ORDER BY id`
-		rows, err = r.QueryxContext(ctx, query, transportType)
+	// a box across the antimeridian is searched as its two halves
+	westMin, westMax, eastMin, eastMax := box.MinLong, box.MaxLong, box.MinLong, box.MaxLong
+	if box.MinLong > box.MaxLong {
+		westMin, westMax, eastMin, eastMax = -180, box.MaxLong, box.MinLong, 180
 	}
 
+	transports := make([]entity.NearbyTransport, 0, 100)
+	query := `
+		SELECT * FROM (
+			SELECT *, 2 * $3::DOUBLE PRECISION * ASIN(LEAST(1, SQRT(
+				POWER(SIN(RADIANS(latitude - $1) / 2), 2) +
+				COS(RADIANS($1)) * COS(RADIANS(latitude)) * POWER(SIN(RADIANS(longitude - $2) / 2), 2)
+			))) AS distance
+			FROM transports
+			WHERE can_be_rented = TRUE AND deleted_at IS NULL
+				AND ($5 = 'All' OR transport_type = $5)
+				AND latitude BETWEEN $6 AND $7
+				AND (longitude BETWEEN $8 AND $9 OR longitude BETWEEN $10 AND $11)
+		) AS nearby
+		WHERE distance <= $4
+		ORDER BY distance, id
+	`
+	rows, err := r.QueryxContext(ctx, query, lat, long, geo.EarthRadius, radius, transportType, box.MinLat, box.MaxLat, westMin, westMax, eastMin, eastMax)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var transport entity.Transport
+		var transport entity.NearbyTransport
 		if err := rows.StructScan(&transport); err != nil {
 			return nil, err
 		}
 
-		if isAvailable(lat, long, transport.Latitude, transport.Longitude, radius) {
-			transports = append(transports, transport)
-		}
+		transports = append(transports, transport)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -176,7 +228,7 @@ func (r *TransportRepository) Update(ctx context.Context, transport *entity.Tran
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
-	query := `UPDATE transports SET owner_id = $1, can_be_rented = $2, transport_type = $3, model = $4, color = $5, identifier = $6, description = $7, latitude = $8, longitude = $9, minute_price = $10, day_price = $11, updated_at = $12 WHERE id = $13`
+	query := `UPDATE transports SET owner_id = $1, can_be_rented = $2, transport_type = $3, model = $4, color = $5, identifier = $6, description = $7, latitude = $8, longitude = $9, minute_price = $10, day_price = $11, updated_at = $12 WHERE id = $13 AND deleted_at IS NULL`
 	if _, err := r.ExecContext(ctx, query, transport.OwnerID, transport.CanBeRented, transport.TransportType, transport.Model, transport.Color, transport.Identifier, transport.Description, transport.Latitude, transport.Longitude, transport.MinutePrice, transport.DayPrice, transport.UpdatedAt, transport.ID); err != nil {
 		return err
 	}
@@ -188,7 +240,7 @@ func (r *TransportRepository) ChangeAvailability(ctx context.Context, id int64,
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
-	query := `UPDATE transports SET can_be_rented = $1 WHERE id = $2`
+	query := `UPDATE transports SET can_be_rented = $1 WHERE id = $2 AND deleted_at IS NULL`
 	if _, err := r.ExecContext(ctx, query, can_be_rented, id); err != nil {
 		return err
 	}
@@ -196,22 +248,39 @@ func (r *TransportRepository) ChangeAvailability(ctx context.Context, id int64,
 	return nil
 }
 
-func (r *TransportRepository) Delete(ctx context.Context, id int64) error {
+func (r *TransportRepository) UpdatePosition(ctx context.Context, id int64, lat, long float64) error {
 	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
 	defer cancel()
 
-	query := `DELETE FROM transports WHERE id = $1`
-	if _, err := r.ExecContext(ctx, query, id); err != nil {
+	query := `UPDATE transports SET latitude = $1, longitude = $2, updated_at = NOW() WHERE id = $3`
+	if _, err := r.ExecContext(ctx, query, lat, long, id); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-func isAvailable(lat1, long1, lat2, long2, radius float64) bool {
-	x := lat1 - lat2
-	y := long1 - long2
-	r := math.Sqrt(x*x + y*y)
+// Delete withdraws the transport for good. The row stays for the rents that
+// reference it but is no longer found. It reports false if the transport
+// does not exist or has a rent in progress.
+func (r *TransportRepository) Delete(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE transports SET can_be_rented = FALSE, deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND deleted_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM rents WHERE transport_id = $1 AND time_end IS NULL)
+	`
+	result, err := r.ExecContext(ctx, query, id)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
 
-	return r < radius
+	return affected == 1, nil
 }
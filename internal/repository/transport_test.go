@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/pkg/geo"
+)
+
+const (
+	benchVehicles = 100000
+	benchRadius   = 1000
+	// benchSpread is the distance from the center of the synthetic fleet to
+	// the edges of its area, in meters
+	benchSpread = 15000
+	benchLat    = 54.3142
+	benchLong   = 48.4031
+)
+
+var benchTransportTypes = []string{"Car", "Bike", "Scooter"}
+
+// BenchmarkListByAvailability searches around random points among 100k
+// synthetic vehicles. It needs a migrated database given by TEST_DATABASE_DSN
+// and is skipped without one. The vehicles are removed afterwards.
+func BenchmarkListByAvailability(b *testing.B) {
+	db := testDB(b)
+	ctx := context.Background()
+	repos := NewRepositories(db)
+
+	ownerID, err := repos.Account.Create(ctx, &entity.Account{
+		Username:     fmt.Sprintf("bench-%d", rand.Int63()),
+		PasswordHash: "-",
+	})
+	if err != nil {
+		b.Fatalf("create owner: %v", err)
+	}
+
+	// owners may not be deleted while they have transport
+	b.Cleanup(func() {
+		if _, err := db.ExecContext(ctx, `DELETE FROM transports WHERE owner_id = $1`, ownerID); err != nil {
+			b.Errorf("delete transport: %v", err)
+			return
+		}
+
+		if _, err := db.ExecContext(ctx, `DELETE FROM accounts WHERE id = $1`, ownerID); err != nil {
+			b.Errorf("delete owner: %v", err)
+		}
+	})
+
+	random := rand.New(rand.NewSource(1))
+	if err := insertBenchVehicles(ctx, db, ownerID, random); err != nil {
+		b.Fatalf("insert vehicles: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `ANALYZE transports`); err != nil {
+		b.Fatalf("analyze: %v", err)
+	}
+
+	for _, transportType := range []string{"All", "Car"} {
+		b.Run(transportType, func(b *testing.B) {
+			found := 0
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				lat, long := benchPoint(random)
+				transports, err := repos.Transport.ListByAvailability(ctx, lat, long, benchRadius, transportType)
+				if err != nil {
+					b.Fatalf("search: %v", err)
+				}
+
+				found += len(transports)
+			}
+
+			b.ReportMetric(float64(found)/float64(b.N), "found/op")
+		})
+	}
+}
+
+// insertBenchVehicles copies the vehicles in one transaction, nine in ten can
+// be rented.
+func insertBenchVehicles(ctx context.Context, db *sqlx.DB, ownerID int64, random *rand.Rand) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("transports", "owner_id", "can_be_rented", "transport_type", "model", "color", "identifier", "latitude", "longitude", "minute_price", "day_price"))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := 0; i < benchVehicles; i++ {
+		lat, long := benchPoint(random)
+		if _, err := stmt.ExecContext(ctx, ownerID, random.Intn(10) != 0, benchTransportTypes[random.Intn(len(benchTransportTypes))], "model", "color", fmt.Sprintf("bench-%d-%d", ownerID, i), lat, long, 1500, 150000); err != nil {
+			return err
+		}
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return err
+	}
+
+	if err := stmt.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// benchPoint returns a random point of the square area of the fleet.
+func benchPoint(random *rand.Rand) (float64, float64) {
+	deltaLat := benchSpread / geo.EarthRadius * 180 / math.Pi
+	deltaLong := deltaLat / math.Cos(benchLat*math.Pi/180)
+
+	return benchLat + (random.Float64()*2-1)*deltaLat, benchLong + (random.Float64()*2-1)*deltaLong
+}
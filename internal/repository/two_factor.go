@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+type TwoFactorRepository struct {
+	*sqlx.DB
+}
+
+func NewTwoFactorRepository(db *sqlx.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db}
+}
+
+func (r *TwoFactorRepository) Get(ctx context.Context, accountID int64) (*entity.TwoFactor, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var twoFactor entity.TwoFactor
+	query := `SELECT * FROM two_factor WHERE account_id = $1`
+	if err := r.QueryRowxContext(ctx, query, accountID).StructScan(&twoFactor); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &twoFactor, nil
+}
+
+// SetSecret starts an enrollment, replacing a pending secret if any.
+func (r *TwoFactorRepository) SetSecret(ctx context.Context, accountID int64, secret string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO two_factor (account_id, secret) VALUES ($1, $2)
+		ON CONFLICT (account_id) DO UPDATE SET secret = EXCLUDED.secret, enabled_at = NULL, last_used_step = 0, created_at = NOW()
+	`
+	if _, err := r.ExecContext(ctx, query, accountID, secret); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Enable confirms a pending secret and replaces the recovery codes.
+func (r *TwoFactorRepository) Enable(ctx context.Context, accountID, step int64, codeHashes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE two_factor SET enabled_at = NOW(), last_used_step = $1 WHERE account_id = $2`
+	if _, err := tx.ExecContext(ctx, query, step, accountID); err != nil {
+		return fmt.Errorf("enable two factor: %w", err)
+	}
+
+	if err := replaceRecoveryCodes(ctx, tx, accountID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit enable: %w", err)
+	}
+
+	return nil
+}
+
+// UseStep records the time step of an accepted code and reports false if it
+// or a later step was already used, so a code cannot be replayed.
+func (r *TwoFactorRepository) UseStep(ctx context.Context, accountID, step int64) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE two_factor SET last_used_step = $1 WHERE account_id = $2 AND last_used_step < $1`
+	result, err := r.ExecContext(ctx, query, step, accountID)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, accountID int64, codeHashes []string) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(ctx, tx, accountID, codeHashes); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks an unused code as used and reports whether there was
+// one.
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, accountID int64, codeHash string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	query := `UPDATE recovery_codes SET used_at = NOW() WHERE account_id = $1 AND code_hash = $2 AND used_at IS NULL`
+	result, err := r.ExecContext(ctx, query, accountID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected > 0, nil
+}
+
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, accountID int64) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	var count int
+	query := `SELECT COUNT(*) FROM recovery_codes WHERE account_id = $1 AND used_at IS NULL`
+	if err := r.QueryRowContext(ctx, query, accountID).Scan(&count); err != nil {
+		return -1, err
+	}
+
+	return count, nil
+}
+
+// Delete disables two-factor authentication and drops the recovery codes.
+func (r *TwoFactorRepository) Delete(ctx context.Context, accountID int64) error {
+	ctx, cancel := context.WithTimeout(ctx, operationTimeout)
+	defer cancel()
+
+	tx, err := r.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE account_id = $1`, accountID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor WHERE account_id = $1`, accountID); err != nil {
+		return fmt.Errorf("delete two factor: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit delete: %w", err)
+	}
+
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sqlx.Tx, accountID int64, codeHashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE account_id = $1`, accountID); err != nil {
+		return fmt.Errorf("delete recovery codes: %w", err)
+	}
+
+	for _, codeHash := range codeHashes {
+		query := `INSERT INTO recovery_codes (account_id, code_hash) VALUES ($1, $2)`
+		if _, err := tx.ExecContext(ctx, query, accountID, codeHash); err != nil {
+			return fmt.Errorf("insert recovery code: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/realdanielursul/simbir-go/internal/repository"
+)
+
+// Permissions guarding admin service methods, granted through roles.
+const (
+	PermAccountsRead    = "accounts:read"
+	PermAccountsWrite   = "accounts:write"
+	PermAccountsBalance = "accounts:balance"
+	PermTransportsRead  = "transports:read"
+	PermTransportsWrite = "transports:write"
+	PermRentsRead       = "rents:read"
+	PermRentsWrite      = "rents:write"
+	PermRolesManage     = "roles:manage"
+	PermAuditRead       = "audit:read"
+	PermKYCReview       = "kyc:review"
+)
+
+var allPermissions = []string{
+	PermAccountsRead,
+	PermAccountsWrite,
+	PermAccountsBalance,
+	PermTransportsRead,
+	PermTransportsWrite,
+	PermRentsRead,
+	PermRentsWrite,
+	PermRolesManage,
+	PermAuditRead,
+	PermKYCReview,
+}
+
+// Principal is the authenticated caller.
+type Principal struct {
+	UserID      int64
+	Permissions []string
+	// TwoFactorRequired is set for staff, who hold no permissions until they
+	// enable two-factor authentication.
+	TwoFactorRequired bool
+	// Scopes limit callers authenticated with an API key, nil means the
+	// caller signed in with a token and is not limited.
+	Scopes []string
+	// IP is the address the caller connected from, kept in the audit log.
+	IP string
+}
+
+func (p *Principal) Can(permission string) bool {
+	for _, granted := range p.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasScope reports whether the caller may use a route requiring the scope.
+func (p *Principal) HasScope(scope string) bool {
+	if p.Scopes == nil {
+		return true
+	}
+
+	for _, granted := range p.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsAPIKey reports whether the caller authenticated with an API key.
+func (p *Principal) IsAPIKey() bool {
+	return p.Scopes != nil
+}
+
+// IsStaff reports whether the caller may use any admin endpoint.
+func (p *Principal) IsStaff() bool {
+	return len(p.Permissions) > 0
+}
+
+// SystemPrincipal has every permission, for tools running outside of a
+// request such as database seeding.
+func SystemPrincipal() *Principal {
+	return &Principal{Permissions: allPermissions}
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
+
+// staffCaller reports whether the caller stored in ctx is staff, including
+// staff whose permissions wait for a second factor.
+func staffCaller(ctx context.Context) bool {
+	principal, ok := PrincipalFromContext(ctx)
+	return ok && (principal.IsStaff() || principal.TwoFactorRequired)
+}
+
+// authorize checks the caller stored in ctx has the permission.
+func authorize(ctx context.Context, permission string) error {
+	principal, ok := PrincipalFromContext(ctx)
+	if !ok || !principal.Can(permission) {
+		return ErrAccessDenied
+	}
+
+	return nil
+}
+
+type AccessService struct {
+	accountRepo   repository.Account
+	roleRepo      repository.Role
+	twoFactorRepo repository.TwoFactor
+}
+
+func NewAccessService(accountRepo repository.Account, roleRepo repository.Role, twoFactorRepo repository.TwoFactor) *AccessService {
+	return &AccessService{
+		accountRepo:   accountRepo,
+		roleRepo:      roleRepo,
+		twoFactorRepo: twoFactorRepo,
+	}
+}
+
+func (s *AccessService) Principal(ctx context.Context, userID int64) (*Principal, error) {
+	account, err := s.accountRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	// is_admin predates roles and keeps every permission
+	permissions := allPermissions
+	if !account.IsAdmin {
+		permissions, err = s.roleRepo.ListPermissions(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+
+		sort.Strings(permissions)
+	}
+
+	if len(permissions) == 0 {
+		return &Principal{UserID: userID, Permissions: permissions}, nil
+	}
+
+	// any permission, not only is_admin, is granted behind a second factor
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil || twoFactor.EnabledAt == nil {
+		return &Principal{UserID: userID, Permissions: []string{}, TwoFactorRequired: true}, nil
+	}
+
+	return &Principal{UserID: userID, Permissions: permissions}, nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+func TestPrincipalRequiresTwoFactor(t *testing.T) {
+	enabledAt := time.Now()
+
+	tests := []struct {
+		name        string
+		admin       bool
+		permissions []string
+		twoFactor   bool
+		want        []string
+		required    bool
+	}{
+		{name: "customer", want: nil},
+		{name: "customer with two-factor", twoFactor: true, want: nil},
+		{name: "admin", admin: true, required: true},
+		{name: "admin with two-factor", admin: true, twoFactor: true, want: allPermissions},
+		{name: "finance role", permissions: []string{PermAccountsBalance, PermAccountsRead}, required: true},
+		{name: "finance role with two-factor", permissions: []string{PermAccountsBalance, PermAccountsRead}, twoFactor: true, want: []string{PermAccountsBalance, PermAccountsRead}},
+		{name: "superadmin role", permissions: allPermissions, required: true},
+		{name: "superadmin role with two-factor", permissions: allPermissions, twoFactor: true, want: allPermissions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accounts := newFakeAccounts()
+			id, _ := accounts.Create(context.Background(), &entity.Account{Username: "staff", IsAdmin: tt.admin})
+
+			twoFactor := &fakeTwoFactor{factors: map[int64]*entity.TwoFactor{}}
+			if tt.twoFactor {
+				twoFactor.factors[id] = &entity.TwoFactor{AccountID: id, EnabledAt: &enabledAt}
+			}
+
+			access := NewAccessService(accounts, &fakeRoles{permissions: map[int64][]string{id: tt.permissions}}, twoFactor)
+
+			principal, err := access.Principal(context.Background(), id)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if principal.TwoFactorRequired != tt.required {
+				t.Errorf("TwoFactorRequired = %v, want %v", principal.TwoFactorRequired, tt.required)
+			}
+
+			want := slices.Sorted(slices.Values(tt.want))
+			got := slices.Sorted(slices.Values(principal.Permissions))
+			if !slices.Equal(got, want) {
+				t.Errorf("permissions = %v, want %v", got, want)
+			}
+
+			if tt.required && principal.IsStaff() {
+				t.Error("staff without a second factor can use admin endpoints")
+			}
+		})
+	}
+}
+
+func TestStaffCannotDisableTwoFactor(t *testing.T) {
+	accounts := newFakeAccounts()
+	id, _ := accounts.Create(context.Background(), &entity.Account{Username: "finance"})
+
+	service := NewAccountService(accounts, fakeTokens{}, fakeRefreshTokens{}, &fakeSessions{}, &fakeTwoFactor{}, nil,
+		nil, nil, nil, nil, time.Minute, time.Hour, "Simbir.GO", time.Minute)
+
+	ctx := WithPrincipal(context.Background(), &Principal{UserID: id, Permissions: []string{PermAccountsBalance}})
+	if err := service.DisableTwoFactor(ctx, id, "123456", &ClientInfo{IP: "192.0.2.1"}); !errors.Is(err, ErrTwoFactorRequired) {
+		t.Fatalf("got %v, want %v", err, ErrTwoFactorRequired)
+	}
+}
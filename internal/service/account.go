@@ -2,40 +2,75 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/realdanielursul/simbir-go/internal/entity"
 	"github.com/realdanielursul/simbir-go/internal/repository"
 	"github.com/realdanielursul/simbir-go/pkg/hasher"
+	"github.com/realdanielursul/simbir-go/pkg/jwtkeys"
+	"github.com/sirupsen/logrus"
 )
 
 type TokenClaims struct {
 	jwt.StandardClaims
-	UserID  int64
-	IsAdmin bool
+	UserID    int64
+	IsAdmin   bool
+	SessionID int64
 }
 
 type AccountService struct {
-	accountRepo    repository.Account
-	tokenRepo      repository.Token
-	passwordHasher hasher.PasswordHasher
-	signKey        string
-	tokenTTL       time.Duration
+	accountRepo      repository.Account
+	tokenRepo        repository.Token
+	refreshTokenRepo repository.RefreshToken
+	sessionRepo      repository.Session
+	twoFactorRepo    repository.TwoFactor
+	challengeRepo    repository.SignInChallenge
+	passwordHasher   hasher.PasswordHasher
+	validator        *Validator
+	guard            *SignInGuard
+	keys             *jwtkeys.KeySet
+	tokenTTL         time.Duration
+	refreshTokenTTL  time.Duration
+	twoFactorIssuer  string
+	challengeTTL     time.Duration
+
+	// a sign in with an unknown username verifies the password against
+	// dummy, so it takes as long as with a wrong password
+	dummyOnce sync.Once
+	dummy     string
 }
 
-func NewAccountService(accountRepo repository.Account, tokenRepo repository.Token, passwordHasher hasher.PasswordHasher, signKey string, tokenTTL time.Duration) *AccountService {
+func NewAccountService(accountRepo repository.Account, tokenRepo repository.Token, refreshTokenRepo repository.RefreshToken, sessionRepo repository.Session, twoFactorRepo repository.TwoFactor, challengeRepo repository.SignInChallenge, passwordHasher hasher.PasswordHasher, validator *Validator, guard *SignInGuard, keys *jwtkeys.KeySet, tokenTTL, refreshTokenTTL time.Duration, twoFactorIssuer string, challengeTTL time.Duration) *AccountService {
 	return &AccountService{
-		accountRepo:    accountRepo,
-		tokenRepo:      tokenRepo,
-		passwordHasher: passwordHasher,
-		signKey:        signKey,
-		tokenTTL:       tokenTTL,
+		accountRepo:      accountRepo,
+		tokenRepo:        tokenRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		sessionRepo:      sessionRepo,
+		twoFactorRepo:    twoFactorRepo,
+		challengeRepo:    challengeRepo,
+		passwordHasher:   passwordHasher,
+		validator:        validator,
+		guard:            guard,
+		keys:             keys,
+		tokenTTL:         tokenTTL,
+		refreshTokenTTL:  refreshTokenTTL,
+		twoFactorIssuer:  twoFactorIssuer,
+		challengeTTL:     challengeTTL,
 	}
 }
 
 func (s *AccountService) SignUp(ctx context.Context, input *AccountInput) (int64, error) {
+	if err := s.validator.Account(input); err != nil {
+		return -1, err
+	}
+
 	// check username uniqueness
 	account, err := s.accountRepo.GetByUsername(ctx, input.Username)
 	if err != nil {
@@ -46,16 +81,6 @@ func (s *AccountService) SignUp(ctx context.Context, input *AccountInput) (int64
 		return -1, ErrUsernameAlreadyExists
 	}
 
-	// validate username
-	if err := validateUsername(input.Username); err != nil {
-		return -1, err
-	}
-
-	// validate password
-	if err := validatePassword(input.Password); err != nil {
-		return -1, err
-	}
-
 	id, err := s.accountRepo.Create(ctx, &entity.Account{
 		Username:     input.Username,
 		PasswordHash: s.passwordHasher.Hash(input.Password),
@@ -67,39 +92,157 @@ func (s *AccountService) SignUp(ctx context.Context, input *AccountInput) (int64
 	return id, nil
 }
 
-func (s *AccountService) SignIn(ctx context.Context, input *AccountInput) (string, error) {
-	account, err := s.accountRepo.GetByUsernameAndPassword(ctx, input.Username, s.passwordHasher.Hash(input.Password))
+func (s *AccountService) SignIn(ctx context.Context, input *AccountInput, client *ClientInfo) (*SignInOutput, error) {
+	if err := s.guard.Check(ctx, input.Username, client); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetByUsername(ctx, input.Username)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &TokenClaims{
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(s.tokenTTL).Unix(),
-			IssuedAt:  time.Now().Unix(),
-		},
-		UserID:  account.ID,
-		IsAdmin: account.IsAdmin,
-	})
+	if account == nil {
+		s.passwordHasher.Verify(input.Password, s.dummyHash())
+
+		if err := s.guard.Fail(ctx, input.Username, nil, client, SignInUnknownUsername); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrInvalidCredentials
+	}
+
+	rehash, err := s.verifyPassword(ctx, account, input.Password, client)
+	if err != nil {
+		return nil, err
+	}
 
-	tokenString, err := token.SignedString([]byte(s.signKey))
+	// upgrade legacy or outdated hash, sign in anyway if it fails
+	if rehash {
+		if err := s.accountRepo.UpdatePasswordHash(ctx, account.ID, s.passwordHasher.Hash(input.Password)); err != nil {
+			logrus.Errorf("failed to rehash password of account %d: %v", account.ID, err)
+		}
+	}
+
+	// with a second factor the failures are kept until it passed, in
+	// VerifySignIn, or the right password would reset the count of wrong codes
+	twoFactor, err := s.twoFactorRepo.Get(ctx, account.ID)
 	if err != nil {
-		return "", ErrCannotSignToken
+		return nil, err
 	}
 
-	err = s.tokenRepo.Create(ctx, &entity.Token{
-		UserID:      account.ID,
-		TokenString: tokenString,
-		IsValid:     true,
+	if twoFactor == nil || twoFactor.EnabledAt == nil {
+		err = s.guard.Succeed(ctx, account.Username, client)
+	} else {
+		err = s.guard.Pass(ctx, account.Username, client)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signInAccount(ctx, account, client)
+}
+
+// dummyHash returns a hash made with the current parameters, computed on
+// first use as they may be expensive. Whether a password matches it does not
+// matter.
+func (s *AccountService) dummyHash() string {
+	s.dummyOnce.Do(func() {
+		s.dummy = s.passwordHasher.Hash("unknown username")
 	})
+
+	return s.dummy
+}
+
+// confirmPassword checks the password of a signed in account before a change
+// that needs it again. It is guarded like a sign in, a stolen session must
+// not allow guessing the password without running into the lockout.
+func (s *AccountService) confirmPassword(ctx context.Context, account *entity.Account, password string, client *ClientInfo) error {
+	if err := s.guard.Check(ctx, account.Username, client); err != nil {
+		return err
+	}
+
+	if _, err := s.verifyPassword(ctx, account, password, client); err != nil {
+		return err
+	}
+
+	return s.guard.Succeed(ctx, account.Username, client)
+}
+
+// verifyPassword records a wrong password, the guard must have been checked
+// before and a right one is left to the caller to settle with it. It reports
+// whether the hash should be upgraded.
+func (s *AccountService) verifyPassword(ctx context.Context, account *entity.Account, password string, client *ClientInfo) (bool, error) {
+	ok, rehash := s.passwordHasher.Verify(password, account.PasswordHash)
+	if !ok {
+		if err := s.guard.Fail(ctx, account.Username, &account.ID, client, SignInInvalidPassword); err != nil {
+			return false, err
+		}
+
+		return false, ErrInvalidCredentials
+	}
+
+	return rehash, nil
+}
+
+// Refresh rotates the refresh token. A token that was already used means it
+// leaked, so the whole session is revoked.
+func (s *AccountService) Refresh(ctx context.Context, refreshToken string) (*TokenOutput, error) {
+	token, err := s.refreshTokenRepo.GetByHash(ctx, hashToken(refreshToken))
 	if err != nil {
-		return "", err
+		return nil, err
+	}
+
+	if token == nil || token.RevokedAt != nil || time.Now().After(token.ExpiresAt) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	used, err := s.refreshTokenRepo.Use(ctx, token.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !used {
+		logrus.Warnf("refresh token reuse detected for account %d, revoking session %d", token.UserID, token.SessionID)
+		if err := s.sessionRepo.Revoke(ctx, token.SessionID); err != nil {
+			return nil, err
+		}
+
+		return nil, ErrInvalidRefreshToken
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, token.UserID)
+	if err != nil {
+		return nil, err
 	}
 
-	return tokenString, nil
+	if account == nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if err := checkStatus(account); err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionRepo.Touch(ctx, token.SessionID); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, account, token.SessionID)
 }
 
 func (s *AccountService) SignOut(ctx context.Context, tokenString string) error {
+	token, err := s.tokenRepo.Get(ctx, tokenString)
+	if err != nil {
+		return err
+	}
+
+	// tokens issued before sessions are invalidated one by one
+	if token != nil && token.SessionID != nil {
+		return s.sessionRepo.Revoke(ctx, *token.SessionID)
+	}
+
 	if err := s.tokenRepo.Invalidate(ctx, tokenString); err != nil {
 		return err
 	}
@@ -118,33 +261,38 @@ func (s *AccountService) GetAccount(ctx context.Context, id int64) (*AccountOutp
 	}
 
 	return &AccountOutput{
-		ID:        account.ID,
-		Username:  account.Username,
-		Balance:   float64(account.Balance) / 100,
-		CreatedAt: account.CreatedAt,
-		UpdatedAt: account.UpdatedAt,
+		ID:            account.ID,
+		Username:      account.Username,
+		Email:         account.Email,
+		EmailVerified: account.EmailVerifiedAt != nil,
+		Balance:       float64(account.Balance) / 100,
+		CreatedAt:     account.CreatedAt,
+		UpdatedAt:     account.UpdatedAt,
 	}, nil
 }
 
 func (s *AccountService) UpdateAccount(ctx context.Context, id int64, input *AccountInput) error {
-	// check username uniqueness
-	account, err := s.accountRepo.GetByUsername(ctx, input.Username)
+	if err := s.validator.Account(input); err != nil {
+		return err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	if account != nil {
-		return ErrUsernameAlreadyExists
+	if account == nil {
+		return ErrAccountNotFound
 	}
 
-	// validate username
-	if err := validateUsername(input.Username); err != nil {
+	// check username uniqueness
+	existing, err := s.accountRepo.GetByUsername(ctx, input.Username)
+	if err != nil {
 		return err
 	}
 
-	// validate password
-	if err := validatePassword(input.Password); err != nil {
-		return err
+	if existing != nil && existing.ID != id {
+		return ErrUsernameAlreadyExists
 	}
 
 	err = s.accountRepo.Update(ctx, &entity.Account{
@@ -163,35 +311,218 @@ func (s *AccountService) UpdateAccount(ctx context.Context, id int64, input *Acc
 		return err
 	}
 
+	if err := s.sessionRepo.RevokeAll(ctx, id); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (s *AccountService) ValidateToken(ctx context.Context, tokenString string) (int64, bool, error) {
-	_, err := jwt.ParseWithClaims(tokenString, &TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+func (s *AccountService) ListSessions(ctx context.Context, userID, currentSessionID int64) ([]SessionOutput, error) {
+	sessions, err := s.sessionRepo.ListActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]SessionOutput, 0, len(sessions))
+	for _, session := range sessions {
+		output = append(output, SessionOutput{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+
+	return output, nil
+}
+
+func (s *AccountService) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session == nil || session.UserID != userID || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+
+	return s.sessionRepo.Revoke(ctx, sessionID)
+}
+
+func (s *AccountService) ValidateToken(ctx context.Context, tokenString string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		// any key still published is accepted
+		kid, _ := token.Header["kid"].(string)
+		key, ok := s.keys.Key(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key id: %q", kid)
+		}
+
+		if token.Method.Alg() != key.Algorithm {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
-		return []byte(s.signKey), nil
+		return key.PublicKey, nil
 	})
 	if err != nil {
-		return -1, false, ErrCannotParseToken
+		return nil, ErrCannotParseToken
 	}
 
+	// check token was not invalidated by sign out
 	token, err := s.tokenRepo.Get(ctx, tokenString)
 	if err != nil {
-		return -1, false, err
+		return nil, err
+	}
+
+	if token == nil || !token.IsValid {
+		return nil, ErrInvalidToken
+	}
+
+	// the account may have been blocked since the token was issued
+	account, err := s.accountRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err := checkStatus(account); err != nil {
+		return nil, err
+	}
+
+	if token.SessionID != nil {
+		if err := s.sessionRepo.Touch(ctx, *token.SessionID); err != nil {
+			return nil, err
+		}
 	}
 
-	return token.UserID, token.IsValid, nil
+	return claims, nil
 }
 
-////////////////////////////////////////////////////////////////////////////////////////////////////////////
+func (s *AccountService) PublicKeys() jwtkeys.JWKS {
+	return s.keys.JWKS()
+}
 
-func validateUsername(username string) error {
-	return nil
+// signInAccount finishes a sign in whose first factor was checked, with a
+// challenge if the account has two-factor authentication enabled.
+func (s *AccountService) signInAccount(ctx context.Context, account *entity.Account, client *ClientInfo) (*SignInOutput, error) {
+	if err := checkStatus(account); err != nil {
+		return nil, err
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, account.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor != nil && twoFactor.EnabledAt != nil {
+		challenge, err := s.createChallenge(ctx, account.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		return &SignInOutput{Challenge: challenge}, nil
+	}
+
+	tokens, err := s.startSession(ctx, account, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignInOutput{
+		Token:        tokens.Token,
+		RefreshToken: tokens.RefreshToken,
+		ExpiresIn:    tokens.ExpiresIn,
+	}, nil
 }
 
-func validatePassword(password string) error {
-	return nil
+func (s *AccountService) startSession(ctx context.Context, account *entity.Account, client *ClientInfo) (*TokenOutput, error) {
+	sessionID, err := s.sessionRepo.Create(ctx, &entity.Session{
+		UserID:    account.ID,
+		UserAgent: client.UserAgent,
+		IP:        client.IP,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(ctx, account, sessionID)
+}
+
+// issueTokens signs a short-lived access token and creates a refresh token
+// for the session.
+func (s *AccountService) issueTokens(ctx context.Context, account *entity.Account, sessionID int64) (*TokenOutput, error) {
+	key, err := s.keys.SigningKey()
+	if err != nil {
+		return nil, ErrCannotSignToken
+	}
+
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(key.Algorithm), &TokenClaims{
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(s.tokenTTL).Unix(),
+			IssuedAt:  time.Now().Unix(),
+		},
+		UserID:    account.ID,
+		IsAdmin:   account.IsAdmin,
+		SessionID: sessionID,
+	})
+	token.Header["kid"] = key.ID
+
+	tokenString, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		return nil, ErrCannotSignToken
+	}
+
+	err = s.tokenRepo.Create(ctx, &entity.Token{
+		UserID:      account.ID,
+		TokenString: tokenString,
+		IsValid:     true,
+		SessionID:   &sessionID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.refreshTokenRepo.Create(ctx, &entity.RefreshToken{
+		UserID:    account.ID,
+		SessionID: sessionID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenOutput{
+		Token:        tokenString,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.tokenTTL.Seconds()),
+	}, nil
+}
+
+func randomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken hashes refresh tokens before storing them, they are random
+// enough for a plain SHA-256.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
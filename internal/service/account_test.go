@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/pkg/hasher"
+)
+
+// testArgon2idParams keep the tests fast, they are far below what servers use.
+var testArgon2idParams = hasher.Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// recordingHasher records the hashes passwords are verified against.
+type recordingHasher struct {
+	hasher.PasswordHasher
+
+	mu       sync.Mutex
+	verified []string
+}
+
+func (h *recordingHasher) Verify(password, hash string) (bool, bool) {
+	h.mu.Lock()
+	h.verified = append(h.verified, hash)
+	h.mu.Unlock()
+
+	return h.PasswordHasher.Verify(password, hash)
+}
+
+func TestSignInUnknownUsernameVerifiesPassword(t *testing.T) {
+	passwordHasher := &recordingHasher{PasswordHasher: hasher.NewArgon2idHasher(testArgon2idParams, nil)}
+	accounts := newFakeAccounts()
+	_, _ = accounts.Create(context.Background(), &entity.Account{
+		Username:     "jane",
+		PasswordHash: passwordHasher.Hash("correct horse"),
+	})
+
+	guard := NewSignInGuard(newFakeSignInFailures(), &fakeSignInAttempts{}, testLockout)
+	service := NewAccountService(accounts, fakeTokens{}, fakeRefreshTokens{}, &fakeSessions{}, &fakeTwoFactor{}, nil,
+		passwordHasher, nil, guard, nil, time.Minute, time.Hour, "Simbir.GO", time.Minute)
+
+	client := &ClientInfo{UserAgent: "test", IP: "192.0.2.1"}
+	for _, username := range []string{"john", "jim", "jane"} {
+		_, err := service.SignIn(context.Background(), &AccountInput{Username: username, Password: "guess"}, client)
+		if !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("sign in as %s: got %v, want %v", username, err, ErrInvalidCredentials)
+		}
+	}
+
+	// unknown and known usernames alike cost an argon2id verification with
+	// the configured parameters
+	if len(passwordHasher.verified) != 3 {
+		t.Fatalf("%d passwords verified for 3 sign ins", len(passwordHasher.verified))
+	}
+
+	for i, hash := range passwordHasher.verified {
+		if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+			t.Errorf("sign in %d verified against %q, want an argon2id hash of the configured parameters", i+1, hash)
+		}
+	}
+
+	if passwordHasher.verified[0] != passwordHasher.verified[1] {
+		t.Error("the dummy hash is computed again for every unknown username")
+	}
+}
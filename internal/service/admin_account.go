@@ -11,17 +11,37 @@ import (
 
 type AdminAccountService struct {
 	accountRepo    repository.Account
+	sessionRepo    repository.Session
 	passwordHasher hasher.PasswordHasher
+	validator      *Validator
+	guard          *SignInGuard
+	auditor        *Auditor
+	privacy        *PrivacyService
+	payments       *PaymentService
 }
 
-func NewAdminAccountService(accountRepo repository.Account, passwordHasher hasher.PasswordHasher) *AdminAccountService {
+func NewAdminAccountService(accountRepo repository.Account, sessionRepo repository.Session, passwordHasher hasher.PasswordHasher, validator *Validator, guard *SignInGuard, auditor *Auditor, privacy *PrivacyService, payments *PaymentService) *AdminAccountService {
 	return &AdminAccountService{
 		accountRepo:    accountRepo,
+		sessionRepo:    sessionRepo,
 		passwordHasher: passwordHasher,
+		validator:      validator,
+		guard:          guard,
+		auditor:        auditor,
+		privacy:        privacy,
+		payments:       payments,
 	}
 }
 
 func (s *AdminAccountService) CreateAccount(ctx context.Context, input *AdminAccountInput) (int64, error) {
+	if err := authorize(ctx, PermAccountsWrite); err != nil {
+		return -1, err
+	}
+
+	if err := s.validator.AdminAccount(input); err != nil {
+		return -1, err
+	}
+
 	// check username uniqueness
 	account, err := s.accountRepo.GetByUsername(ctx, input.Username)
 	if err != nil {
@@ -32,20 +52,41 @@ func (s *AdminAccountService) CreateAccount(ctx context.Context, input *AdminAcc
 		return -1, ErrUsernameAlreadyExists
 	}
 
-	id, err := s.accountRepo.Create(ctx, &entity.Account{
+	// balance and admin rights need their own permissions
+	if input.Balance != 0 {
+		if err := authorize(ctx, PermAccountsBalance); err != nil {
+			return -1, err
+		}
+	}
+
+	if input.IsAdmin {
+		if err := authorize(ctx, PermRolesManage); err != nil {
+			return -1, err
+		}
+	}
+
+	created := &entity.Account{
 		Username:     input.Username,
 		PasswordHash: s.passwordHasher.Hash(input.Password),
 		IsAdmin:      input.IsAdmin,
 		Balance:      int64(input.Balance * 100),
-	})
+	}
+
+	id, err := s.accountRepo.Create(ctx, created)
 	if err != nil {
 		return -1, err
 	}
 
+	s.auditor.Record(ctx, AuditAccountCreate, AuditTargetAccount, id, nil, auditAccount(created))
+
 	return id, nil
 }
 
 func (s *AdminAccountService) GetAccount(ctx context.Context, id int64) (*AdminAccountOutput, error) {
+	if err := authorize(ctx, PermAccountsRead); err != nil {
+		return nil, err
+	}
+
 	account, err := s.accountRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -56,16 +97,31 @@ func (s *AdminAccountService) GetAccount(ctx context.Context, id int64) (*AdminA
 	}
 
 	return &AdminAccountOutput{
-		ID:        account.ID,
-		Username:  account.Username,
-		IsAdmin:   account.IsAdmin,
-		Balance:   float64(account.Balance) / 100,
-		CreatedAt: account.CreatedAt,
-		UpdatedAt: account.UpdatedAt,
+		ID:            account.ID,
+		Username:      account.Username,
+		Email:         account.Email,
+		EmailVerified: account.EmailVerifiedAt != nil,
+		IsAdmin:       account.IsAdmin,
+		Balance:       float64(account.Balance) / 100,
+		CreatedAt:     account.CreatedAt,
+		UpdatedAt:     account.UpdatedAt,
+		ErasedAt:      account.ErasedAt,
+		Status:        accountStatus(account),
+		StatusReason:  account.StatusReason,
+		StatusUntil:   account.StatusUntil,
+		StatusSetBy:   account.StatusSetBy,
+		StatusSetAt:   account.StatusSetAt,
+		FullName:      account.FullName,
+		Phone:         account.Phone,
+		BirthDate:     formatDate(account.BirthDate),
 	}, nil
 }
 
 func (s *AdminAccountService) ListAccounts(ctx context.Context, count, start int) ([]AdminAccountOutput, error) {
+	if err := authorize(ctx, PermAccountsRead); err != nil {
+		return nil, err
+	}
+
 	accounts, err := s.accountRepo.List(ctx, count, start)
 	if err != nil {
 		return nil, err
@@ -74,12 +130,23 @@ func (s *AdminAccountService) ListAccounts(ctx context.Context, count, start int
 	accountsOutput := make([]AdminAccountOutput, 0, len(accounts))
 	for _, account := range accounts {
 		accountOutput := AdminAccountOutput{
-			ID:        account.ID,
-			Username:  account.Username,
-			IsAdmin:   account.IsAdmin,
-			Balance:   float64(account.Balance) / 100,
-			CreatedAt: account.CreatedAt,
-			UpdatedAt: account.UpdatedAt,
+			ID:            account.ID,
+			Username:      account.Username,
+			Email:         account.Email,
+			EmailVerified: account.EmailVerifiedAt != nil,
+			IsAdmin:       account.IsAdmin,
+			Balance:       float64(account.Balance) / 100,
+			CreatedAt:     account.CreatedAt,
+			UpdatedAt:     account.UpdatedAt,
+			ErasedAt:      account.ErasedAt,
+			Status:        accountStatus(&account),
+			StatusReason:  account.StatusReason,
+			StatusUntil:   account.StatusUntil,
+			StatusSetBy:   account.StatusSetBy,
+			StatusSetAt:   account.StatusSetAt,
+			FullName:      account.FullName,
+			Phone:         account.Phone,
+			BirthDate:     formatDate(account.BirthDate),
 		}
 
 		accountsOutput = append(accountsOutput, accountOutput)
@@ -89,32 +156,96 @@ func (s *AdminAccountService) ListAccounts(ctx context.Context, count, start int
 }
 
 func (s *AdminAccountService) UpdateAccount(ctx context.Context, id int64, input *AdminAccountInput) error {
+	if err := authorize(ctx, PermAccountsWrite); err != nil {
+		return err
+	}
+
+	if err := s.validator.AdminAccount(input); err != nil {
+		return err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// an erased account must not get a username and password back
+	if account == nil || account.ErasedAt != nil {
+		return ErrAccountNotFound
+	}
+
 	// check username uniqueness
-	account, err := s.accountRepo.GetByUsername(ctx, input.Username)
+	existing, err := s.accountRepo.GetByUsername(ctx, input.Username)
 	if err != nil {
 		return err
 	}
 
-	if account != nil {
+	if existing != nil && existing.ID != id {
 		return ErrUsernameAlreadyExists
 	}
 
-	err = s.accountRepo.Update(ctx, &entity.Account{
+	// balance and admin rights need their own permissions
+	if int64(input.Balance*100) != account.Balance {
+		if err := authorize(ctx, PermAccountsBalance); err != nil {
+			return err
+		}
+	}
+
+	if input.IsAdmin != account.IsAdmin {
+		if err := authorize(ctx, PermRolesManage); err != nil {
+			return err
+		}
+	}
+
+	updated := &entity.Account{
 		ID:           id,
 		Username:     input.Username,
 		PasswordHash: s.passwordHasher.Hash(input.Password),
 		IsAdmin:      input.IsAdmin,
 		Balance:      int64(input.Balance * 100),
+		Email:        account.Email,
 		UpdatedAt:    time.Now().UTC(),
-	})
+	}
+
+	if err := s.accountRepo.Update(ctx, updated); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, AuditAccountUpdate, AuditTargetAccount, id, auditAccount(account), auditAccount(updated))
+
+	return nil
+}
+
+func (s *AdminAccountService) UpdateBalance(ctx context.Context, id int64, input *AdminBalanceInput) error {
+	if err := authorize(ctx, PermAccountsBalance); err != nil {
+		return err
+	}
+
+	if err := s.validator.Balance(input.Balance); err != nil {
+		return err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
+	if account == nil {
+		return ErrAccountNotFound
+	}
+
+	if err := s.accountRepo.SetBalance(ctx, id, int64(input.Balance*100), s.auditor.Balance(ctx, AuditAccountBalance, id)); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 func (s *AdminAccountService) DeleteAccount(ctx context.Context, id int64) error {
+	if err := authorize(ctx, PermAccountsWrite); err != nil {
+		return err
+	}
+
 	account, err := s.accountRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -124,9 +255,169 @@ func (s *AdminAccountService) DeleteAccount(ctx context.Context, id int64) error
 		return ErrAccountNotFound
 	}
 
-	if err := s.accountRepo.Delete(ctx, id); err != nil {
+	// rents must be kept, so accounts are erased rather than deleted
+	return s.privacy.erase(ctx, account)
+}
+
+func (s *AdminAccountService) ListSessions(ctx context.Context, id int64) ([]SessionOutput, error) {
+	if err := authorize(ctx, PermAccountsRead); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	sessions, err := s.sessionRepo.ListActive(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]SessionOutput, 0, len(sessions))
+	for _, session := range sessions {
+		output = append(output, SessionOutput{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+		})
+	}
+
+	return output, nil
+}
+
+// SetStatus suspends, bans or reactivates the account. A blocked account is
+// signed out everywhere and its rents are ended where the transport stands.
+func (s *AdminAccountService) SetStatus(ctx context.Context, id int64, input *AccountStatusInput) error {
+	if err := authorize(ctx, PermAccountsWrite); err != nil {
+		return err
+	}
+
+	if err := s.validator.AccountStatus(input); err != nil {
+		return err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if account == nil || account.ErasedAt != nil {
+		return ErrAccountNotFound
+	}
+
+	updated := *account
+	updated.Status = input.Status
+	updated.StatusReason = nil
+	updated.StatusUntil = nil
+	updated.StatusSetBy = nil
+	if input.Status != AccountStatusActive {
+		updated.StatusReason = &input.Reason
+		updated.StatusUntil = input.Until
+	}
+
+	if principal, ok := PrincipalFromContext(ctx); ok && principal.UserID != 0 {
+		// admins could lock themselves out
+		if principal.UserID == id {
+			return ErrAccessDenied
+		}
+
+		updated.StatusSetBy = &principal.UserID
+	}
+
+	if err := s.accountRepo.SetStatus(ctx, &updated); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, AuditAccountStatus, AuditTargetAccount, id, auditStatus(account), auditStatus(&updated))
+
+	if input.Status == AccountStatusActive {
+		return nil
+	}
+
+	if err := s.sessionRepo.RevokeAll(ctx, id); err != nil {
+		return err
+	}
+
+	return s.payments.endActiveRents(ctx, id)
+}
+
+// RevokeSessions signs the account out on every device.
+func (s *AdminAccountService) RevokeSessions(ctx context.Context, id int64) error {
+	if err := authorize(ctx, PermAccountsWrite); err != nil {
+		return err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, id)
+	if err != nil {
 		return err
 	}
 
+	if account == nil {
+		return ErrAccountNotFound
+	}
+
+	if err := s.sessionRepo.RevokeAll(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, AuditAccountSessions, AuditTargetAccount, id, nil, nil)
+
 	return nil
 }
+
+// Unlock lifts a sign in lockout of the account's username.
+func (s *AdminAccountService) Unlock(ctx context.Context, id int64) error {
+	if err := authorize(ctx, PermAccountsWrite); err != nil {
+		return err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if account == nil {
+		return ErrAccountNotFound
+	}
+
+	if err := s.guard.Unlock(ctx, account.Username); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, AuditAccountUnlock, AuditTargetAccount, id, nil, nil)
+
+	return nil
+}
+
+func (s *AdminAccountService) ListSignInAttempts(ctx context.Context, username, ip string, count, start int) ([]SignInAttemptOutput, error) {
+	if err := authorize(ctx, PermAccountsRead); err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.guard.ListAttempts(ctx, username, ip, count, start)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]SignInAttemptOutput, 0, len(attempts))
+	for _, attempt := range attempts {
+		output = append(output, SignInAttemptOutput{
+			ID:        attempt.ID,
+			Username:  attempt.Username,
+			AccountID: attempt.AccountID,
+			IP:        attempt.IP,
+			UserAgent: attempt.UserAgent,
+			Reason:    attempt.Reason,
+			CreatedAt: attempt.CreatedAt,
+		})
+	}
+
+	return output, nil
+}
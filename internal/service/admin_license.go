@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/internal/repository"
+	"github.com/realdanielursul/simbir-go/pkg/storage"
+)
+
+type AdminLicenseService struct {
+	licenseRepo repository.License
+	store       storage.Store
+	validator   *Validator
+	auditor     *Auditor
+}
+
+func NewAdminLicenseService(licenseRepo repository.License, store storage.Store, validator *Validator, auditor *Auditor) *AdminLicenseService {
+	return &AdminLicenseService{
+		licenseRepo: licenseRepo,
+		store:       store,
+		validator:   validator,
+		auditor:     auditor,
+	}
+}
+
+// ListLicenses returns documents in the given status, or in any if it is
+// empty, oldest first.
+func (s *AdminLicenseService) ListLicenses(ctx context.Context, status string, count, start int) ([]LicenseOutput, error) {
+	if err := authorize(ctx, PermKYCReview); err != nil {
+		return nil, err
+	}
+
+	if err := s.validator.LicenseStatusFilter(status); err != nil {
+		return nil, err
+	}
+
+	documents, err := s.licenseRepo.List(ctx, status, count, start)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]LicenseOutput, 0, len(documents))
+	for _, document := range documents {
+		output = append(output, *licenseOutput(&document))
+	}
+
+	return output, nil
+}
+
+func (s *AdminLicenseService) GetLicenseFile(ctx context.Context, id int64) (*LicenseFile, error) {
+	if err := authorize(ctx, PermKYCReview); err != nil {
+		return nil, err
+	}
+
+	document, err := s.licenseRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if document == nil {
+		return nil, ErrLicenseNotFound
+	}
+
+	content, err := s.store.Open(ctx, document.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrLicenseNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &LicenseFile{
+		Name:        fmt.Sprintf("license-%d%s", document.ID, licenseExtensions[document.ContentType]),
+		ContentType: document.ContentType,
+		Size:        document.Size,
+		Content:     content,
+	}, nil
+}
+
+func (s *AdminLicenseService) ApproveLicense(ctx context.Context, id int64) error {
+	if err := authorize(ctx, PermKYCReview); err != nil {
+		return err
+	}
+
+	return s.review(ctx, id, entity.LicenseApproved, nil)
+}
+
+func (s *AdminLicenseService) RejectLicense(ctx context.Context, id int64, input *LicenseRejectInput) error {
+	if err := authorize(ctx, PermKYCReview); err != nil {
+		return err
+	}
+
+	if err := s.validator.LicenseReject(input); err != nil {
+		return err
+	}
+
+	return s.review(ctx, id, entity.LicenseRejected, &input.Reason)
+}
+
+func (s *AdminLicenseService) review(ctx context.Context, id int64, status string, reason *string) error {
+	document, err := s.licenseRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if document == nil {
+		return ErrLicenseNotFound
+	}
+
+	if document.Status != entity.LicensePending {
+		return ErrLicenseReviewed
+	}
+
+	reviewed := *document
+	reviewed.Status = status
+	reviewed.RejectReason = reason
+	if principal, ok := PrincipalFromContext(ctx); ok && principal.UserID != 0 {
+		reviewed.ReviewedBy = &principal.UserID
+	}
+
+	// another admin may have decided in the meantime
+	ok, err := s.licenseRepo.Review(ctx, &reviewed)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return ErrLicenseReviewed
+	}
+
+	action := AuditLicenseApprove
+	if status == entity.LicenseRejected {
+		action = AuditLicenseReject
+	}
+
+	s.auditor.Record(ctx, action, AuditTargetLicense, id, auditLicense(document), auditLicense(&reviewed))
+
+	return nil
+}
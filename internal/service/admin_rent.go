@@ -10,21 +10,33 @@ import (
 
 type AdminRentService struct {
 	accountRepo   repository.Account
-	paymentRepo   repository.Payment
 	transportRepo repository.Transport
 	rentRepo      repository.Rent
+	validator     *Validator
+	rider         *RiderChecker
+	auditor       *Auditor
 }
 
-func NewAdminRentService(accountRepo repository.Account, paymentRepo repository.Payment, transportRepo repository.Transport, rentRepo repository.Rent) *AdminRentService {
+func NewAdminRentService(accountRepo repository.Account, transportRepo repository.Transport, rentRepo repository.Rent, validator *Validator, rider *RiderChecker, auditor *Auditor) *AdminRentService {
 	return &AdminRentService{
 		accountRepo:   accountRepo,
-		paymentRepo:   paymentRepo,
 		transportRepo: transportRepo,
 		rentRepo:      rentRepo,
+		validator:     validator,
+		rider:         rider,
+		auditor:       auditor,
 	}
 }
 
 func (s *AdminRentService) StartRent(ctx context.Context, input *AdminRentInput) (int64, error) {
+	if err := authorize(ctx, PermRentsWrite); err != nil {
+		return -1, err
+	}
+
+	if err := s.validator.AdminRent(input); err != nil {
+		return -1, err
+	}
+
 	// check balance is good
 	//get acc
 	account, err := s.accountRepo.GetByID(ctx, input.UserID)
@@ -36,6 +48,10 @@ func (s *AdminRentService) StartRent(ctx context.Context, input *AdminRentInput)
 		return -1, ErrAccountNotFound
 	}
 
+	if err := checkStatus(account); err != nil {
+		return -1, err
+	}
+
 	//get transport
 	transport, err := s.transportRepo.GetByID(ctx, input.TransportID)
 	if err != nil {
@@ -46,35 +62,40 @@ func (s *AdminRentService) StartRent(ctx context.Context, input *AdminRentInput)
 		return -1, ErrTransportNotFound
 	}
 
-	var priceOfUnit int64
-	if input.PriceType == "Minutes" {
-		priceOfUnit = transport.MinutePrice
-	} else {
-		priceOfUnit = transport.DayPrice
+	if err := s.rider.Check(ctx, account, transport.TransportType); err != nil {
+		return -1, err
 	}
 
-	id, err := s.rentRepo.StartRent(ctx, &entity.Rent{
+	// admins may start a rent the balance does not cover, but never on a
+	// transport that is already taken
+	rent := &entity.Rent{
 		TransportID: input.TransportID,
 		UserID:      input.UserID,
 		TimeStart:   time.Now().UTC(),
 		TimeEnd:     nil,
-		PriceOfUnit: priceOfUnit,
 		PriceType:   input.PriceType,
 		FinalPrice:  nil,
-	})
-
-	if err := s.transportRepo.ChangeAvailability(ctx, input.TransportID, false); err != nil {
-		return -1, err
 	}
 
-	if err := s.paymentRepo.UpdateBalance(ctx, input.UserID, -priceOfUnit); err != nil {
-		return -1, ErrRentNotFound
+	id, err := s.rentRepo.StartRent(ctx, rent, true, s.auditor.Balance(ctx, AuditRentCharge, account.ID))
+	if err != nil {
+		return -1, rentStartError(err)
 	}
 
+	s.auditor.Record(ctx, AuditRentStart, AuditTargetRent, id, nil, auditRent(rent))
+
 	return id, nil
 }
 
 func (s *AdminRentService) EndRent(ctx context.Context, id int64, lat, long float64) error {
+	if err := authorize(ctx, PermRentsWrite); err != nil {
+		return err
+	}
+
+	if err := s.validator.Position(lat, long); err != nil {
+		return err
+	}
+
 	rent, err := s.rentRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -84,18 +105,22 @@ func (s *AdminRentService) EndRent(ctx context.Context, id int64, lat, long floa
 		return ErrRentNotFound
 	}
 
-	if err := s.rentRepo.EndRent(ctx, id, lat, long); err != nil {
-		return err
+	if rent.TimeEnd != nil {
+		return ErrRentAlreadyEnded
 	}
 
-	if err := s.transportRepo.ChangeAvailability(ctx, rent.TransportID, true); err != nil {
-		return err
+	if err := s.rentRepo.EndRent(ctx, id, lat, long, s.auditor.RentEnd(ctx, AuditRentEnd, rent)); err != nil {
+		return rentEndError(err)
 	}
 
 	return nil
 }
 
 func (s *AdminRentService) GetRent(ctx context.Context, id int64) (*RentOutput, error) {
+	if err := authorize(ctx, PermRentsRead); err != nil {
+		return nil, err
+	}
+
 	rent, err := s.rentRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -106,18 +131,23 @@ func (s *AdminRentService) GetRent(ctx context.Context, id int64) (*RentOutput,
 	}
 
 	return &RentOutput{
-		ID:          rent.ID,
-		TransportID: rent.TransportID,
-		UserID:      rent.UserID,
-		TimeStart:   rent.TimeStart,
-		TimeEnd:     rent.TimeEnd,
-		PriceOfUnit: rent.PriceOfUnit,
-		PriceType:   rent.PriceType,
-		FinalPrice:  rent.FinalPrice,
+		ID:           rent.ID,
+		TransportID:  rent.TransportID,
+		UserID:       rent.UserID,
+		TimeStart:    rent.TimeStart,
+		TimeEnd:      rent.TimeEnd,
+		PriceOfUnit:  rent.PriceOfUnit,
+		PriceType:    rent.PriceType,
+		FinalPrice:   rent.FinalPrice,
+		LastBilledAt: rent.LastBilledAt,
 	}, nil
 }
 
 func (s *AdminRentService) ListRentsByUser(ctx context.Context, userID int64) ([]RentOutput, error) {
+	if err := authorize(ctx, PermRentsRead); err != nil {
+		return nil, err
+	}
+
 	account, err := s.accountRepo.GetByID(ctx, userID)
 	if err != nil {
 		return nil, err
@@ -135,14 +165,15 @@ func (s *AdminRentService) ListRentsByUser(ctx context.Context, userID int64) ([
 	rentsOutput := make([]RentOutput, 0, len(rents))
 	for _, rent := range rents {
 		rentOutput := RentOutput{
-			ID:          rent.ID,
-			TransportID: rent.TransportID,
-			UserID:      rent.UserID,
-			TimeStart:   rent.TimeStart,
-			TimeEnd:     rent.TimeEnd,
-			PriceOfUnit: rent.PriceOfUnit,
-			PriceType:   rent.PriceType,
-			FinalPrice:  rent.FinalPrice,
+			ID:           rent.ID,
+			TransportID:  rent.TransportID,
+			UserID:       rent.UserID,
+			TimeStart:    rent.TimeStart,
+			TimeEnd:      rent.TimeEnd,
+			PriceOfUnit:  rent.PriceOfUnit,
+			PriceType:    rent.PriceType,
+			FinalPrice:   rent.FinalPrice,
+			LastBilledAt: rent.LastBilledAt,
 		}
 
 		rentsOutput = append(rentsOutput, rentOutput)
@@ -152,6 +183,10 @@ func (s *AdminRentService) ListRentsByUser(ctx context.Context, userID int64) ([
 }
 
 func (s *AdminRentService) ListRentsByTransport(ctx context.Context, transportID int64) ([]RentOutput, error) {
+	if err := authorize(ctx, PermRentsRead); err != nil {
+		return nil, err
+	}
+
 	transport, err := s.transportRepo.GetByID(ctx, transportID)
 	if err != nil {
 		return nil, err
@@ -169,14 +204,15 @@ func (s *AdminRentService) ListRentsByTransport(ctx context.Context, transportID
 	rentsOutput := make([]RentOutput, 0, len(rents))
 	for _, rent := range rents {
 		rentOutput := RentOutput{
-			ID:          rent.ID,
-			TransportID: rent.TransportID,
-			UserID:      rent.UserID,
-			TimeStart:   rent.TimeStart,
-			TimeEnd:     rent.TimeEnd,
-			PriceOfUnit: rent.PriceOfUnit,
-			PriceType:   rent.PriceType,
-			FinalPrice:  rent.FinalPrice,
+			ID:           rent.ID,
+			TransportID:  rent.TransportID,
+			UserID:       rent.UserID,
+			TimeStart:    rent.TimeStart,
+			TimeEnd:      rent.TimeEnd,
+			PriceOfUnit:  rent.PriceOfUnit,
+			PriceType:    rent.PriceType,
+			FinalPrice:   rent.FinalPrice,
+			LastBilledAt: rent.LastBilledAt,
 		}
 
 		rentsOutput = append(rentsOutput, rentOutput)
@@ -186,6 +222,10 @@ func (s *AdminRentService) ListRentsByTransport(ctx context.Context, transportID
 }
 
 func (s *AdminRentService) DeleteRent(ctx context.Context, id int64) error {
+	if err := authorize(ctx, PermRentsWrite); err != nil {
+		return err
+	}
+
 	rent, err := s.rentRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -199,5 +239,7 @@ func (s *AdminRentService) DeleteRent(ctx context.Context, id int64) error {
 		return err
 	}
 
+	s.auditor.Record(ctx, AuditRentDelete, AuditTargetRent, id, auditRent(rent), nil)
+
 	return nil
 }
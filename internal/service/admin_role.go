@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+
+	"github.com/realdanielursul/simbir-go/internal/repository"
+)
+
+type AdminRoleService struct {
+	accountRepo repository.Account
+	roleRepo    repository.Role
+	auditor     *Auditor
+}
+
+func NewAdminRoleService(accountRepo repository.Account, roleRepo repository.Role, auditor *Auditor) *AdminRoleService {
+	return &AdminRoleService{
+		accountRepo: accountRepo,
+		roleRepo:    roleRepo,
+		auditor:     auditor,
+	}
+}
+
+func (s *AdminRoleService) ListRoles(ctx context.Context) ([]RoleOutput, error) {
+	if err := authorize(ctx, PermAccountsRead); err != nil {
+		return nil, err
+	}
+
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rolesOutput := make([]RoleOutput, 0, len(roles))
+	for _, role := range roles {
+		rolesOutput = append(rolesOutput, RoleOutput{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+		})
+	}
+
+	return rolesOutput, nil
+}
+
+func (s *AdminRoleService) ListAccountRoles(ctx context.Context, accountID int64) ([]string, error) {
+	if err := authorize(ctx, PermAccountsRead); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	return s.roleRepo.ListByAccount(ctx, accountID)
+}
+
+func (s *AdminRoleService) AssignRole(ctx context.Context, accountID int64, role string) error {
+	if err := authorize(ctx, PermRolesManage); err != nil {
+		return err
+	}
+
+	if err := s.checkAccountAndRole(ctx, accountID, role); err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.Assign(ctx, accountID, role); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, AuditRoleAssign, AuditTargetAccount, accountID, nil, &roleAudit{Role: role})
+
+	return nil
+}
+
+func (s *AdminRoleService) UnassignRole(ctx context.Context, accountID int64, role string) error {
+	if err := authorize(ctx, PermRolesManage); err != nil {
+		return err
+	}
+
+	if err := s.checkAccountAndRole(ctx, accountID, role); err != nil {
+		return err
+	}
+
+	if err := s.roleRepo.Unassign(ctx, accountID, role); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, AuditRoleUnassign, AuditTargetAccount, accountID, &roleAudit{Role: role}, nil)
+
+	return nil
+}
+
+func (s *AdminRoleService) checkAccountAndRole(ctx context.Context, accountID int64, role string) error {
+	account, err := s.accountRepo.GetByID(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	if account == nil {
+		return ErrAccountNotFound
+	}
+
+	existing, err := s.roleRepo.GetByName(ctx, role)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		return ErrRoleNotFound
+	}
+
+	return nil
+}
@@ -10,15 +10,27 @@ import (
 
 type AdminTransportService struct {
 	transportRepo repository.Transport
+	validator     *Validator
+	auditor       *Auditor
 }
 
-func NewAdminTransportService(transportRepo repository.Transport) *AdminTransportService {
+func NewAdminTransportService(transportRepo repository.Transport, validator *Validator, auditor *Auditor) *AdminTransportService {
 	return &AdminTransportService{
 		transportRepo: transportRepo,
+		validator:     validator,
+		auditor:       auditor,
 	}
 }
 
 func (s *AdminTransportService) CreateTransport(ctx context.Context, input *AdminTransportInput) (int64, error) {
+	if err := authorize(ctx, PermTransportsWrite); err != nil {
+		return -1, err
+	}
+
+	if err := s.validator.AdminTransport(input); err != nil {
+		return -1, err
+	}
+
 	// check identifier uniqueness
 	transport, err := s.transportRepo.GetByIdentifier(ctx, input.Identifier)
 	if err != nil {
@@ -29,7 +41,7 @@ func (s *AdminTransportService) CreateTransport(ctx context.Context, input *Admi
 		return -1, ErrIdentifierAlreadyExists
 	}
 
-	id, err := s.transportRepo.Create(ctx, &entity.Transport{
+	created := &entity.Transport{
 		OwnerID:       input.OwnerID,
 		CanBeRented:   input.CanBeRented,
 		TransportType: input.TransportType,
@@ -41,26 +53,118 @@ func (s *AdminTransportService) CreateTransport(ctx context.Context, input *Admi
 		Longitude:     input.Longitude,
 		MinutePrice:   int64(input.MinutePrice * 100),
 		DayPrice:      int64(input.DayPrice * 100),
-	})
+	}
+
+	id, err := s.transportRepo.Create(ctx, created)
 	if err != nil {
 		return -1, err
 	}
 
+	s.auditor.Record(ctx, AuditTransportCreate, AuditTargetTransport, id, nil, auditTransport(created))
+
 	return id, nil
 }
 
+func (s *AdminTransportService) GetTransport(ctx context.Context, id int64) (*TransportOutput, error) {
+	if err := authorize(ctx, PermTransportsRead); err != nil {
+		return nil, err
+	}
+
+	transport, err := s.transportRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if transport == nil {
+		return nil, ErrTransportNotFound
+	}
+
+	return &TransportOutput{
+		ID:            transport.ID,
+		OwnerID:       transport.OwnerID,
+		CanBeRented:   transport.CanBeRented,
+		TransportType: transport.TransportType,
+		Model:         transport.Model,
+		Color:         transport.Color,
+		Identifier:    transport.Identifier,
+		Description:   transport.Description,
+		Latitude:      transport.Latitude,
+		Longitude:     transport.Longitude,
+		MinutePrice:   float64(transport.MinutePrice) / 100,
+		DayPrice:      float64(transport.DayPrice) / 100,
+		CreatedAt:     transport.CreatedAt,
+		UpdatedAt:     transport.UpdatedAt,
+	}, nil
+}
+
+func (s *AdminTransportService) ListTransport(ctx context.Context, transportType string, count, start int) ([]TransportOutput, error) {
+	if err := authorize(ctx, PermTransportsRead); err != nil {
+		return nil, err
+	}
+
+	if err := s.validator.TransportTypeFilter(transportType); err != nil {
+		return nil, err
+	}
+
+	transports, err := s.transportRepo.List(ctx, transportType, count, start)
+	if err != nil {
+		return nil, err
+	}
+
+	transportsOutput := make([]TransportOutput, 0, len(transports))
+	for _, transport := range transports {
+		transportOutput := TransportOutput{
+			ID:            transport.ID,
+			OwnerID:       transport.OwnerID,
+			CanBeRented:   transport.CanBeRented,
+			TransportType: transport.TransportType,
+			Model:         transport.Model,
+			Color:         transport.Color,
+			Identifier:    transport.Identifier,
+			Description:   transport.Description,
+			Latitude:      transport.Latitude,
+			Longitude:     transport.Longitude,
+			MinutePrice:   float64(transport.MinutePrice) / 100,
+			DayPrice:      float64(transport.DayPrice) / 100,
+			CreatedAt:     transport.CreatedAt,
+			UpdatedAt:     transport.UpdatedAt,
+		}
+
+		transportsOutput = append(transportsOutput, transportOutput)
+	}
+
+	return transportsOutput, nil
+}
+
 func (s *AdminTransportService) UpdateTransport(ctx context.Context, id int64, input *AdminTransportInput) error {
+	if err := authorize(ctx, PermTransportsWrite); err != nil {
+		return err
+	}
+
+	if err := s.validator.AdminTransport(input); err != nil {
+		return err
+	}
+
+	transport, err := s.transportRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if transport == nil {
+		return ErrTransportNotFound
+	}
+
 	// check identifier uniqueness
-	transport, err := s.transportRepo.GetByIdentifier(ctx, input.Identifier)
+	existing, err := s.transportRepo.GetByIdentifier(ctx, input.Identifier)
 	if err != nil {
 		return err
 	}
 
-	if transport != nil {
+	if existing != nil && existing.ID != id {
 		return ErrIdentifierAlreadyExists
 	}
 
-	err = s.transportRepo.Update(ctx, &entity.Transport{
+	updated := &entity.Transport{
 		ID:            id,
 		OwnerID:       input.OwnerID,
 		CanBeRented:   input.CanBeRented,
@@ -74,15 +178,24 @@ func (s *AdminTransportService) UpdateTransport(ctx context.Context, id int64, i
 		MinutePrice:   int64(input.MinutePrice * 100),
 		DayPrice:      int64(input.DayPrice * 100),
 		UpdatedAt:     time.Now().UTC(),
-	})
-	if err != nil {
+	}
+
+	if err := s.transportRepo.Update(ctx, updated); err != nil {
 		return err
 	}
 
+	s.auditor.Record(ctx, AuditTransportUpdate, AuditTargetTransport, id, auditTransport(transport), auditTransport(updated))
+
 	return nil
 }
 
+// DeleteTransport withdraws the transport for good, the rents taken on it
+// are kept.
 func (s *AdminTransportService) DeleteTransport(ctx context.Context, id int64) error {
+	if err := authorize(ctx, PermTransportsWrite); err != nil {
+		return err
+	}
+
 	transport, err := s.transportRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -92,9 +205,16 @@ func (s *AdminTransportService) DeleteTransport(ctx context.Context, id int64) e
 		return ErrTransportNotFound
 	}
 
-	if err := s.transportRepo.Delete(ctx, id); err != nil {
+	deleted, err := s.transportRepo.Delete(ctx, id)
+	if err != nil {
 		return err
 	}
 
+	if !deleted {
+		return ErrTransportRented
+	}
+
+	s.auditor.Record(ctx, AuditTransportDelete, AuditTargetTransport, id, auditTransport(transport), nil)
+
 	return nil
 }
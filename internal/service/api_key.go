@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Scopes an API key can be limited to. Each one unlocks a fixed set of
+// routes, everything else stays JWT only.
+const (
+	ScopeAccountRead       = "account:read"
+	ScopeTransportWrite    = "transport:write"
+	ScopeTransportPosition = "transport:position"
+	ScopeRentRead          = "rent:read"
+	ScopeRentWrite         = "rent:write"
+)
+
+var allScopes = []string{
+	ScopeAccountRead,
+	ScopeTransportWrite,
+	ScopeTransportPosition,
+	ScopeRentRead,
+	ScopeRentWrite,
+}
+
+const (
+	apiKeyPrefix = "sgk_"
+	// characters of the key kept in plain text so users can tell keys apart
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+)
+
+type APIKeyService struct {
+	apiKeyRepo  repository.APIKey
+	accountRepo repository.Account
+	validator   *Validator
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKey, accountRepo repository.Account, validator *Validator) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo:  apiKeyRepo,
+		accountRepo: accountRepo,
+		validator:   validator,
+	}
+}
+
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID int64, input *APIKeyInput) (*APIKeyOutput, error) {
+	if err := s.validator.APIKey(input); err != nil {
+		return nil, err
+	}
+
+	secret, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	key := apiKeyPrefix + secret
+	apiKey := &entity.APIKey{
+		AccountID: userID,
+		Name:      input.Name,
+		Prefix:    key[:apiKeyDisplayLength],
+		KeyHash:   hashToken(key),
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	apiKey.ID, err = s.apiKeyRepo.Create(ctx, apiKey)
+	if err != nil {
+		return nil, err
+	}
+
+	output := apiKeyOutput(apiKey)
+	output.Key = key
+
+	return output, nil
+}
+
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, userID int64) ([]APIKeyOutput, error) {
+	keys, err := s.apiKeyRepo.ListByAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keysOutput := make([]APIKeyOutput, 0, len(keys))
+	for _, key := range keys {
+		keysOutput = append(keysOutput, *apiKeyOutput(&key))
+	}
+
+	return keysOutput, nil
+}
+
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID, id int64) error {
+	key, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// keys of other accounts are reported as missing
+	if key == nil || key.AccountID != userID || key.RevokedAt != nil {
+		return ErrAPIKeyNotFound
+	}
+
+	return s.apiKeyRepo.Revoke(ctx, id)
+}
+
+// Authenticate resolves a key sent by a machine client. The principal it
+// returns is limited to the scopes of the key and has no admin permissions.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*Principal, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	apiKey, err := s.apiKeyRepo.GetByHash(ctx, hashToken(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey == nil || apiKey.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if apiKey.ExpiresAt != nil && !apiKey.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, apiKey.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if err := checkStatus(account); err != nil {
+		return nil, err
+	}
+
+	if err := s.apiKeyRepo.Touch(ctx, apiKey.ID); err != nil {
+		logrus.Warnf("failed to touch api key %d: %v", apiKey.ID, err)
+	}
+
+	return &Principal{
+		UserID:      apiKey.AccountID,
+		Permissions: []string{},
+		Scopes:      apiKey.Scopes,
+	}, nil
+}
+
+func apiKeyOutput(key *entity.APIKey) *APIKeyOutput {
+	return &APIKeyOutput{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func isScope(scope string) bool {
+	for _, known := range allScopes {
+		if scope == known {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,362 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/internal/repository"
+	"github.com/sirupsen/logrus"
+)
+
+// Audited actions.
+const (
+	AuditAccountCreate   = "account.create"
+	AuditAccountUpdate   = "account.update"
+	AuditAccountBalance  = "account.balance"
+	AuditAccountErase    = "account.erase"
+	AuditAccountSessions = "account.sessions.revoke"
+	AuditAccountUnlock   = "account.unlock"
+	AuditAccountStatus   = "account.status"
+	AuditRoleAssign      = "account.role.assign"
+	AuditRoleUnassign    = "account.role.unassign"
+	AuditTransportCreate = "transport.create"
+	AuditTransportUpdate = "transport.update"
+	AuditTransportDelete = "transport.delete"
+	AuditRentStart       = "rent.start"
+	AuditRentEnd         = "rent.end"
+	AuditRentDelete      = "rent.delete"
+	AuditRentCharge      = "rent.charge"
+	AuditRentForceEnd    = "rent.force_end"
+	AuditBalanceDeposit  = "balance.deposit"
+	AuditLicenseApprove  = "license.approve"
+	AuditLicenseReject   = "license.reject"
+)
+
+const (
+	AuditTargetAccount   = "account"
+	AuditTargetTransport = "transport"
+	AuditTargetRent      = "rent"
+	AuditTargetLicense   = "license"
+)
+
+const (
+	actorUser   = "user"
+	actorAPIKey = "api_key"
+	actorSystem = "system"
+)
+
+// Snapshots of audited records. Secrets such as password hashes are left out.
+type (
+	accountAudit struct {
+		Username string  `json:"username"`
+		IsAdmin  bool    `json:"isAdmin"`
+		Balance  float64 `json:"balance"`
+		Email    *string `json:"email,omitempty"`
+	}
+
+	transportAudit struct {
+		OwnerID       int64   `json:"ownerId"`
+		CanBeRented   bool    `json:"canBeRented"`
+		TransportType string  `json:"transportType"`
+		Model         string  `json:"model"`
+		Color         string  `json:"color"`
+		Identifier    string  `json:"identifier"`
+		Description   *string `json:"description,omitempty"`
+		Latitude      float64 `json:"latitude"`
+		Longitude     float64 `json:"longitude"`
+		MinutePrice   float64 `json:"minutePrice"`
+		DayPrice      float64 `json:"dayPrice"`
+	}
+
+	rentAudit struct {
+		TransportID int64      `json:"transportId"`
+		UserID      int64      `json:"userId"`
+		TimeStart   time.Time  `json:"timeStart"`
+		TimeEnd     *time.Time `json:"timeEnd,omitempty"`
+		PriceOfUnit float64    `json:"priceOfUnit"`
+		PriceType   string     `json:"priceType"`
+		FinalPrice  *float64   `json:"finalPrice,omitempty"`
+	}
+
+	statusAudit struct {
+		Status string     `json:"status"`
+		Reason *string    `json:"reason,omitempty"`
+		Until  *time.Time `json:"until,omitempty"`
+	}
+
+	balanceAudit struct {
+		Balance float64 `json:"balance"`
+	}
+
+	roleAudit struct {
+		Role string `json:"role"`
+	}
+
+	licenseAudit struct {
+		AccountID    int64   `json:"accountId"`
+		Status       string  `json:"status"`
+		RejectReason *string `json:"rejectReason,omitempty"`
+	}
+)
+
+func auditAccount(account *entity.Account) *accountAudit {
+	return &accountAudit{
+		Username: account.Username,
+		IsAdmin:  account.IsAdmin,
+		Balance:  float64(account.Balance) / 100,
+		Email:    account.Email,
+	}
+}
+
+func auditStatus(account *entity.Account) *statusAudit {
+	return &statusAudit{
+		Status: accountStatus(account),
+		Reason: account.StatusReason,
+		Until:  account.StatusUntil,
+	}
+}
+
+func auditBalance(balance int64) *balanceAudit {
+	return &balanceAudit{Balance: float64(balance) / 100}
+}
+
+func auditLicense(document *entity.LicenseDocument) *licenseAudit {
+	return &licenseAudit{
+		AccountID:    document.AccountID,
+		Status:       document.Status,
+		RejectReason: document.RejectReason,
+	}
+}
+
+func auditTransport(transport *entity.Transport) *transportAudit {
+	return &transportAudit{
+		OwnerID:       transport.OwnerID,
+		CanBeRented:   transport.CanBeRented,
+		TransportType: transport.TransportType,
+		Model:         transport.Model,
+		Color:         transport.Color,
+		Identifier:    transport.Identifier,
+		Description:   transport.Description,
+		Latitude:      transport.Latitude,
+		Longitude:     transport.Longitude,
+		MinutePrice:   float64(transport.MinutePrice) / 100,
+		DayPrice:      float64(transport.DayPrice) / 100,
+	}
+}
+
+func auditRent(rent *entity.Rent) *rentAudit {
+	audit := &rentAudit{
+		TransportID: rent.TransportID,
+		UserID:      rent.UserID,
+		TimeStart:   rent.TimeStart,
+		TimeEnd:     rent.TimeEnd,
+		PriceOfUnit: float64(rent.PriceOfUnit) / 100,
+		PriceType:   rent.PriceType,
+	}
+
+	if rent.FinalPrice != nil {
+		finalPrice := float64(*rent.FinalPrice) / 100
+		audit.FinalPrice = &finalPrice
+	}
+
+	return audit
+}
+
+// Auditor appends to the audit log on behalf of the services that change
+// accounts, transport, rents and balances.
+type Auditor struct {
+	auditRepo repository.AuditLog
+}
+
+func NewAuditor(auditRepo repository.AuditLog) *Auditor {
+	return &Auditor{auditRepo: auditRepo}
+}
+
+// Record logs an action of the caller in ctx, callers without a principal
+// are the system. Before and after are snapshots of the target, nil if it
+// did not exist, and only their differing fields are kept. The change
+// already happened, so a failure is logged rather than returned; changes of
+// money are audited with Balance and RentEnd instead.
+func (a *Auditor) Record(ctx context.Context, action, targetType string, targetID int64, before, after any) {
+	entry, err := newAuditEntry(ctx, action, targetType, targetID, before, after)
+	if err == nil {
+		err = a.auditRepo.Create(ctx, entry)
+	}
+
+	if err != nil {
+		logrus.Errorf("failed to write audit entry %s of %s %d: %v", action, targetType, targetID, err)
+	}
+}
+
+// Balance returns the audit of a balance change of the account by the caller
+// in ctx. Money never moves unaudited, so the repository stores the entry in
+// the transaction moving it, with the balances it read and wrote.
+func (a *Auditor) Balance(ctx context.Context, action string, accountID int64) repository.BalanceAudit {
+	return func(before, after int64) (*entity.AuditEntry, error) {
+		return newAuditEntry(ctx, action, AuditTargetAccount, accountID, auditBalance(before), auditBalance(after))
+	}
+}
+
+// RentEnd returns the audit of ending the rent by the caller in ctx, stored
+// by the repository in the transaction ending it with the final price it
+// computed.
+func (a *Auditor) RentEnd(ctx context.Context, action string, rent *entity.Rent) repository.RentAudit {
+	return func(ended *entity.Rent) (*entity.AuditEntry, error) {
+		return newAuditEntry(ctx, action, AuditTargetRent, rent.ID, auditRent(rent), auditRent(ended))
+	}
+}
+
+func newAuditEntry(ctx context.Context, action, targetType string, targetID int64, before, after any) (*entity.AuditEntry, error) {
+	beforeJSON, afterJSON, err := auditDiff(before, after)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &entity.AuditEntry{
+		ActorType:  actorSystem,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   &targetID,
+		Before:     beforeJSON,
+		After:      afterJSON,
+	}
+
+	if principal, ok := PrincipalFromContext(ctx); ok && principal.UserID != 0 {
+		entry.ActorID = &principal.UserID
+		entry.ActorType = actorUser
+		if principal.IsAPIKey() {
+			entry.ActorType = actorAPIKey
+		}
+
+		entry.IP = principal.IP
+	}
+
+	return entry, nil
+}
+
+// auditDiff drops the fields before and after agree on.
+func auditDiff(before, after any) (types.NullJSONText, types.NullJSONText, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return types.NullJSONText{}, types.NullJSONText{}, err
+	}
+
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return types.NullJSONText{}, types.NullJSONText{}, err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for field, value := range beforeFields {
+			if other, ok := afterFields[field]; ok && bytes.Equal(value, other) {
+				delete(beforeFields, field)
+				delete(afterFields, field)
+			}
+		}
+	}
+
+	beforeJSON, err := auditJSON(beforeFields)
+	if err != nil {
+		return types.NullJSONText{}, types.NullJSONText{}, err
+	}
+
+	afterJSON, err := auditJSON(afterFields)
+	if err != nil {
+		return types.NullJSONText{}, types.NullJSONText{}, err
+	}
+
+	return beforeJSON, afterJSON, nil
+}
+
+func auditFields(snapshot any) (map[string]json.RawMessage, error) {
+	if snapshot == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+func auditJSON(fields map[string]json.RawMessage) (types.NullJSONText, error) {
+	if fields == nil {
+		return types.NullJSONText{}, nil
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return types.NullJSONText{}, err
+	}
+
+	return types.NullJSONText{JSONText: data, Valid: true}, nil
+}
+
+type AdminAuditService struct {
+	auditRepo repository.AuditLog
+}
+
+func NewAdminAuditService(auditRepo repository.AuditLog) *AdminAuditService {
+	return &AdminAuditService{auditRepo: auditRepo}
+}
+
+func (s *AdminAuditService) ListAuditLog(ctx context.Context, input *AuditLogQuery, count, start int) ([]AuditEntryOutput, error) {
+	if err := authorize(ctx, PermAuditRead); err != nil {
+		return nil, err
+	}
+
+	var fields fieldErrors
+	fields.check(input.From == nil || input.To == nil || input.From.Before(*input.To), "to", "must be after from", ErrInvalidValue)
+	if err := fields.err(); err != nil {
+		return nil, err
+	}
+
+	entries, err := s.auditRepo.List(ctx, &repository.AuditFilter{
+		ActorID:    input.ActorID,
+		Action:     input.Action,
+		TargetType: input.TargetType,
+		TargetID:   input.TargetID,
+		From:       input.From,
+		To:         input.To,
+	}, count, start)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]AuditEntryOutput, 0, len(entries))
+	for _, entry := range entries {
+		entryOutput := AuditEntryOutput{
+			ID:         entry.ID,
+			ActorID:    entry.ActorID,
+			ActorType:  entry.ActorType,
+			Action:     entry.Action,
+			TargetType: entry.TargetType,
+			TargetID:   entry.TargetID,
+			IP:         entry.IP,
+			CreatedAt:  entry.CreatedAt,
+		}
+
+		if entry.Before.Valid {
+			entryOutput.Before = json.RawMessage(entry.Before.JSONText)
+		}
+
+		if entry.After.Valid {
+			entryOutput.After = json.RawMessage(entry.After.JSONText)
+		}
+
+		output = append(output, entryOutput)
+	}
+
+	return output, nil
+}
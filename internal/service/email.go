@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/internal/repository"
+	"github.com/realdanielursul/simbir-go/pkg/hasher"
+	"github.com/realdanielursul/simbir-go/pkg/mailer"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	verificationMailSubject = "Confirm your email address"
+	verificationMailBody    = `Hello %s,
+
+confirm your email address by opening the link below:
+
+%s
+
+The link expires in %s. If you did not add this address to a Simbir.GO account, ignore this mail.
+`
+
+	resetMailSubject = "Reset your password"
+	resetMailBody    = `Hello %s,
+
+someone asked to reset the password of your Simbir.GO account. Open the link below to choose a new one:
+
+%s
+
+The link expires in %s. If it was not you, ignore this mail, your password stays unchanged.
+`
+)
+
+type EmailService struct {
+	accountRepo     repository.Account
+	tokenRepo       repository.Token
+	sessionRepo     repository.Session
+	emailTokenRepo  repository.EmailToken
+	passwordHasher  hasher.PasswordHasher
+	validator       *Validator
+	guard           *SignInGuard
+	sender          mailer.Sender
+	linkBaseURL     string
+	verificationTTL time.Duration
+	resetTTL        time.Duration
+}
+
+func NewEmailService(accountRepo repository.Account, tokenRepo repository.Token, sessionRepo repository.Session, emailTokenRepo repository.EmailToken, passwordHasher hasher.PasswordHasher, validator *Validator, guard *SignInGuard, sender mailer.Sender, linkBaseURL string, verificationTTL, resetTTL time.Duration) *EmailService {
+	return &EmailService{
+		accountRepo:     accountRepo,
+		tokenRepo:       tokenRepo,
+		sessionRepo:     sessionRepo,
+		emailTokenRepo:  emailTokenRepo,
+		passwordHasher:  passwordHasher,
+		validator:       validator,
+		guard:           guard,
+		sender:          sender,
+		linkBaseURL:     strings.TrimRight(linkBaseURL, "/"),
+		verificationTTL: verificationTTL,
+		resetTTL:        resetTTL,
+	}
+}
+
+// SetEmail changes the email of the account and mails a verification link
+// to it.
+func (s *EmailService) SetEmail(ctx context.Context, userID int64, email string) error {
+	if err := s.validator.Email(email); err != nil {
+		return err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if account == nil {
+		return ErrAccountNotFound
+	}
+
+	// check email uniqueness
+	existing, err := s.accountRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil && existing.ID != userID {
+		return ErrEmailAlreadyExists
+	}
+
+	if err := s.accountRepo.SetEmail(ctx, userID, email); err != nil {
+		return err
+	}
+
+	account.Email = &email
+	return s.sendVerification(ctx, account)
+}
+
+func (s *EmailService) ResendVerification(ctx context.Context, userID int64) error {
+	account, err := s.accountRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if account == nil {
+		return ErrAccountNotFound
+	}
+
+	if account.Email == nil {
+		return ErrEmailNotSet
+	}
+
+	if account.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	return s.sendVerification(ctx, account)
+}
+
+func (s *EmailService) VerifyEmail(ctx context.Context, token string) error {
+	emailToken, err := s.useToken(ctx, token, entity.EmailTokenVerifyEmail)
+	if err != nil {
+		return err
+	}
+
+	verified, err := s.accountRepo.VerifyEmail(ctx, emailToken.AccountID, emailToken.Email)
+	if err != nil {
+		return err
+	}
+
+	// the email was changed after the link was sent
+	if !verified {
+		return ErrInvalidEmailToken
+	}
+
+	return nil
+}
+
+// ForgotPassword mails a reset link if a verified email matches. It succeeds
+// either way so it cannot be used to find out which emails have accounts.
+func (s *EmailService) ForgotPassword(ctx context.Context, email string) error {
+	if err := s.validator.Email(email); err != nil {
+		return err
+	}
+
+	account, err := s.accountRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	if account == nil || account.EmailVerifiedAt == nil {
+		return nil
+	}
+
+	token, err := s.createToken(ctx, account, entity.EmailTokenResetPassword, s.resetTTL)
+	if err != nil {
+		return err
+	}
+
+	err = s.sender.Send(ctx, &mailer.Message{
+		To:      *account.Email,
+		Subject: resetMailSubject,
+		Body:    fmt.Sprintf(resetMailBody, account.Username, s.link("/reset-password", token), s.resetTTL),
+	})
+	if err != nil {
+		logrus.Errorf("failed to send password reset mail to account %d: %v", account.ID, err)
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password and signs the account out everywhere.
+func (s *EmailService) ResetPassword(ctx context.Context, input *ResetPasswordInput) error {
+	if err := s.validator.Password(input.Password); err != nil {
+		return err
+	}
+
+	emailToken, err := s.useToken(ctx, input.Token, entity.EmailTokenResetPassword)
+	if err != nil {
+		return err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, emailToken.AccountID)
+	if err != nil {
+		return err
+	}
+
+	// the link went to an address the account no longer uses
+	if account == nil || account.Email == nil || *account.Email != emailToken.Email || account.EmailVerifiedAt == nil {
+		return ErrInvalidEmailToken
+	}
+
+	if err := s.accountRepo.UpdatePasswordHash(ctx, account.ID, s.passwordHasher.Hash(input.Password)); err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.InvalidateAll(ctx, account.ID); err != nil {
+		return err
+	}
+
+	if err := s.sessionRepo.RevokeAll(ctx, account.ID); err != nil {
+		return err
+	}
+
+	// the owner proved control of the email, so a lockout no longer applies
+	return s.guard.Unlock(ctx, account.Username)
+}
+
+func (s *EmailService) sendVerification(ctx context.Context, account *entity.Account) error {
+	token, err := s.createToken(ctx, account, entity.EmailTokenVerifyEmail, s.verificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.sender.Send(ctx, &mailer.Message{
+		To:      *account.Email,
+		Subject: verificationMailSubject,
+		Body:    fmt.Sprintf(verificationMailBody, account.Username, s.link("/verify-email", token), s.verificationTTL),
+	})
+}
+
+func (s *EmailService) createToken(ctx context.Context, account *entity.Account, purpose string, ttl time.Duration) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.emailTokenRepo.Create(ctx, &entity.EmailToken{
+		AccountID: account.ID,
+		Purpose:   purpose,
+		Email:     *account.Email,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// useToken consumes a valid token of the purpose.
+func (s *EmailService) useToken(ctx context.Context, token, purpose string) (*entity.EmailToken, error) {
+	emailToken, err := s.emailTokenRepo.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if emailToken == nil || emailToken.Purpose != purpose || emailToken.UsedAt != nil || time.Now().After(emailToken.ExpiresAt) {
+		return nil, ErrInvalidEmailToken
+	}
+
+	used, err := s.emailTokenRepo.Use(ctx, emailToken.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !used {
+		return nil, ErrInvalidEmailToken
+	}
+
+	return emailToken, nil
+}
+
+func (s *EmailService) link(path, token string) string {
+	return s.linkBaseURL + path + "?token=" + url.QueryEscape(token)
+}
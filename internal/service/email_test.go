@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/pkg/hasher"
+	"github.com/realdanielursul/simbir-go/pkg/mailer"
+)
+
+var mailTokenPattern = regexp.MustCompile(`\?token=([A-Za-z0-9_-]+)`)
+
+type fakeSender struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+}
+
+func (s *fakeSender) Send(_ context.Context, msg *mailer.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, *msg)
+	return nil
+}
+
+// lastToken returns the token of the link in the last mail sent.
+func (s *fakeSender) lastToken(t *testing.T) string {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.messages) == 0 {
+		t.Fatal("no mail was sent")
+	}
+
+	match := mailTokenPattern.FindStringSubmatch(s.messages[len(s.messages)-1].Body)
+	if match == nil {
+		t.Fatal("the mail has no link")
+	}
+
+	return match[1]
+}
+
+type emailTest struct {
+	accountID      int64
+	accounts       *fakeAccounts
+	sender         *fakeSender
+	passwordHasher hasher.PasswordHasher
+	service        *EmailService
+}
+
+func newEmailTest(t *testing.T) *emailTest {
+	t.Helper()
+
+	e := &emailTest{
+		accounts:       newFakeAccounts(),
+		sender:         &fakeSender{},
+		passwordHasher: hasher.NewSHA1Hasher("salt"),
+	}
+
+	email := "jane@example.com"
+	verifiedAt := time.Now()
+	e.accountID, _ = e.accounts.Create(context.Background(), &entity.Account{
+		Username:        "jane",
+		PasswordHash:    e.passwordHasher.Hash("old password"),
+		Email:           &email,
+		EmailVerifiedAt: &verifiedAt,
+	})
+
+	validator := NewValidator(testValidationRules)
+	guard := NewSignInGuard(newFakeSignInFailures(), &fakeSignInAttempts{}, testLockout)
+	e.service = NewEmailService(e.accounts, fakeTokens{}, &fakeSessions{}, newFakeEmailTokens(), e.passwordHasher, validator, guard, e.sender,
+		"http://localhost:3000", time.Hour, time.Hour)
+
+	return e
+}
+
+func (e *emailTest) passwordIs(password string) bool {
+	account, _ := e.accounts.GetByID(context.Background(), e.accountID)
+	ok, _ := e.passwordHasher.Verify(password, account.PasswordHash)
+	return ok
+}
+
+func TestResetPassword(t *testing.T) {
+	e := newEmailTest(t)
+
+	if err := e.service.ForgotPassword(context.Background(), "jane@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	token := e.sender.lastToken(t)
+	if err := e.service.ResetPassword(context.Background(), &ResetPasswordInput{Token: token, Password: "new password"}); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+
+	if !e.passwordIs("new password") {
+		t.Error("the password was not changed")
+	}
+
+	if err := e.service.ResetPassword(context.Background(), &ResetPasswordInput{Token: token, Password: "another password"}); !errors.Is(err, ErrInvalidEmailToken) {
+		t.Errorf("reusing the link: got %v, want %v", err, ErrInvalidEmailToken)
+	}
+}
+
+func TestResetPasswordAfterEmailChange(t *testing.T) {
+	e := newEmailTest(t)
+
+	if err := e.service.ForgotPassword(context.Background(), "jane@example.com"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the owner moves to a new address before the link is used
+	e.accounts.modify(e.accountID, func(account *entity.Account) {
+		email := "jane@new.example.com"
+		account.Email = &email
+	})
+
+	err := e.service.ResetPassword(context.Background(), &ResetPasswordInput{Token: e.sender.lastToken(t), Password: "new password"})
+	if !errors.Is(err, ErrInvalidEmailToken) {
+		t.Fatalf("got %v, want %v", err, ErrInvalidEmailToken)
+	}
+
+	if !e.passwordIs("old password") {
+		t.Error("a link mailed to the old address changed the password")
+	}
+}
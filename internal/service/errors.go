@@ -5,14 +5,61 @@ import "errors"
 var (
 	ErrUsernameAlreadyExists   = errors.New("username already exists")
 	ErrIdentifierAlreadyExists = errors.New("identifier already exists")
+	ErrEmailAlreadyExists      = errors.New("email already exists")
 	ErrInvalidUsername         = errors.New("invalid username")
 	ErrInvalidPassword         = errors.New("invalid password")
+	ErrInvalidEmail            = errors.New("invalid email")
+	ErrValidation              = errors.New("validation failed")
+	ErrRequiredField           = errors.New("required field is empty")
+	ErrInvalidValue            = errors.New("invalid value")
+	ErrInvalidTransportType    = errors.New("invalid transport type")
+	ErrInvalidCoordinates      = errors.New("invalid coordinates")
+	ErrInvalidPrice            = errors.New("invalid price")
+	ErrInvalidAmount           = errors.New("invalid amount")
+	ErrInvalidRadius           = errors.New("invalid radius")
+	ErrInvalidCredentials      = errors.New("invalid username or password")
 	ErrCannotSignToken         = errors.New("cannot sign token")
 	ErrCannotParseToken        = errors.New("cannot parse token")
+	ErrInvalidToken            = errors.New("invalid token")
+	ErrInvalidRefreshToken     = errors.New("invalid refresh token")
+	ErrInvalidEmailToken       = errors.New("invalid or expired email token")
+	ErrEmailNotSet             = errors.New("email not set")
+	ErrEmailAlreadyVerified    = errors.New("email already verified")
+	ErrOIDCNotConfigured       = errors.New("external sign in is not configured")
+	ErrOIDCProviderUnavailable = errors.New("external sign in provider unavailable")
+	ErrInvalidOIDCState        = errors.New("invalid or expired login state")
+	ErrOIDCLoginFailed         = errors.New("external sign in failed")
+	ErrTooManyAttempts         = errors.New("too many failed sign in attempts")
+	ErrInvalidChallenge        = errors.New("invalid or expired sign in challenge")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication required")
+	ErrTwoFactorEnabled        = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication not enabled")
+	ErrInvalidAPIKey           = errors.New("invalid api key")
+	ErrInvalidScope            = errors.New("invalid scope")
 	ErrAccountNotFound         = errors.New("account not found")
+	ErrSessionNotFound         = errors.New("session not found")
+	ErrAPIKeyNotFound          = errors.New("api key not found")
+	ErrRoleNotFound            = errors.New("role not found")
 	ErrTransportNotFound       = errors.New("transport not found")
 	ErrAccessDenied            = errors.New("access denied")
 	ErrNotEnoughMoney          = errors.New("not enough money")
 	ErrInvalidRentType         = errors.New("invalid rent type")
 	ErrRentNotFound            = errors.New("rent not found")
+	ErrRentAlreadyEnded        = errors.New("rent already ended")
+	ErrTransportUnavailable    = errors.New("transport cannot be rented")
+	ErrActiveRent              = errors.New("account has a rent in progress")
+	ErrTransportRented         = errors.New("transport has a rent in progress")
+	ErrAccountSuspended        = errors.New("account is suspended")
+	ErrAccountBanned           = errors.New("account is banned")
+	ErrInvalidAccountStatus    = errors.New("invalid account status")
+	ErrBirthDateLocked         = errors.New("birth date cannot change once a license is approved")
+	ErrLicenseNotFound         = errors.New("license not found")
+	ErrLicensePending          = errors.New("a license is already waiting for review")
+	ErrLicenseReviewed         = errors.New("license already reviewed")
+	ErrLicenseTooLarge         = errors.New("license file too large")
+	ErrInvalidLicenseFile      = errors.New("license must be a JPEG, PNG or PDF file")
+	ErrLicenseRequired         = errors.New("an approved driver license is required")
+	ErrBirthDateRequired       = errors.New("birth date required in the profile")
+	ErrAgeRequirement          = errors.New("rider is too young for this transport")
 )
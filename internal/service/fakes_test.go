@@ -0,0 +1,534 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/internal/repository"
+)
+
+// In-memory repositories for the service tests. Each embeds its interface, so
+// a method a test does not expect to be called panics on the nil interface.
+
+type fakeAccounts struct {
+	repository.Account
+
+	mu       sync.Mutex
+	nextID   int64
+	accounts map[int64]*entity.Account
+}
+
+func newFakeAccounts() *fakeAccounts {
+	return &fakeAccounts{accounts: make(map[int64]*entity.Account)}
+}
+
+func (r *fakeAccounts) Create(_ context.Context, account *entity.Account) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.create(account), nil
+}
+
+func (r *fakeAccounts) create(account *entity.Account) int64 {
+	r.nextID++
+	stored := *account
+	stored.ID = r.nextID
+	stored.Status = AccountStatusActive
+	stored.CreatedAt = time.Now()
+	stored.UpdatedAt = stored.CreatedAt
+	r.accounts[stored.ID] = &stored
+
+	return stored.ID
+}
+
+func (r *fakeAccounts) GetByID(_ context.Context, id int64) (*entity.Account, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if account, ok := r.accounts[id]; ok {
+		copied := *account
+		return &copied, nil
+	}
+
+	return nil, nil
+}
+
+func (r *fakeAccounts) GetByUsername(_ context.Context, username string) (*entity.Account, error) {
+	return r.find(func(account *entity.Account) bool {
+		return account.Username == username
+	}), nil
+}
+
+func (r *fakeAccounts) GetByEmail(_ context.Context, email string) (*entity.Account, error) {
+	return r.find(func(account *entity.Account) bool {
+		return account.Email != nil && *account.Email == email
+	}), nil
+}
+
+func (r *fakeAccounts) find(match func(*entity.Account) bool) *entity.Account {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, account := range r.accounts {
+		if match(account) {
+			copied := *account
+			return &copied
+		}
+	}
+
+	return nil
+}
+
+func (r *fakeAccounts) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.accounts)
+}
+
+type fakeIdentities struct {
+	repository.ExternalIdentity
+
+	accounts *fakeAccounts
+
+	mu         sync.Mutex
+	nextID     int64
+	identities []entity.ExternalIdentity
+}
+
+func (r *fakeIdentities) CreateWithAccount(_ context.Context, account *entity.Account, identity *entity.ExternalIdentity) (int64, error) {
+	r.accounts.mu.Lock()
+	accountID := r.accounts.create(account)
+	r.accounts.mu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	stored := *identity
+	stored.ID = r.nextID
+	stored.AccountID = accountID
+	stored.CreatedAt = time.Now()
+	stored.LastLoginAt = stored.CreatedAt
+	r.identities = append(r.identities, stored)
+
+	return accountID, nil
+}
+
+func (r *fakeIdentities) GetBySubject(_ context.Context, issuer, subject string) (*entity.ExternalIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *fakeIdentities) Touch(_ context.Context, id int64, email *string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.identities {
+		if r.identities[i].ID == id {
+			r.identities[i].Email = email
+			r.identities[i].LastLoginAt = time.Now()
+		}
+	}
+
+	return nil
+}
+
+type fakeLoginStates struct {
+	mu     sync.Mutex
+	nextID int64
+	states map[string]*entity.OIDCLoginState
+}
+
+func newFakeLoginStates() *fakeLoginStates {
+	return &fakeLoginStates{states: make(map[string]*entity.OIDCLoginState)}
+}
+
+func (r *fakeLoginStates) Create(_ context.Context, state *entity.OIDCLoginState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	stored := *state
+	stored.ID = r.nextID
+	stored.CreatedAt = time.Now()
+	r.states[stored.StateHash] = &stored
+
+	return nil
+}
+
+func (r *fakeLoginStates) GetByHash(_ context.Context, stateHash string) (*entity.OIDCLoginState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if state, ok := r.states[stateHash]; ok {
+		copied := *state
+		return &copied, nil
+	}
+
+	return nil, nil
+}
+
+func (r *fakeLoginStates) Use(_ context.Context, id int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, state := range r.states {
+		if state.ID == id && state.UsedAt == nil && time.Now().Before(state.ExpiresAt) {
+			now := time.Now()
+			state.UsedAt = &now
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// modify changes the stored state of a login, as if it had been saved
+// differently.
+func (r *fakeLoginStates) modify(state string, change func(*entity.OIDCLoginState)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change(r.states[hashToken(state)])
+}
+
+type fakeSessions struct {
+	repository.Session
+
+	mu     sync.Mutex
+	nextID int64
+}
+
+func (r *fakeSessions) Create(_ context.Context, _ *entity.Session) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	return r.nextID, nil
+}
+
+type fakeTokens struct {
+	repository.Token
+}
+
+func (fakeTokens) Create(context.Context, *entity.Token) error {
+	return nil
+}
+
+type fakeRefreshTokens struct {
+	repository.RefreshToken
+}
+
+func (fakeRefreshTokens) Create(context.Context, *entity.RefreshToken) error {
+	return nil
+}
+
+type fakeTwoFactor struct {
+	repository.TwoFactor
+
+	mu      sync.Mutex
+	factors map[int64]*entity.TwoFactor
+}
+
+func (r *fakeTwoFactor) Get(_ context.Context, accountID int64) (*entity.TwoFactor, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if factor, ok := r.factors[accountID]; ok {
+		copied := *factor
+		return &copied, nil
+	}
+
+	return nil, nil
+}
+
+type fakeRoles struct {
+	repository.Role
+
+	permissions map[int64][]string
+}
+
+func (r *fakeRoles) ListPermissions(_ context.Context, accountID int64) ([]string, error) {
+	return append([]string(nil), r.permissions[accountID]...), nil
+}
+
+type fakeSignInFailures struct {
+	mu       sync.Mutex
+	failures map[string]*entity.SignInFailure
+}
+
+func newFakeSignInFailures() *fakeSignInFailures {
+	return &fakeSignInFailures{failures: make(map[string]*entity.SignInFailure)}
+}
+
+func (r *fakeSignInFailures) Get(_ context.Context, key string) (*entity.SignInFailure, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if failure, ok := r.failures[key]; ok {
+		copied := *failure
+		return &copied, nil
+	}
+
+	return nil, nil
+}
+
+func (r *fakeSignInFailures) Attempt(_ context.Context, key string, resetAfter time.Duration, lockFor func(int) time.Duration) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	failure, ok := r.failures[key]
+	if ok && failure.LockedUntil != nil && time.Now().Before(*failure.LockedUntil) {
+		return false, nil
+	}
+
+	if !ok || time.Since(failure.LastFailureAt) > resetAfter {
+		failure = &entity.SignInFailure{Key: key}
+		r.failures[key] = failure
+	}
+
+	failure.Failures++
+	failure.LastFailureAt = time.Now()
+
+	if delay := lockFor(failure.Failures); delay > 0 {
+		lockedUntil := time.Now().Add(delay)
+		failure.LockedUntil = &lockedUntil
+	}
+
+	return true, nil
+}
+
+func (r *fakeSignInFailures) Refund(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if failure, ok := r.failures[key]; ok {
+		failure.Failures = max(failure.Failures-1, 0)
+		failure.LockedUntil = nil
+	}
+
+	return nil
+}
+
+func (r *fakeSignInFailures) Reset(_ context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.failures, key)
+	return nil
+}
+
+type fakeSignInAttempts struct {
+	repository.SignInAttempt
+
+	mu       sync.Mutex
+	attempts []entity.SignInAttempt
+}
+
+func (r *fakeSignInAttempts) Create(_ context.Context, attempt *entity.SignInAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.attempts = append(r.attempts, *attempt)
+	return nil
+}
+
+func (r *fakeSignInAttempts) reasons() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reasons := make([]string, 0, len(r.attempts))
+	for _, attempt := range r.attempts {
+		reasons = append(reasons, attempt.Reason)
+	}
+
+	return reasons
+}
+
+func (r *fakeAccounts) UpdatePasswordHash(_ context.Context, id int64, passwordHash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if account, ok := r.accounts[id]; ok {
+		account.PasswordHash = passwordHash
+	}
+
+	return nil
+}
+
+// modify changes a stored account, as if it had been updated elsewhere.
+func (r *fakeAccounts) modify(id int64, change func(*entity.Account)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change(r.accounts[id])
+}
+
+func (fakeTokens) InvalidateAll(context.Context, int64) error {
+	return nil
+}
+
+func (*fakeSessions) RevokeAll(context.Context, int64) error {
+	return nil
+}
+
+type fakeEmailTokens struct {
+	mu     sync.Mutex
+	nextID int64
+	tokens map[string]*entity.EmailToken
+}
+
+func newFakeEmailTokens() *fakeEmailTokens {
+	return &fakeEmailTokens{tokens: make(map[string]*entity.EmailToken)}
+}
+
+func (r *fakeEmailTokens) Create(_ context.Context, token *entity.EmailToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	stored := *token
+	stored.ID = r.nextID
+	stored.CreatedAt = time.Now()
+	r.tokens[stored.TokenHash] = &stored
+
+	return nil
+}
+
+func (r *fakeEmailTokens) GetByHash(_ context.Context, tokenHash string) (*entity.EmailToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if token, ok := r.tokens[tokenHash]; ok {
+		copied := *token
+		return &copied, nil
+	}
+
+	return nil, nil
+}
+
+func (r *fakeEmailTokens) Use(_ context.Context, id int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, token := range r.tokens {
+		if token.ID == id && token.UsedAt == nil {
+			now := time.Now()
+			token.UsedAt = &now
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *fakeTwoFactor) UseStep(_ context.Context, accountID, step int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	factor, ok := r.factors[accountID]
+	if !ok || step <= factor.LastUsedStep {
+		return false, nil
+	}
+
+	factor.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeTwoFactor) UseRecoveryCode(context.Context, int64, string) (bool, error) {
+	return false, nil
+}
+
+func (r *fakeTwoFactor) ReplaceRecoveryCodes(context.Context, int64, []string) error {
+	return nil
+}
+
+func (r *fakeTwoFactor) Delete(_ context.Context, accountID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.factors, accountID)
+	return nil
+}
+
+type fakeChallenges struct {
+	mu         sync.Mutex
+	nextID     int64
+	challenges map[string]*entity.SignInChallenge
+}
+
+func newFakeChallenges() *fakeChallenges {
+	return &fakeChallenges{challenges: make(map[string]*entity.SignInChallenge)}
+}
+
+func (r *fakeChallenges) Create(_ context.Context, challenge *entity.SignInChallenge) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	stored := *challenge
+	stored.ID = r.nextID
+	stored.CreatedAt = time.Now()
+	r.challenges[stored.TokenHash] = &stored
+
+	return nil
+}
+
+func (r *fakeChallenges) GetByHash(_ context.Context, tokenHash string) (*entity.SignInChallenge, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if challenge, ok := r.challenges[tokenHash]; ok {
+		copied := *challenge
+		return &copied, nil
+	}
+
+	return nil, nil
+}
+
+func (r *fakeChallenges) Attempt(_ context.Context, id int64, maxAttempts int) (bool, error) {
+	return r.update(id, func(challenge *entity.SignInChallenge) bool {
+		if challenge.Attempts >= maxAttempts {
+			return false
+		}
+
+		challenge.Attempts++
+		return true
+	}), nil
+}
+
+func (r *fakeChallenges) Use(_ context.Context, id int64) (bool, error) {
+	return r.update(id, func(challenge *entity.SignInChallenge) bool {
+		if challenge.UsedAt != nil {
+			return false
+		}
+
+		now := time.Now()
+		challenge.UsedAt = &now
+		return true
+	}), nil
+}
+
+func (r *fakeChallenges) update(id int64, change func(*entity.SignInChallenge) bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, challenge := range r.challenges {
+		if challenge.ID == id {
+			return change(challenge)
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/internal/repository"
+)
+
+// Reasons a sign in was refused, kept in the audit trail.
+const (
+	SignInUnknownUsername = "unknown_username"
+	SignInInvalidPassword = "invalid_password"
+	SignInInvalidCode     = "invalid_two_factor_code"
+	SignInLocked          = "locked"
+)
+
+type LockoutPolicy struct {
+	// failures in a row before the username or IP address gets locked
+	UsernameAttempts int
+	IPAttempts       int
+	// the first lockout lasts BaseDelay and doubles with every further
+	// failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// failures are forgotten after this long without a new one
+	ResetAfter time.Duration
+}
+
+// LockedError refuses a sign in until RetryAfter passed. It matches
+// ErrTooManyAttempts with errors.Is.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%s, retry in %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// SignInGuard throttles password and second factor code guessing per username
+// and per IP address and records refused sign ins.
+type SignInGuard struct {
+	failureRepo repository.SignInFailure
+	attemptRepo repository.SignInAttempt
+	policy      LockoutPolicy
+}
+
+func NewSignInGuard(failureRepo repository.SignInFailure, attemptRepo repository.SignInAttempt, policy LockoutPolicy) *SignInGuard {
+	return &SignInGuard{
+		failureRepo: failureRepo,
+		attemptRepo: attemptRepo,
+		policy:      policy,
+	}
+}
+
+// Check refuses the sign in while the username or the IP address is locked.
+// Otherwise the attempt counts as a failure against both until it passes, so
+// guesses made at the same time are limited like guesses made in a row, and
+// the guess reaching the limit locks out the next.
+func (g *SignInGuard) Check(ctx context.Context, username string, client *ClientInfo) error {
+	ok, err := g.failureRepo.Attempt(ctx, usernameKey(username), g.policy.ResetAfter, g.lockFor(g.policy.UsernameAttempts))
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		ok, err = g.failureRepo.Attempt(ctx, ipKey(client.IP), g.policy.ResetAfter, g.lockFor(g.policy.IPAttempts))
+		if err != nil {
+			return err
+		}
+
+		// not an attempt on the username after all
+		if !ok {
+			if err := g.failureRepo.Refund(ctx, usernameKey(username)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ok {
+		return nil
+	}
+
+	if err := g.audit(ctx, username, nil, client, SignInLocked); err != nil {
+		return err
+	}
+
+	return &LockedError{RetryAfter: g.retryAfter(ctx, username, client)}
+}
+
+// Fail records a failed sign in, it was counted when checked.
+func (g *SignInGuard) Fail(ctx context.Context, username string, accountID *int64, client *ClientInfo, reason string) error {
+	return g.audit(ctx, username, accountID, client, reason)
+}
+
+// Pass takes back the attempt of a step that succeeded without completing
+// the sign in, such as the password of an account with a second factor.
+// Earlier failures are kept.
+func (g *SignInGuard) Pass(ctx context.Context, username string, client *ClientInfo) error {
+	if err := g.failureRepo.Refund(ctx, usernameKey(username)); err != nil {
+		return err
+	}
+
+	return g.failureRepo.Refund(ctx, ipKey(client.IP))
+}
+
+// Succeed forgets failures of the username. Of the IP address only the
+// attempt is taken back, an attacker could otherwise reset its failures with
+// an account of their own.
+func (g *SignInGuard) Succeed(ctx context.Context, username string, client *ClientInfo) error {
+	if err := g.failureRepo.Reset(ctx, usernameKey(username)); err != nil {
+		return err
+	}
+
+	return g.failureRepo.Refund(ctx, ipKey(client.IP))
+}
+
+func (g *SignInGuard) Unlock(ctx context.Context, username string) error {
+	return g.failureRepo.Reset(ctx, usernameKey(username))
+}
+
+func (g *SignInGuard) ListAttempts(ctx context.Context, username, ip string, count, start int) ([]entity.SignInAttempt, error) {
+	return g.attemptRepo.List(ctx, username, ip, count, start)
+}
+
+// lockFor locks a key once it counts the attempts in a row.
+func (g *SignInGuard) lockFor(attempts int) func(int) time.Duration {
+	return func(failures int) time.Duration {
+		if failures < attempts {
+			return 0
+		}
+
+		return g.lockoutDelay(failures - attempts)
+	}
+}
+
+// retryAfter returns how long the longer lock of the username and the IP
+// address lasts.
+func (g *SignInGuard) retryAfter(ctx context.Context, username string, client *ClientInfo) time.Duration {
+	var retryAfter time.Duration
+	for _, key := range []string{usernameKey(username), ipKey(client.IP)} {
+		failure, err := g.failureRepo.Get(ctx, key)
+		if err != nil || failure == nil || failure.LockedUntil == nil {
+			continue
+		}
+
+		if left := time.Until(*failure.LockedUntil); left > retryAfter {
+			retryAfter = left
+		}
+	}
+
+	return retryAfter
+}
+
+func (g *SignInGuard) lockoutDelay(extra int) time.Duration {
+	delay := g.policy.BaseDelay
+	for i := 0; i < extra && delay < g.policy.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, g.policy.MaxDelay)
+}
+
+func (g *SignInGuard) audit(ctx context.Context, username string, accountID *int64, client *ClientInfo, reason string) error {
+	return g.attemptRepo.Create(ctx, &entity.SignInAttempt{
+		Username:  username,
+		AccountID: accountID,
+		IP:        client.IP,
+		UserAgent: client.UserAgent,
+		Reason:    reason,
+	})
+}
+
+func usernameKey(username string) string {
+	return "username:" + username
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/pkg/hasher"
+	"github.com/realdanielursul/simbir-go/pkg/jwtkeys"
+	"github.com/realdanielursul/simbir-go/pkg/totp"
+)
+
+const (
+	testUsername = "jane"
+	testPassword = "correct horse"
+)
+
+var testLockout = LockoutPolicy{
+	UsernameAttempts: 3,
+	IPAttempts:       100,
+	BaseDelay:        time.Minute,
+	MaxDelay:         time.Hour,
+	ResetAfter:       time.Hour,
+}
+
+type lockoutTest struct {
+	accountID int64
+	failures  *fakeSignInFailures
+	attempts  *fakeSignInAttempts
+	twoFactor *fakeTwoFactor
+	accounts  *AccountService
+	privacy   *PrivacyService
+	client    *ClientInfo
+}
+
+func newLockoutTest(t *testing.T) *lockoutTest {
+	t.Helper()
+
+	passwordHasher := hasher.NewSHA1Hasher("salt")
+	accountRepo := newFakeAccounts()
+	accountID, _ := accountRepo.Create(context.Background(), &entity.Account{
+		Username:     testUsername,
+		PasswordHash: passwordHasher.Hash(testPassword),
+	})
+
+	keys, err := jwtkeys.NewKeySet(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keys.Generate(jwtkeys.AlgorithmEdDSA); err != nil {
+		t.Fatal(err)
+	}
+
+	failures := newFakeSignInFailures()
+	attempts := &fakeSignInAttempts{}
+	twoFactor := &fakeTwoFactor{factors: make(map[int64]*entity.TwoFactor)}
+	guard := NewSignInGuard(failures, attempts, testLockout)
+	accounts := NewAccountService(accountRepo, fakeTokens{}, fakeRefreshTokens{}, &fakeSessions{}, twoFactor, newFakeChallenges(),
+		passwordHasher, nil, guard, keys, time.Minute, time.Hour, "Simbir.GO", time.Minute)
+
+	return &lockoutTest{
+		accountID: accountID,
+		failures:  failures,
+		attempts:  attempts,
+		twoFactor: twoFactor,
+		accounts:  accounts,
+		privacy:   NewPrivacyService(accountRepo, nil, nil, nil, nil, accounts, nil, nil, nil, nil, guard, nil),
+		client:    &ClientInfo{UserAgent: "test", IP: "192.0.2.1"},
+	}
+}
+
+// enableTwoFactor turns on a second factor for the account and returns its
+// secret.
+func (l *lockoutTest) enableTwoFactor(t *testing.T) string {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	enabledAt := time.Now()
+	l.twoFactor.factors[l.accountID] = &entity.TwoFactor{AccountID: l.accountID, Secret: secret, EnabledAt: &enabledAt}
+
+	return secret
+}
+
+// signIn signs in with the password and, if challenged, answers with code.
+func (l *lockoutTest) signIn(password, code string) (*SignInOutput, error) {
+	output, err := l.accounts.SignIn(context.Background(), &AccountInput{Username: testUsername, Password: password}, l.client)
+	if err != nil || output.Challenge == nil {
+		return output, err
+	}
+
+	tokens, err := l.accounts.VerifySignIn(context.Background(), &TwoFactorSignInInput{
+		ChallengeToken: output.Challenge.ChallengeToken,
+		Code:           code,
+	}, l.client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignInOutput{Token: tokens.Token, RefreshToken: tokens.RefreshToken, ExpiresIn: tokens.ExpiresIn}, nil
+}
+
+func (l *lockoutTest) usernameFailures() int {
+	failure, _ := l.failures.Get(context.Background(), usernameKey(testUsername))
+	if failure == nil {
+		return 0
+	}
+
+	return failure.Failures
+}
+
+func (l *lockoutTest) erase(password string) error {
+	return l.privacy.EraseAccount(context.Background(), l.accountID, &EraseAccountInput{Password: password}, l.client)
+}
+
+func TestEraseAccountPasswordIsGuarded(t *testing.T) {
+	l := newLockoutTest(t)
+
+	for i := 0; i < testLockout.UsernameAttempts; i++ {
+		if err := l.erase("guess"); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("guess %d: got %v, want %v", i+1, err, ErrInvalidCredentials)
+		}
+	}
+
+	// locked out even with the right password, for erasure and sign in alike
+	var locked *LockedError
+	if err := l.erase(testPassword); !errors.As(err, &locked) {
+		t.Fatalf("erase after %d guesses: got %v, want a lockout", testLockout.UsernameAttempts, err)
+	}
+
+	_, err := l.accounts.SignIn(context.Background(), &AccountInput{Username: testUsername, Password: testPassword}, l.client)
+	if !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("sign in after guessing through erasure: got %v, want %v", err, ErrTooManyAttempts)
+	}
+
+	want := []string{SignInInvalidPassword, SignInInvalidPassword, SignInInvalidPassword, SignInLocked, SignInLocked}
+	if reasons := l.attempts.reasons(); !slices.Equal(reasons, want) {
+		t.Errorf("recorded attempts %v, want %v", reasons, want)
+	}
+}
+
+func TestConfirmedPasswordResetsFailures(t *testing.T) {
+	l := newLockoutTest(t)
+
+	_, err := l.accounts.SignIn(context.Background(), &AccountInput{Username: testUsername, Password: "guess"}, l.client)
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("sign in: got %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	account, _ := l.accounts.accountRepo.GetByID(context.Background(), l.accountID)
+	for i := 0; i < testLockout.UsernameAttempts; i++ {
+		if err := l.accounts.confirmPassword(context.Background(), account, testPassword, l.client); err != nil {
+			t.Fatalf("confirm %d: %v", i+1, err)
+		}
+
+		if err := l.accounts.confirmPassword(context.Background(), account, "guess", l.client); !errors.Is(err, ErrInvalidCredentials) {
+			t.Fatalf("guess %d: got %v, want %v", i+1, err, ErrInvalidCredentials)
+		}
+	}
+}
+
+func TestTwoFactorCodesAreGuarded(t *testing.T) {
+	l := newLockoutTest(t)
+	secret := l.enableTwoFactor(t)
+
+	output, err := l.accounts.SignIn(context.Background(), &AccountInput{Username: testUsername, Password: testPassword}, l.client)
+	if err != nil || output.Challenge == nil {
+		t.Fatalf("sign in: got %+v, %v, want a challenge", output, err)
+	}
+
+	// fewer guesses than one challenge allows are enough to lock
+	for i := 0; i < testLockout.UsernameAttempts; i++ {
+		_, err := l.accounts.VerifySignIn(context.Background(), &TwoFactorSignInInput{
+			ChallengeToken: output.Challenge.ChallengeToken,
+			Code:           "000000",
+		}, l.client)
+		if !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("guess %d: got %v, want %v", i+1, err, ErrInvalidTwoFactorCode)
+		}
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a new challenge does not start over, nor does the right code get in
+	if _, err := l.signIn(testPassword, code); !errors.Is(err, ErrTooManyAttempts) {
+		t.Fatalf("sign in after %d wrong codes: got %v, want %v", testLockout.UsernameAttempts, err, ErrTooManyAttempts)
+	}
+
+	want := []string{SignInInvalidCode, SignInInvalidCode, SignInInvalidCode, SignInLocked}
+	if reasons := l.attempts.reasons(); !slices.Equal(reasons, want) {
+		t.Errorf("recorded attempts %v, want %v", reasons, want)
+	}
+}
+
+func TestPasswordDoesNotResetFailuresBeforeSecondFactor(t *testing.T) {
+	l := newLockoutTest(t)
+	secret := l.enableTwoFactor(t)
+
+	if _, err := l.signIn("guess", ""); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("sign in: got %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	// the right password alone keeps the failure, wrong codes add to it
+	if _, err := l.signIn(testPassword, "000000"); !errors.Is(err, ErrInvalidTwoFactorCode) {
+		t.Fatalf("sign in with a wrong code: got %v, want %v", err, ErrInvalidTwoFactorCode)
+	}
+
+	if failures := l.usernameFailures(); failures != 2 {
+		t.Fatalf("%d failures after a wrong password and a wrong code, want 2", failures)
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.signIn(testPassword, code); err != nil {
+		t.Fatalf("sign in: %v", err)
+	}
+
+	if failures := l.usernameFailures(); failures != 0 {
+		t.Errorf("%d failures after signing in with both factors, want 0", failures)
+	}
+}
+
+func TestManagingTwoFactorIsGuarded(t *testing.T) {
+	l := newLockoutTest(t)
+	secret := l.enableTwoFactor(t)
+	ctx := context.Background()
+
+	for i := 0; i < testLockout.UsernameAttempts; i++ {
+		var err error
+		if i%2 == 0 {
+			_, err = l.accounts.RegenerateRecoveryCodes(ctx, l.accountID, "000000", l.client)
+		} else {
+			err = l.accounts.DisableTwoFactor(ctx, l.accountID, "000000", l.client)
+		}
+
+		if !errors.Is(err, ErrInvalidTwoFactorCode) {
+			t.Fatalf("guess %d: got %v, want %v", i+1, err, ErrInvalidTwoFactorCode)
+		}
+	}
+
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := l.accounts.RegenerateRecoveryCodes(ctx, l.accountID, code, l.client); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("regenerate after %d wrong codes: got %v, want %v", testLockout.UsernameAttempts, err, ErrTooManyAttempts)
+	}
+
+	if err := l.accounts.DisableTwoFactor(ctx, l.accountID, code, l.client); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("disable after %d wrong codes: got %v, want %v", testLockout.UsernameAttempts, err, ErrTooManyAttempts)
+	}
+}
+
+func TestParallelGuessesAreLimited(t *testing.T) {
+	l := newLockoutTest(t)
+
+	errs := make([]error, 4*testLockout.UsernameAttempts)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = l.signIn("guess", "")
+		}()
+	}
+	wg.Wait()
+
+	guessed := 0
+	for i, err := range errs {
+		switch {
+		case errors.Is(err, ErrInvalidCredentials):
+			guessed++
+		case !errors.Is(err, ErrTooManyAttempts):
+			t.Errorf("guess %d: got %v, want %v or %v", i+1, err, ErrInvalidCredentials, ErrTooManyAttempts)
+		}
+	}
+
+	if guessed != testLockout.UsernameAttempts {
+		t.Errorf("%d of %d parallel guesses checked, want %d", guessed, len(errs), testLockout.UsernameAttempts)
+	}
+
+	if _, err := l.signIn(testPassword, ""); !errors.Is(err, ErrTooManyAttempts) {
+		t.Errorf("sign in after parallel guesses: got %v, want %v", err, ErrTooManyAttempts)
+	}
+}
+
+func TestSignInsDoNotCountAgainstIP(t *testing.T) {
+	l := newLockoutTest(t)
+
+	for i := 0; i <= testLockout.IPAttempts; i++ {
+		if _, err := l.signIn(testPassword, ""); err != nil {
+			t.Fatalf("sign in %d: %v", i+1, err)
+		}
+	}
+
+	failure, err := l.failures.Get(context.Background(), ipKey(l.client.IP))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if failure != nil && (failure.Failures != 0 || failure.LockedUntil != nil) {
+		t.Errorf("IP address has %d failures, locked until %v, after only signing in", failure.Failures, failure.LockedUntil)
+	}
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/internal/repository"
+	"github.com/realdanielursul/simbir-go/pkg/hasher"
+	"github.com/realdanielursul/simbir-go/pkg/oidc"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// usernames taken over from a provider are tried with a random suffix
+	// this many times before giving up
+	usernameAttempts = 5
+	usernameFallback = "user"
+)
+
+type OIDCService struct {
+	accountRepo    repository.Account
+	identityRepo   repository.ExternalIdentity
+	stateRepo      repository.OIDCLoginState
+	accounts       *AccountService
+	passwordHasher hasher.PasswordHasher
+	validator      *Validator
+	provider       *oidc.Provider
+	stateTTL       time.Duration
+}
+
+// NewOIDCService signs users in through the provider, a nil provider
+// disables external sign in.
+func NewOIDCService(accountRepo repository.Account, identityRepo repository.ExternalIdentity, stateRepo repository.OIDCLoginState, accounts *AccountService, passwordHasher hasher.PasswordHasher, validator *Validator, provider *oidc.Provider, stateTTL time.Duration) *OIDCService {
+	return &OIDCService{
+		accountRepo:    accountRepo,
+		identityRepo:   identityRepo,
+		stateRepo:      stateRepo,
+		accounts:       accounts,
+		passwordHasher: passwordHasher,
+		validator:      validator,
+		provider:       provider,
+		stateTTL:       stateTTL,
+	}
+}
+
+// StartLogin returns the provider URL to send the user to.
+func (s *OIDCService) StartLogin(ctx context.Context) (*OIDCLoginOutput, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	state, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := s.provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+	if err != nil {
+		logrus.Errorf("failed to start oidc login: %v", err)
+		return nil, ErrOIDCProviderUnavailable
+	}
+
+	err = s.stateRepo.Create(ctx, &entity.OIDCLoginState{
+		StateHash:    hashToken(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(s.stateTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &OIDCLoginOutput{AuthorizationURL: authURL}, nil
+}
+
+// CompleteLogin signs in the account linked to the external identity the
+// provider redirected back with, creating both on the first login.
+func (s *OIDCService) CompleteLogin(ctx context.Context, input *OIDCCallbackInput, client *ClientInfo) (*SignInOutput, error) {
+	if s.provider == nil {
+		return nil, ErrOIDCNotConfigured
+	}
+
+	state, err := s.stateRepo.GetByHash(ctx, hashToken(input.State))
+	if err != nil {
+		return nil, err
+	}
+
+	if state == nil || state.UsedAt != nil || time.Now().After(state.ExpiresAt) {
+		return nil, ErrInvalidOIDCState
+	}
+
+	used, err := s.stateRepo.Use(ctx, state.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !used {
+		return nil, ErrInvalidOIDCState
+	}
+
+	// the user denied access or the provider refused the request
+	if input.Error != "" {
+		return nil, ErrOIDCLoginFailed
+	}
+
+	token, err := s.provider.Exchange(ctx, input.Code, state.CodeVerifier)
+	if err != nil {
+		logrus.Warnf("oidc login failed: %v", err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	claims, err := s.provider.Verify(ctx, token.IDToken, state.Nonce)
+	if err != nil {
+		logrus.Warnf("oidc login failed: %v", err)
+		return nil, ErrOIDCLoginFailed
+	}
+
+	account, err := s.linkedAccount(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.accounts.signInAccount(ctx, account, client)
+}
+
+func (s *OIDCService) ListIdentities(ctx context.Context, userID int64) ([]ExternalIdentityOutput, error) {
+	identities, err := s.identityRepo.ListByAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]ExternalIdentityOutput, 0, len(identities))
+	for _, identity := range identities {
+		output = append(output, ExternalIdentityOutput{
+			ID:          identity.ID,
+			Issuer:      identity.Issuer,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			CreatedAt:   identity.CreatedAt,
+			LastLoginAt: identity.LastLoginAt,
+		})
+	}
+
+	return output, nil
+}
+
+func (s *OIDCService) linkedAccount(ctx context.Context, claims *oidc.Claims) (*entity.Account, error) {
+	var email *string
+	if claims.Email != "" {
+		email = &claims.Email
+	}
+
+	identity, err := s.identityRepo.GetBySubject(ctx, s.provider.Issuer(), claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity == nil {
+		return s.createAccount(ctx, claims, email)
+	}
+
+	if err := s.identityRepo.Touch(ctx, identity.ID, email); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, identity.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrOIDCLoginFailed
+	}
+
+	return account, nil
+}
+
+// createAccount provisions the account of a first login. It gets a random
+// password nobody knows, a verified email allows to set one with a reset.
+// Existing accounts are never linked by email, that would hand them to
+// whoever controls the address at the provider.
+func (s *OIDCService) createAccount(ctx context.Context, claims *oidc.Claims, email *string) (*entity.Account, error) {
+	username, err := s.freeUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &entity.Account{
+		Username:     username,
+		PasswordHash: s.passwordHasher.Hash(password),
+	}
+
+	if email != nil && claims.EmailVerified && s.validator.Email(*email) == nil {
+		existing, err := s.accountRepo.GetByEmail(ctx, *email)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing == nil {
+			now := time.Now()
+			account.Email = email
+			account.EmailVerifiedAt = &now
+		}
+	}
+
+	id, err := s.identityRepo.CreateWithAccount(ctx, account, &entity.ExternalIdentity{
+		Issuer:  s.provider.Issuer(),
+		Subject: claims.Subject,
+		Email:   email,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.accountRepo.GetByID(ctx, id)
+}
+
+// freeUsername derives a valid username that is not taken from the claims.
+func (s *OIDCService) freeUsername(ctx context.Context, claims *oidc.Claims) (string, error) {
+	base := usernameFallback
+	for _, candidate := range []string{claims.PreferredUsername, strings.Split(claims.Email, "@")[0]} {
+		if candidate = sanitizeUsername(candidate); candidate != "" {
+			base = candidate
+			break
+		}
+	}
+
+	// leave room for the suffix
+	if maxLength := s.validator.rules.UsernameMaxLength - 5; len(base) > maxLength {
+		base = base[:maxLength]
+	}
+
+	username := base
+	for i := 0; i < usernameAttempts; i++ {
+		if s.validator.Username(username) == nil {
+			account, err := s.accountRepo.GetByUsername(ctx, username)
+			if err != nil {
+				return "", err
+			}
+
+			if account == nil {
+				return username, nil
+			}
+		}
+
+		suffix, err := randomToken(3)
+		if err != nil {
+			return "", err
+		}
+
+		username = base + "-" + suffix
+	}
+
+	return "", ErrUsernameAlreadyExists
+}
+
+// sanitizeUsername drops the characters usernames may not contain.
+func sanitizeUsername(username string) string {
+	var b strings.Builder
+	for _, r := range username {
+		if isUsernameRune(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/pkg/hasher"
+	"github.com/realdanielursul/simbir-go/pkg/jwtkeys"
+	"github.com/realdanielursul/simbir-go/pkg/oidc"
+	"github.com/realdanielursul/simbir-go/pkg/oidc/oidctest"
+)
+
+const (
+	testClientID     = "simbir-go"
+	testClientSecret = "secret"
+	testRedirectURL  = "http://localhost:8080/api/Account/OIDC/Callback"
+)
+
+var testOIDCUser = oidctest.User{
+	Subject:       "248289761001",
+	Username:      "jane",
+	Email:         "jane@example.com",
+	EmailVerified: true,
+}
+
+// oidcTest runs the service against an oidctest issuer.
+type oidcTest struct {
+	issuer     *oidctest.Issuer
+	service    *OIDCService
+	accounts   *fakeAccounts
+	identities *fakeIdentities
+	states     *fakeLoginStates
+	// resign, when set, replaces the ID tokens the issuer returns with ones
+	// carrying the same claims signed by resign
+	resign func(claims jwt.MapClaims) (string, error)
+}
+
+func newOIDCTest(t *testing.T, resign func(claims jwt.MapClaims) (string, error)) *oidcTest {
+	t.Helper()
+
+	o := &oidcTest{resign: resign}
+
+	server := httptest.NewUnstartedServer(o)
+	issuerURL := "http://" + server.Listener.Addr().String()
+
+	issuer, err := oidctest.NewIssuer(issuerURL, testClientID, testClientSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer.AddUser(testOIDCUser)
+	o.issuer = issuer
+
+	server.Start()
+	t.Cleanup(server.Close)
+
+	keys, err := jwtkeys.NewKeySet(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := keys.Generate(jwtkeys.AlgorithmEdDSA); err != nil {
+		t.Fatal(err)
+	}
+
+	validator := NewValidator(testValidationRules)
+	passwordHasher := hasher.NewSHA1Hasher("salt")
+
+	o.accounts = newFakeAccounts()
+	o.identities = &fakeIdentities{accounts: o.accounts}
+	o.states = newFakeLoginStates()
+
+	accounts := NewAccountService(o.accounts, fakeTokens{}, fakeRefreshTokens{}, &fakeSessions{}, &fakeTwoFactor{}, nil,
+		passwordHasher, validator, nil, keys, time.Minute, time.Hour, "Simbir.GO", time.Minute)
+
+	provider := oidc.NewProvider(oidc.Config{
+		Issuer:       issuerURL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"openid", "profile", "email"},
+	}, server.Client())
+
+	o.service = NewOIDCService(o.accounts, o.identities, o.states, accounts, passwordHasher, validator, provider, time.Minute)
+	return o
+}
+
+func (o *oidcTest) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/token" || o.resign == nil {
+		o.issuer.ServeHTTP(w, r)
+		return
+	}
+
+	recorder := httptest.NewRecorder()
+	o.issuer.ServeHTTP(recorder, r)
+
+	var token oidc.TokenResponse
+	if recorder.Code != http.StatusOK || json.Unmarshal(recorder.Body.Bytes(), &token) != nil {
+		w.WriteHeader(recorder.Code)
+		_, _ = w.Write(recorder.Body.Bytes())
+		return
+	}
+
+	claims := jwt.MapClaims{}
+	if _, _, err := new(jwt.Parser).ParseUnverified(token.IDToken, claims); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	idToken, err := o.resign(claims)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	token.IDToken = idToken
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(token)
+}
+
+// authorize starts a login and follows it to the issuer, returning what the
+// issuer redirects back with.
+func (o *oidcTest) authorize(t *testing.T) (state, code string) {
+	t.Helper()
+
+	login, err := o.service.StartLogin(context.Background())
+	if err != nil {
+		t.Fatalf("StartLogin: %v", err)
+	}
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+
+	resp, err := client.Get(login.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	location, err := resp.Location()
+	if err != nil {
+		t.Fatalf("issuer did not redirect back: %v", err)
+	}
+
+	query := location.Query()
+	if query.Get("error") != "" {
+		t.Fatalf("issuer refused the login: %s %s", query.Get("error"), query.Get("error_description"))
+	}
+
+	return query.Get("state"), query.Get("code")
+}
+
+func (o *oidcTest) complete(state, code string) (*SignInOutput, error) {
+	return o.service.CompleteLogin(context.Background(), &OIDCCallbackInput{
+		Code:  code,
+		State: state,
+	}, &ClientInfo{UserAgent: "test", IP: "192.0.2.1"})
+}
+
+func (o *oidcTest) login(t *testing.T) (*SignInOutput, error) {
+	t.Helper()
+
+	state, code := o.authorize(t)
+	return o.complete(state, code)
+}
+
+func TestOIDCFirstLoginCreatesAccount(t *testing.T) {
+	o := newOIDCTest(t, nil)
+
+	output, err := o.login(t)
+	if err != nil {
+		t.Fatalf("first login: %v", err)
+	}
+
+	if output.Token == "" || output.RefreshToken == "" {
+		t.Fatalf("first login returned no tokens: %+v", output)
+	}
+
+	account, _ := o.accounts.GetByUsername(context.Background(), testOIDCUser.Username)
+	if account == nil {
+		t.Fatalf("no account %q was created", testOIDCUser.Username)
+	}
+
+	if account.Email == nil || *account.Email != testOIDCUser.Email || account.EmailVerifiedAt == nil {
+		t.Errorf("account email = %v verified at %v, want the verified %s", account.Email, account.EmailVerifiedAt, testOIDCUser.Email)
+	}
+
+	if _, err := o.login(t); err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+
+	if count := o.accounts.count(); count != 1 {
+		t.Errorf("%d accounts after the second login, want 1", count)
+	}
+}
+
+func TestOIDCFirstLoginDoesNotLinkByEmail(t *testing.T) {
+	o := newOIDCTest(t, nil)
+
+	email := testOIDCUser.Email
+	verifiedAt := time.Now()
+	existingID, _ := o.accounts.Create(context.Background(), &entity.Account{
+		Username:        testOIDCUser.Username,
+		PasswordHash:    "hash",
+		Email:           &email,
+		EmailVerifiedAt: &verifiedAt,
+	})
+
+	if _, err := o.login(t); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	identity, _ := o.identities.GetBySubject(context.Background(), o.service.provider.Issuer(), testOIDCUser.Subject)
+	if identity == nil {
+		t.Fatal("no identity was linked")
+	}
+
+	if identity.AccountID == existingID {
+		t.Fatal("the identity was linked to the existing account with the same email")
+	}
+
+	created, _ := o.accounts.GetByID(context.Background(), identity.AccountID)
+	if created.Username == testOIDCUser.Username {
+		t.Errorf("created account took the existing username %q", created.Username)
+	}
+
+	if created.Email != nil {
+		t.Errorf("created account got the email %q of the existing account", *created.Email)
+	}
+
+	existing, _ := o.accounts.GetByID(context.Background(), existingID)
+	if existing.Email == nil || *existing.Email != email {
+		t.Errorf("existing account email changed to %v", existing.Email)
+	}
+}
+
+func TestOIDCCodeVerifier(t *testing.T) {
+	o := newOIDCTest(t, nil)
+
+	login, err := o.service.StartLogin(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := url.Parse(login.AuthorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := authURL.Query()
+	state, _ := o.states.GetByHash(context.Background(), hashToken(query.Get("state")))
+	if state == nil {
+		t.Fatal("the login state was not stored")
+	}
+
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") != oidc.CodeChallenge(state.CodeVerifier) {
+		t.Fatalf("authorization URL does not carry the S256 challenge of the stored verifier: %s", login.AuthorizationURL)
+	}
+
+	// a code intercepted on the way back is useless without the verifier
+	stateValue, code := o.authorize(t)
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o.states.modify(stateValue, func(state *entity.OIDCLoginState) {
+		state.CodeVerifier = verifier
+	})
+
+	if _, err := o.complete(stateValue, code); !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Fatalf("login with another verifier: got %v, want %v", err, ErrOIDCLoginFailed)
+	}
+
+	if count := o.accounts.count(); count != 0 {
+		t.Errorf("%d accounts created by a failed login", count)
+	}
+}
+
+func TestOIDCState(t *testing.T) {
+	t.Run("reused", func(t *testing.T) {
+		o := newOIDCTest(t, nil)
+
+		state, code := o.authorize(t)
+		if _, err := o.complete(state, code); err != nil {
+			t.Fatalf("login: %v", err)
+		}
+
+		if _, err := o.complete(state, code); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Fatalf("replayed callback: got %v, want %v", err, ErrInvalidOIDCState)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		o := newOIDCTest(t, nil)
+
+		state, code := o.authorize(t)
+		o.states.modify(state, func(state *entity.OIDCLoginState) {
+			state.ExpiresAt = time.Now().Add(-time.Second)
+		})
+
+		if _, err := o.complete(state, code); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Fatalf("expired state: got %v, want %v", err, ErrInvalidOIDCState)
+		}
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		o := newOIDCTest(t, nil)
+
+		_, code := o.authorize(t)
+		if _, err := o.complete("forged", code); !errors.Is(err, ErrInvalidOIDCState) {
+			t.Fatalf("unknown state: got %v, want %v", err, ErrInvalidOIDCState)
+		}
+	})
+}
+
+func TestOIDCNonceMismatch(t *testing.T) {
+	o := newOIDCTest(t, nil)
+
+	// the ID token carries the nonce of another login
+	state, code := o.authorize(t)
+	o.states.modify(state, func(state *entity.OIDCLoginState) {
+		state.Nonce = "another login"
+	})
+
+	if _, err := o.complete(state, code); !errors.Is(err, ErrOIDCLoginFailed) {
+		t.Fatalf("nonce mismatch: got %v, want %v", err, ErrOIDCLoginFailed)
+	}
+
+	if count := o.accounts.count(); count != 0 {
+		t.Errorf("%d accounts created by a failed login", count)
+	}
+}
+
+func TestOIDCTokenSignature(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signWith := func(kid string) func(claims jwt.MapClaims) (string, error) {
+		return func(claims jwt.MapClaims) (string, error) {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+			token.Header["kid"] = kid
+			return token.SignedString(key)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		resign func(claims jwt.MapClaims) (string, error)
+	}{
+		{name: "unknown kid", resign: signWith("rotated")},
+		{name: "bad signature", resign: signWith("oidctest")},
+		{name: "unsigned", resign: func(claims jwt.MapClaims) (string, error) {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, claims)
+			token.Header["kid"] = "oidctest"
+			return token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := newOIDCTest(t, tt.resign)
+
+			if _, err := o.login(t); !errors.Is(err, ErrOIDCLoginFailed) {
+				t.Fatalf("got %v, want %v", err, ErrOIDCLoginFailed)
+			}
+
+			if count := o.accounts.count(); count != 0 {
+				t.Errorf("%d accounts created by a failed login", count)
+			}
+		})
+	}
+}
@@ -2,8 +2,10 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	"github.com/realdanielursul/simbir-go/internal/entity"
 	"github.com/realdanielursul/simbir-go/internal/repository"
 	"github.com/sirupsen/logrus"
 )
@@ -13,14 +15,16 @@ type PaymentService struct {
 	transportRepo repository.Transport
 	paymentRepo   repository.Payment
 	rentRepo      repository.Rent
+	auditor       *Auditor
 }
 
-func NewPaymentService(accountRepo repository.Account, paymentRepo repository.Payment, transportRepo repository.Transport, rentRepo repository.Rent) *PaymentService {
+func NewPaymentService(accountRepo repository.Account, paymentRepo repository.Payment, transportRepo repository.Transport, rentRepo repository.Rent, auditor *Auditor) *PaymentService {
 	return &PaymentService{
 		accountRepo:   accountRepo,
 		transportRepo: transportRepo,
 		paymentRepo:   paymentRepo,
 		rentRepo:      rentRepo,
+		auditor:       auditor,
 	}
 }
 
@@ -34,7 +38,7 @@ func (s *PaymentService) UpdateBalance(ctx context.Context, accountID int64, amo
 		return ErrAccountNotFound
 	}
 
-	if err := s.paymentRepo.UpdateBalance(ctx, accountID, int64(amount*100)); err != nil {
+	if err := s.paymentRepo.UpdateBalance(ctx, accountID, int64(amount*100), s.auditor.Balance(ctx, AuditBalanceDeposit, accountID)); err != nil {
 		return err
 	}
 
@@ -48,7 +52,8 @@ func (s *PaymentService) BillingWorker(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
-			s.ProcessBilling(ctx)
+			// let an in-flight billing round finish even if ctx is cancelled meanwhile
+			s.ProcessBilling(context.WithoutCancel(ctx))
 		case <-ctx.Done():
 			return
 		}
@@ -60,13 +65,15 @@ func (s *PaymentService) ProcessBilling(ctx context.Context) {
 
 	rents, err := s.rentRepo.ListActive(ctx)
 	if err != nil {
-		logrus.Errorf("billing error: %w", err)
+		logrus.Errorf("billing error: %v", err)
+		return
 	}
 
 	for _, rent := range rents {
 		account, err := s.accountRepo.GetByID(ctx, rent.UserID)
-		if err != nil {
-			logrus.Errorf("billing error: %w", err)
+		if err != nil || account == nil {
+			logrus.Errorf("billing error: cannot get account %d: %v", rent.UserID, err)
+			continue
 		}
 
 		elapsed := time.Now().UTC().Sub(rent.LastBilledAt)
@@ -74,44 +81,78 @@ func (s *PaymentService) ProcessBilling(ctx context.Context) {
 		switch rent.PriceType {
 		case "Minutes":
 			if account.Balance < rent.PriceOfUnit {
-				if err := s.rentRepo.EndRent(ctx, rent.ID, 0, 0); err != nil {
-					logrus.Errorf("billing error: %w", err)
+				if err := s.forceEndRent(ctx, &rent); err != nil {
+					logrus.Errorf("billing error: %v", err)
 				}
 
-				if err := s.transportRepo.ChangeAvailability(ctx, rent.TransportID, true); err != nil {
-					logrus.Errorf("billing error: %w", err)
-				}
+				continue
 			}
 
 			if int64(elapsed.Seconds()) == 59 {
-				if err := s.paymentRepo.UpdateBalance(ctx, rent.UserID, -rent.PriceOfUnit); err != nil {
-					logrus.Errorf("billing error: %w", err)
-				}
-
-				if err := s.rentRepo.UpdateLastBilledTime(ctx, rent.ID); err != nil {
-					logrus.Errorf("billing error: %w", err)
-				}
+				s.chargeRent(ctx, &rent)
 			}
 		case "Days":
 			if account.Balance < rent.PriceOfUnit {
-				if err := s.rentRepo.EndRent(ctx, rent.ID, 0, 0); err != nil {
-					logrus.Errorf("billing error: %w", err)
+				if err := s.forceEndRent(ctx, &rent); err != nil {
+					logrus.Errorf("billing error: %v", err)
 				}
 
-				if err := s.transportRepo.ChangeAvailability(ctx, rent.TransportID, true); err != nil {
-					logrus.Errorf("billing error: %w", err)
-				}
+				continue
 			}
 
 			if int64(elapsed.Hours()/24) == 1 {
-				if err := s.paymentRepo.UpdateBalance(ctx, rent.UserID, -rent.PriceOfUnit); err != nil {
-					logrus.Errorf("billing error: %w", err)
-				}
-
-				if err := s.rentRepo.UpdateLastBilledTime(ctx, rent.ID); err != nil {
-					logrus.Errorf("billing error: %w", err)
-				}
+				s.chargeRent(ctx, &rent)
 			}
 		}
 	}
 }
+
+// chargeRent bills the next unit of the rent.
+func (s *PaymentService) chargeRent(ctx context.Context, rent *entity.Rent) {
+	if err := s.paymentRepo.UpdateBalance(ctx, rent.UserID, -rent.PriceOfUnit, s.auditor.Balance(ctx, AuditRentCharge, rent.UserID)); err != nil {
+		logrus.Errorf("billing error: %v", err)
+	}
+
+	if err := s.rentRepo.UpdateLastBilledTime(ctx, rent.ID); err != nil {
+		logrus.Errorf("billing error: %v", err)
+	}
+}
+
+// endActiveRents ends every rent of the user that is still running.
+func (s *PaymentService) endActiveRents(ctx context.Context, userID int64) error {
+	rents, err := s.rentRepo.ListActiveByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, rent := range rents {
+		if err := s.forceEndRent(ctx, &rent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// forceEndRent ends the rent leaving the transport at its last known position.
+func (s *PaymentService) forceEndRent(ctx context.Context, rent *entity.Rent) error {
+	transport, err := s.transportRepo.GetByID(ctx, rent.TransportID)
+	if err != nil {
+		return err
+	}
+
+	if transport == nil {
+		return ErrTransportNotFound
+	}
+
+	if err := s.rentRepo.EndRent(ctx, rent.ID, transport.Latitude, transport.Longitude, s.auditor.RentEnd(ctx, AuditRentForceEnd, rent)); err != nil {
+		// the renter ended it meanwhile
+		if errors.Is(err, repository.ErrRentAlreadyEnded) {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
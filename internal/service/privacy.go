@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/internal/repository"
+)
+
+// exportPageSize is the page owned transport is read in for an export.
+const exportPageSize = 100
+
+// paymentTypes maps the audited actions that move a balance to the payment
+// type they are exported as.
+var paymentTypes = map[string]string{
+	AuditBalanceDeposit: "deposit",
+	AuditRentCharge:     "charge",
+	AuditAccountBalance: "adjustment",
+	AuditAccountUpdate:  "adjustment",
+}
+
+type PrivacyService struct {
+	accountRepo   repository.Account
+	sessionRepo   repository.Session
+	twoFactorRepo repository.TwoFactor
+	rentRepo      repository.Rent
+	auditRepo     repository.AuditLog
+	accounts      *AccountService
+	rents         *RentService
+	transports    *TransportService
+	identities    *OIDCService
+	profiles      *ProfileService
+	guard         *SignInGuard
+	auditor       *Auditor
+}
+
+func NewPrivacyService(accountRepo repository.Account, sessionRepo repository.Session, twoFactorRepo repository.TwoFactor, rentRepo repository.Rent, auditRepo repository.AuditLog, accounts *AccountService, rents *RentService, transports *TransportService, identities *OIDCService, profiles *ProfileService, guard *SignInGuard, auditor *Auditor) *PrivacyService {
+	return &PrivacyService{
+		accountRepo:   accountRepo,
+		sessionRepo:   sessionRepo,
+		twoFactorRepo: twoFactorRepo,
+		rentRepo:      rentRepo,
+		auditRepo:     auditRepo,
+		accounts:      accounts,
+		rents:         rents,
+		transports:    transports,
+		identities:    identities,
+		profiles:      profiles,
+		guard:         guard,
+		auditor:       auditor,
+	}
+}
+
+// ExportAccount collects the data kept about the account.
+func (s *PrivacyService) ExportAccount(ctx context.Context, userID int64) (*AccountExport, error) {
+	account, err := s.accounts.GetAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.profiles.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	licenses, err := s.profiles.listLicenses(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rents, err := s.rents.ListRentsByAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, err := s.payments(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionsOutput := make([]SessionOutput, 0, len(sessions))
+	for _, session := range sessions {
+		sessionsOutput = append(sessionsOutput, SessionOutput{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			RevokedAt:  session.RevokedAt,
+		})
+	}
+
+	transports, err := s.ownedTransport(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, err := s.identities.ListIdentities(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountExport{
+		Account:    *account,
+		Profile:    *profile,
+		Licenses:   licenses,
+		Rents:      rents,
+		Payments:   payments,
+		Sessions:   sessionsOutput,
+		Transports: transports,
+		Identities: identities,
+		ExportedAt: time.Now().UTC(),
+	}, nil
+}
+
+// EraseAccount anonymizes the account of the caller once the password, and
+// the second factor if enabled, confirm it.
+func (s *PrivacyService) EraseAccount(ctx context.Context, userID int64, input *EraseAccountInput, client *ClientInfo) error {
+	account, err := s.accountRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if account == nil || account.ErasedAt != nil {
+		return ErrAccountNotFound
+	}
+
+	if err := s.accounts.confirmPassword(ctx, account, input.Password, client); err != nil {
+		return err
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if twoFactor != nil && twoFactor.EnabledAt != nil {
+		if err := s.accounts.verifyCode(ctx, twoFactor, input.Code, true); err != nil {
+			return err
+		}
+	}
+
+	return s.erase(ctx, account)
+}
+
+// erase removes the personal data of the account, its license documents
+// and everything that signs it in. Rents and the balance stay for accounting,
+// owned transport stays with its rent history but is withdrawn from rental.
+// Entries the audit log recorded earlier are kept with the personal data
+// redacted.
+func (s *PrivacyService) erase(ctx context.Context, account *entity.Account) error {
+	active, err := s.rentRepo.HasActive(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+
+	if active {
+		return ErrActiveRent
+	}
+
+	// the rows go with the account, the files are removed after it is gone
+	licenseFiles, err := s.profiles.licenseFiles(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+
+	erased, err := s.accountRepo.Erase(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+
+	if !erased {
+		return ErrAccountNotFound
+	}
+
+	s.profiles.removeFiles(ctx, licenseFiles)
+
+	if err := s.guard.Unlock(ctx, account.Username); err != nil {
+		return err
+	}
+
+	// the entry outlives the erasure, it keeps no address of the person
+	if principal, ok := PrincipalFromContext(ctx); ok && principal.UserID == account.ID {
+		anonymous := *principal
+		anonymous.IP = ""
+		ctx = WithPrincipal(ctx, &anonymous)
+	}
+
+	s.auditor.Record(ctx, AuditAccountErase, AuditTargetAccount, account.ID, nil, nil)
+
+	return nil
+}
+
+// payments rebuilds the balance changes of the account from the audit log.
+func (s *PrivacyService) payments(ctx context.Context, accountID int64) ([]PaymentOutput, error) {
+	actions := make([]string, 0, len(paymentTypes))
+	for action := range paymentTypes {
+		actions = append(actions, action)
+	}
+
+	entries, err := s.auditRepo.ListByTarget(ctx, AuditTargetAccount, accountID, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]PaymentOutput, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Before.Valid || !entry.After.Valid {
+			continue
+		}
+
+		var before, after struct {
+			Balance *float64 `json:"balance"`
+		}
+
+		if err := json.Unmarshal(entry.Before.JSONText, &before); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(entry.After.JSONText, &after); err != nil {
+			return nil, err
+		}
+
+		// updates that left the balance alone
+		if before.Balance == nil || after.Balance == nil {
+			continue
+		}
+
+		payments = append(payments, PaymentOutput{
+			Type:      paymentTypes[entry.Action],
+			Amount:    math.Round((*after.Balance-*before.Balance)*100) / 100,
+			Balance:   *after.Balance,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	return payments, nil
+}
+
+func (s *PrivacyService) ownedTransport(ctx context.Context, ownerID int64) ([]TransportOutput, error) {
+	transports := make([]TransportOutput, 0)
+	for start := 0; ; start += exportPageSize {
+		page, err := s.transports.ListTransportByOwner(ctx, ownerID, exportPageSize, start)
+		if err != nil {
+			return nil, err
+		}
+
+		transports = append(transports, page...)
+		if len(page) < exportPageSize {
+			return transports, nil
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/internal/repository"
+	"github.com/realdanielursul/simbir-go/pkg/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// License review statuses, profiles without an upload have none.
+const (
+	LicenseStatusNone     = "none"
+	LicenseStatusPending  = entity.LicensePending
+	LicenseStatusApproved = entity.LicenseApproved
+	LicenseStatusRejected = entity.LicenseRejected
+)
+
+var licenseStatuses = []string{LicenseStatusPending, LicenseStatusApproved, LicenseStatusRejected}
+
+// LicenseContentTypes are the file types accepted as license documents,
+// detected from the content rather than trusted from the client.
+var LicenseContentTypes = []string{"image/jpeg", "image/png", "application/pdf"}
+
+var licenseExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"application/pdf": ".pdf",
+}
+
+// KYCPolicy sets what riders have to prove before renting.
+type KYCPolicy struct {
+	// MaxLicenseSize limits uploaded license documents, in bytes
+	MaxLicenseSize int64
+	// MinRiderAge is the age required to rent bikes and scooters, 0
+	// disables the check
+	MinRiderAge int
+}
+
+type ProfileService struct {
+	accountRepo repository.Account
+	licenseRepo repository.License
+	store       storage.Store
+	validator   *Validator
+	policy      KYCPolicy
+}
+
+func NewProfileService(accountRepo repository.Account, licenseRepo repository.License, store storage.Store, validator *Validator, policy KYCPolicy) *ProfileService {
+	return &ProfileService{
+		accountRepo: accountRepo,
+		licenseRepo: licenseRepo,
+		store:       store,
+		validator:   validator,
+		policy:      policy,
+	}
+}
+
+func (s *ProfileService) GetProfile(ctx context.Context, userID int64) (*ProfileOutput, error) {
+	account, err := s.account(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	licenseStatus, err := s.licenseStatus(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ProfileOutput{
+		FullName:      account.FullName,
+		Phone:         account.Phone,
+		BirthDate:     formatDate(account.BirthDate),
+		LicenseStatus: licenseStatus,
+	}, nil
+}
+
+// UpdateProfile replaces the profile. The birth date an approved license
+// was checked against cannot change anymore.
+func (s *ProfileService) UpdateProfile(ctx context.Context, userID int64, input *ProfileInput) error {
+	if err := s.validator.Profile(input); err != nil {
+		return err
+	}
+
+	account, err := s.account(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var birthDate *time.Time
+	if input.BirthDate != nil {
+		date, _ := time.Parse(dateLayout, *input.BirthDate)
+		birthDate = &date
+	}
+
+	if account.BirthDate != nil && (birthDate == nil || birthDate.Format(dateLayout) != account.BirthDate.Format(dateLayout)) {
+		approved, err := s.licenseRepo.HasApproved(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if approved {
+			return ErrBirthDateLocked
+		}
+	}
+
+	account.FullName = input.FullName
+	account.Phone = input.Phone
+	account.BirthDate = birthDate
+
+	return s.accountRepo.SetProfile(ctx, account)
+}
+
+// GetLicense returns the license document uploaded last.
+func (s *ProfileService) GetLicense(ctx context.Context, userID int64) (*LicenseOutput, error) {
+	document, err := s.licenseRepo.GetLatest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if document == nil {
+		return nil, ErrLicenseNotFound
+	}
+
+	return licenseOutput(document), nil
+}
+
+// UploadLicense stores a driver license document for an admin to review.
+// A new document may be uploaded once the previous one was reviewed, e.g.
+// after a rejection or when the license was renewed.
+func (s *ProfileService) UploadLicense(ctx context.Context, userID int64, file io.Reader) (*LicenseOutput, error) {
+	if _, err := s.account(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	latest, err := s.licenseRepo.GetLatest(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if latest != nil && latest.Status == entity.LicensePending {
+		return nil, ErrLicensePending
+	}
+
+	// read one byte past the limit to tell a file of exactly the limit
+	// from a larger one
+	data, err := io.ReadAll(io.LimitReader(file, s.policy.MaxLicenseSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read license: %w", err)
+	}
+
+	if int64(len(data)) > s.policy.MaxLicenseSize {
+		return nil, ErrLicenseTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := licenseExtensions[contentType]
+	if !ok {
+		return nil, ErrInvalidLicenseFile
+	}
+
+	name, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	document := &entity.LicenseDocument{
+		AccountID:   userID,
+		StorageKey:  fmt.Sprintf("licenses/%d/%s%s", userID, name, extension),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Status:      entity.LicensePending,
+		CreatedAt:   time.Now().UTC(),
+	}
+
+	if err := s.store.Put(ctx, document.StorageKey, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	document.ID, err = s.licenseRepo.Create(ctx, document)
+	if err != nil {
+		s.removeFiles(ctx, []string{document.StorageKey})
+		return nil, err
+	}
+
+	return licenseOutput(document), nil
+}
+
+func (s *ProfileService) listLicenses(ctx context.Context, accountID int64) ([]LicenseOutput, error) {
+	documents, err := s.licenseRepo.ListByAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]LicenseOutput, 0, len(documents))
+	for _, document := range documents {
+		output = append(output, *licenseOutput(&document))
+	}
+
+	return output, nil
+}
+
+// licenseFiles returns the storage keys of the documents of the account.
+func (s *ProfileService) licenseFiles(ctx context.Context, accountID int64) ([]string, error) {
+	documents, err := s.licenseRepo.ListByAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(documents))
+	for _, document := range documents {
+		keys = append(keys, document.StorageKey)
+	}
+
+	return keys, nil
+}
+
+// removeFiles deletes stored documents whose rows are gone. A file left
+// behind is logged for cleanup rather than failing the caller.
+func (s *ProfileService) removeFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			logrus.Errorf("failed to delete license file %s: %v", key, err)
+		}
+	}
+}
+
+func (s *ProfileService) licenseStatus(ctx context.Context, accountID int64) (string, error) {
+	approved, err := s.licenseRepo.HasApproved(ctx, accountID)
+	if err != nil {
+		return "", err
+	}
+
+	if approved {
+		return entity.LicenseApproved, nil
+	}
+
+	latest, err := s.licenseRepo.GetLatest(ctx, accountID)
+	if err != nil {
+		return "", err
+	}
+
+	if latest == nil {
+		return LicenseStatusNone, nil
+	}
+
+	return latest.Status, nil
+}
+
+func (s *ProfileService) account(ctx context.Context, userID int64) (*entity.Account, error) {
+	account, err := s.accountRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil || account.ErasedAt != nil {
+		return nil, ErrAccountNotFound
+	}
+
+	return account, nil
+}
+
+// RiderChecker decides whether an account may rent a type of transport:
+// cars need an approved driver license, bikes and scooters a minimum age.
+type RiderChecker struct {
+	licenseRepo repository.License
+	policy      KYCPolicy
+}
+
+func NewRiderChecker(licenseRepo repository.License, policy KYCPolicy) *RiderChecker {
+	return &RiderChecker{
+		licenseRepo: licenseRepo,
+		policy:      policy,
+	}
+}
+
+func (c *RiderChecker) Check(ctx context.Context, account *entity.Account, transportType string) error {
+	if transportType == "Car" {
+		approved, err := c.licenseRepo.HasApproved(ctx, account.ID)
+		if err != nil {
+			return err
+		}
+
+		if !approved {
+			return ErrLicenseRequired
+		}
+
+		return nil
+	}
+
+	if c.policy.MinRiderAge <= 0 {
+		return nil
+	}
+
+	if account.BirthDate == nil {
+		return ErrBirthDateRequired
+	}
+
+	if age(*account.BirthDate, time.Now().UTC()) < c.policy.MinRiderAge {
+		return ErrAgeRequirement
+	}
+
+	return nil
+}
+
+// age returns the full years between birthDate and now.
+func age(birthDate, now time.Time) int {
+	years := now.Year() - birthDate.Year()
+	if now.Month() < birthDate.Month() || now.Month() == birthDate.Month() && now.Day() < birthDate.Day() {
+		years--
+	}
+
+	return years
+}
+
+func isLicenseStatus(status string) bool {
+	for _, known := range licenseStatuses {
+		if status == known {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatDate(date *time.Time) *string {
+	if date == nil {
+		return nil
+	}
+
+	formatted := date.Format(dateLayout)
+	return &formatted
+}
+
+func licenseOutput(document *entity.LicenseDocument) *LicenseOutput {
+	return &LicenseOutput{
+		ID:           document.ID,
+		AccountID:    document.AccountID,
+		ContentType:  document.ContentType,
+		Size:         document.Size,
+		Status:       document.Status,
+		RejectReason: document.RejectReason,
+		ReviewedBy:   document.ReviewedBy,
+		ReviewedAt:   document.ReviewedAt,
+		CreatedAt:    document.CreatedAt,
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/realdanielursul/simbir-go/internal/entity"
@@ -10,21 +11,29 @@ import (
 
 type RentService struct {
 	accountRepo   repository.Account
-	paymentRepo   repository.Payment
 	transportRepo repository.Transport
 	rentRepo      repository.Rent
+	validator     *Validator
+	rider         *RiderChecker
+	auditor       *Auditor
 }
 
-func NewRentService(accountRepo repository.Account, paymentRepo repository.Payment, transportRepo repository.Transport, rentRepo repository.Rent) *RentService {
+func NewRentService(accountRepo repository.Account, transportRepo repository.Transport, rentRepo repository.Rent, validator *Validator, rider *RiderChecker, auditor *Auditor) *RentService {
 	return &RentService{
 		accountRepo:   accountRepo,
-		paymentRepo:   paymentRepo,
 		transportRepo: transportRepo,
 		rentRepo:      rentRepo,
+		validator:     validator,
+		rider:         rider,
+		auditor:       auditor,
 	}
 }
 
 func (s *RentService) StartRent(ctx context.Context, userID, transportID int64, rentType string) (int64, error) {
+	if err := s.validator.RentType(rentType); err != nil {
+		return -1, err
+	}
+
 	// check balance is good
 	//get acc
 	account, err := s.accountRepo.GetByID(ctx, userID)
@@ -36,6 +45,10 @@ func (s *RentService) StartRent(ctx context.Context, userID, transportID int64,
 		return -1, ErrAccountNotFound
 	}
 
+	if err := checkStatus(account); err != nil {
+		return -1, err
+	}
+
 	//get transport
 	transport, err := s.transportRepo.GetByID(ctx, transportID)
 	if err != nil {
@@ -46,43 +59,55 @@ func (s *RentService) StartRent(ctx context.Context, userID, transportID int64,
 		return -1, ErrTransportNotFound
 	}
 
-	if rentType == "Minutes" && account.Balance < transport.MinutePrice {
-		return -1, ErrNotEnoughMoney
+	if !transport.CanBeRented {
+		return -1, ErrTransportUnavailable
 	}
 
-	if rentType == "Days" && account.Balance < transport.DayPrice {
-		return -1, ErrNotEnoughMoney
+	// owners cannot rent their own transport
+	if transport.OwnerID == userID {
+		return -1, ErrAccessDenied
 	}
 
-	var priceOfUnit int64
-	if rentType == "Minutes" {
-		priceOfUnit = transport.MinutePrice
-	} else {
-		priceOfUnit = transport.DayPrice
+	if err := s.rider.Check(ctx, account, transport.TransportType); err != nil {
+		return -1, err
 	}
 
-	id, err := s.rentRepo.StartRent(ctx, &entity.Rent{
+	// the transport is claimed and the first unit charged in one transaction,
+	// two renters cannot take it and the balance cannot be spent twice
+	rent := &entity.Rent{
 		TransportID: transportID,
 		UserID:      userID,
 		TimeStart:   time.Now().UTC(),
 		TimeEnd:     nil,
-		PriceOfUnit: priceOfUnit,
 		PriceType:   rentType,
 		FinalPrice:  nil,
-	})
-
-	if err := s.transportRepo.ChangeAvailability(ctx, transportID, false); err != nil {
-		return -1, err
 	}
 
-	if err := s.paymentRepo.UpdateBalance(ctx, userID, -priceOfUnit); err != nil {
-		return -1, ErrRentNotFound
+	id, err := s.rentRepo.StartRent(ctx, rent, false, s.auditor.Balance(ctx, AuditRentCharge, userID))
+	if err != nil {
+		return -1, rentStartError(err)
 	}
 
 	return id, nil
 }
 
+// rentStartError translates the conditions checked when the rent is stored.
+func rentStartError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrTransportUnavailable):
+		return ErrTransportUnavailable
+	case errors.Is(err, repository.ErrInsufficientBalance):
+		return ErrNotEnoughMoney
+	default:
+		return err
+	}
+}
+
 func (s *RentService) EndRent(ctx context.Context, userID, id int64, lat, long float64) error {
+	if err := s.validator.Position(lat, long); err != nil {
+		return err
+	}
+
 	rent, err := s.rentRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
@@ -92,18 +117,34 @@ func (s *RentService) EndRent(ctx context.Context, userID, id int64, lat, long f
 		return ErrRentNotFound
 	}
 
-	if err := s.rentRepo.EndRent(ctx, id, lat, long); err != nil {
-		return err
+	// only renter can end the rent
+	if rent.UserID != userID {
+		return ErrAccessDenied
 	}
 
-	if err := s.transportRepo.ChangeAvailability(ctx, rent.TransportID, true); err != nil {
-		return err
+	if rent.TimeEnd != nil {
+		return ErrRentAlreadyEnded
+	}
+
+	// the transport is released in the same transaction, and only once when
+	// the rent is ended from two places at the same time
+	if err := s.rentRepo.EndRent(ctx, id, lat, long, nil); err != nil {
+		return rentEndError(err)
 	}
 
 	return nil
 }
 
-func (s *RentService) GetRent(ctx context.Context, id int64) (*RentOutput, error) {
+// rentEndError translates the conditions checked when the rent is ended.
+func rentEndError(err error) error {
+	if errors.Is(err, repository.ErrRentAlreadyEnded) {
+		return ErrRentAlreadyEnded
+	}
+
+	return err
+}
+
+func (s *RentService) GetRent(ctx context.Context, userID, id int64) (*RentOutput, error) {
 	rent, err := s.rentRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -113,15 +154,28 @@ func (s *RentService) GetRent(ctx context.Context, id int64) (*RentOutput, error
 		return nil, ErrRentNotFound
 	}
 
+	// only renter or transport owner can see the rent
+	if rent.UserID != userID {
+		transport, err := s.transportRepo.GetByID(ctx, rent.TransportID)
+		if err != nil {
+			return nil, err
+		}
+
+		if transport == nil || transport.OwnerID != userID {
+			return nil, ErrAccessDenied
+		}
+	}
+
 	return &RentOutput{
-		ID:          rent.ID,
-		TransportID: rent.TransportID,
-		UserID:      rent.UserID,
-		TimeStart:   rent.TimeStart,
-		TimeEnd:     rent.TimeEnd,
-		PriceOfUnit: rent.PriceOfUnit,
-		PriceType:   rent.PriceType,
-		FinalPrice:  rent.FinalPrice,
+		ID:           rent.ID,
+		TransportID:  rent.TransportID,
+		UserID:       rent.UserID,
+		TimeStart:    rent.TimeStart,
+		TimeEnd:      rent.TimeEnd,
+		PriceOfUnit:  rent.PriceOfUnit,
+		PriceType:    rent.PriceType,
+		FinalPrice:   rent.FinalPrice,
+		LastBilledAt: rent.LastBilledAt,
 	}, nil
 }
 
@@ -143,14 +197,15 @@ func (s *RentService) ListRentsByAccount(ctx context.Context, accountID int64) (
 	rentsOutput := make([]RentOutput, 0, len(rents))
 	for _, rent := range rents {
 		rentOutput := RentOutput{
-			ID:          rent.ID,
-			TransportID: rent.TransportID,
-			UserID:      rent.UserID,
-			TimeStart:   rent.TimeStart,
-			TimeEnd:     rent.TimeEnd,
-			PriceOfUnit: rent.PriceOfUnit,
-			PriceType:   rent.PriceType,
-			FinalPrice:  rent.FinalPrice,
+			ID:           rent.ID,
+			TransportID:  rent.TransportID,
+			UserID:       rent.UserID,
+			TimeStart:    rent.TimeStart,
+			TimeEnd:      rent.TimeEnd,
+			PriceOfUnit:  rent.PriceOfUnit,
+			PriceType:    rent.PriceType,
+			FinalPrice:   rent.FinalPrice,
+			LastBilledAt: rent.LastBilledAt,
 		}
 
 		rentsOutput = append(rentsOutput, rentOutput)
@@ -159,7 +214,7 @@ func (s *RentService) ListRentsByAccount(ctx context.Context, accountID int64) (
 	return rentsOutput, nil
 }
 
-func (s *RentService) ListRentsByTransport(ctx context.Context, transportID int64) ([]RentOutput, error) {
+func (s *RentService) ListRentsByTransport(ctx context.Context, userID, transportID int64) ([]RentOutput, error) {
 	transport, err := s.transportRepo.GetByID(ctx, transportID)
 	if err != nil {
 		return nil, err
@@ -169,6 +224,11 @@ func (s *RentService) ListRentsByTransport(ctx context.Context, transportID int6
 		return nil, ErrTransportNotFound
 	}
 
+	// only transport owner can see its rent history
+	if transport.OwnerID != userID {
+		return nil, ErrAccessDenied
+	}
+
 	rents, err := s.rentRepo.GetHistoryByTransport(ctx, transportID)
 	if err != nil {
 		return nil, err
@@ -177,14 +237,15 @@ func (s *RentService) ListRentsByTransport(ctx context.Context, transportID int6
 	rentsOutput := make([]RentOutput, 0, len(rents))
 	for _, rent := range rents {
 		rentOutput := RentOutput{
-			ID:          rent.ID,
-			TransportID: rent.TransportID,
-			UserID:      rent.UserID,
-			TimeStart:   rent.TimeStart,
-			TimeEnd:     rent.TimeEnd,
-			PriceOfUnit: rent.PriceOfUnit,
-			PriceType:   rent.PriceType,
-			FinalPrice:  rent.FinalPrice,
+			ID:           rent.ID,
+			TransportID:  rent.TransportID,
+			UserID:       rent.UserID,
+			TimeStart:    rent.TimeStart,
+			TimeEnd:      rent.TimeEnd,
+			PriceOfUnit:  rent.PriceOfUnit,
+			PriceType:    rent.PriceType,
+			FinalPrice:   rent.FinalPrice,
+			LastBilledAt: rent.LastBilledAt,
 		}
 
 		rentsOutput = append(rentsOutput, rentOutput)
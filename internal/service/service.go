@@ -2,48 +2,142 @@ package service
 
 import (
 	"context"
+	"io"
 	"time"
 
 	"github.com/realdanielursul/simbir-go/internal/repository"
+	"github.com/realdanielursul/simbir-go/pkg/api"
 	"github.com/realdanielursul/simbir-go/pkg/hasher"
+	"github.com/realdanielursul/simbir-go/pkg/jwtkeys"
+	"github.com/realdanielursul/simbir-go/pkg/mailer"
+	"github.com/realdanielursul/simbir-go/pkg/oidc"
+	"github.com/realdanielursul/simbir-go/pkg/storage"
 )
 
-type AccountInput struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
-}
+// Request and response bodies are defined in pkg/api, which pkg/client
+// shares without depending on the service layer.
+type (
+	AccountInput           = api.AccountInput
+	AccountOutput          = api.AccountOutput
+	TokenOutput            = api.TokenOutput
+	SignInOutput           = api.SignInOutput
+	SignInChallengeOutput  = api.SignInChallengeOutput
+	TwoFactorSignInInput   = api.TwoFactorSignInInput
+	TwoFactorCodeInput     = api.TwoFactorCodeInput
+	TwoFactorStatus        = api.TwoFactorStatus
+	TwoFactorEnrollment    = api.TwoFactorEnrollment
+	RecoveryCodesOutput    = api.RecoveryCodesOutput
+	RefreshInput           = api.RefreshInput
+	SessionOutput          = api.SessionOutput
+	APIKeyInput            = api.APIKeyInput
+	APIKeyOutput           = api.APIKeyOutput
+	EmailInput             = api.EmailInput
+	EmailTokenInput        = api.EmailTokenInput
+	ResetPasswordInput     = api.ResetPasswordInput
+	OIDCLoginOutput        = api.OIDCLoginOutput
+	ExternalIdentityOutput = api.ExternalIdentityOutput
+	ProfileInput           = api.ProfileInput
+	ProfileOutput          = api.ProfileOutput
+	LicenseOutput          = api.LicenseOutput
+	LicenseRejectInput     = api.LicenseRejectInput
+	EraseAccountInput      = api.EraseAccountInput
+	PaymentOutput          = api.PaymentOutput
+	AccountExport          = api.AccountExport
+	AdminAccountInput      = api.AdminAccountInput
+	AdminAccountOutput     = api.AdminAccountOutput
+	AccountStatusInput     = api.AccountStatusInput
+	AdminBalanceInput      = api.AdminBalanceInput
+	SignInAttemptOutput    = api.SignInAttemptOutput
+	RoleOutput             = api.RoleOutput
+	RoleInput              = api.RoleInput
+	TransportInput         = api.TransportInput
+	PositionInput          = api.PositionInput
+	TransportOutput        = api.TransportOutput
+	AdminTransportInput    = api.AdminTransportInput
+	RentOutput             = api.RentOutput
+	AdminRentInput         = api.AdminRentInput
+	AuditLogQuery          = api.AuditLogQuery
+	AuditEntryOutput       = api.AuditEntryOutput
+)
 
-type AccountOutput struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	Balance   float64   `json:"balance"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+// ClientInfo describes the device a session was started from.
+type ClientInfo struct {
+	UserAgent string
+	IP        string
 }
 
 type Account interface {
 	SignUp(ctx context.Context, input *AccountInput) (int64, error)
-	SignIn(ctx context.Context, input *AccountInput) (string, error)
+	SignIn(ctx context.Context, input *AccountInput, client *ClientInfo) (*SignInOutput, error)
+	VerifySignIn(ctx context.Context, input *TwoFactorSignInInput, client *ClientInfo) (*TokenOutput, error)
+	Refresh(ctx context.Context, refreshToken string) (*TokenOutput, error)
 	SignOut(ctx context.Context, tokenString string) error
 	GetAccount(ctx context.Context, id int64) (*AccountOutput, error)
 	UpdateAccount(ctx context.Context, id int64, input *AccountInput) error
-	ValidateToken(ctx context.Context, tokenString string) (int64, bool, error)
+	ListSessions(ctx context.Context, userID, currentSessionID int64) ([]SessionOutput, error)
+	RevokeSession(ctx context.Context, userID, sessionID int64) error
+	TwoFactorStatus(ctx context.Context, userID int64) (*TwoFactorStatus, error)
+	EnrollTwoFactor(ctx context.Context, userID int64) (*TwoFactorEnrollment, error)
+	ConfirmTwoFactor(ctx context.Context, userID int64, code string) (*RecoveryCodesOutput, error)
+	RegenerateRecoveryCodes(ctx context.Context, userID int64, code string, client *ClientInfo) (*RecoveryCodesOutput, error)
+	DisableTwoFactor(ctx context.Context, userID int64, code string, client *ClientInfo) error
+	ValidateToken(ctx context.Context, tokenString string) (*TokenClaims, error)
+	PublicKeys() jwtkeys.JWKS
+}
+
+type APIKey interface {
+	CreateAPIKey(ctx context.Context, userID int64, input *APIKeyInput) (*APIKeyOutput, error)
+	ListAPIKeys(ctx context.Context, userID int64) ([]APIKeyOutput, error)
+	RevokeAPIKey(ctx context.Context, userID, id int64) error
+	Authenticate(ctx context.Context, key string) (*Principal, error)
+}
+
+type Email interface {
+	SetEmail(ctx context.Context, userID int64, email string) error
+	ResendVerification(ctx context.Context, userID int64) error
+	VerifyEmail(ctx context.Context, token string) error
+	ForgotPassword(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, input *ResetPasswordInput) error
+}
+
+// OIDCCallbackInput is what the provider redirects back with.
+type OIDCCallbackInput struct {
+	Code  string
+	State string
+	Error string
 }
 
-type AdminAccountInput struct {
-	Username string  `json:"username"`
-	Password string  `json:"password"`
-	IsAdmin  bool    `json:"isAdmin"`
-	Balance  float64 `json:"balance"`
+type OIDC interface {
+	StartLogin(ctx context.Context) (*OIDCLoginOutput, error)
+	CompleteLogin(ctx context.Context, input *OIDCCallbackInput, client *ClientInfo) (*SignInOutput, error)
+	ListIdentities(ctx context.Context, userID int64) ([]ExternalIdentityOutput, error)
 }
 
-type AdminAccountOutput struct {
-	ID        int64     `json:"id"`
-	Username  string    `json:"username"`
-	IsAdmin   bool      `json:"isAdmin"`
-	Balance   float64   `json:"balance"`
-	CreatedAt time.Time `json:"createdAt"`
-	UpdatedAt time.Time `json:"updatedAt"`
+type Profile interface {
+	GetProfile(ctx context.Context, userID int64) (*ProfileOutput, error)
+	UpdateProfile(ctx context.Context, userID int64, input *ProfileInput) error
+	GetLicense(ctx context.Context, userID int64) (*LicenseOutput, error)
+	UploadLicense(ctx context.Context, userID int64, file io.Reader) (*LicenseOutput, error)
+}
+
+// LicenseFile is an uploaded license document, the caller closes Content.
+type LicenseFile struct {
+	Name        string
+	ContentType string
+	Size        int64
+	Content     io.ReadCloser
+}
+
+type AdminLicense interface {
+	ListLicenses(ctx context.Context, status string, count, start int) ([]LicenseOutput, error)
+	GetLicenseFile(ctx context.Context, id int64) (*LicenseFile, error)
+	ApproveLicense(ctx context.Context, id int64) error
+	RejectLicense(ctx context.Context, id int64, input *LicenseRejectInput) error
+}
+
+type Privacy interface {
+	ExportAccount(ctx context.Context, userID int64) (*AccountExport, error)
+	EraseAccount(ctx context.Context, userID int64, input *EraseAccountInput, client *ClientInfo) error
 }
 
 type AdminAccount interface {
@@ -51,37 +145,24 @@ type AdminAccount interface {
 	GetAccount(ctx context.Context, id int64) (*AdminAccountOutput, error)
 	ListAccounts(ctx context.Context, count, start int) ([]AdminAccountOutput, error)
 	UpdateAccount(ctx context.Context, id int64, input *AdminAccountInput) error
+	UpdateBalance(ctx context.Context, id int64, input *AdminBalanceInput) error
 	DeleteAccount(ctx context.Context, id int64) error
+	ListSessions(ctx context.Context, id int64) ([]SessionOutput, error)
+	RevokeSessions(ctx context.Context, id int64) error
+	Unlock(ctx context.Context, id int64) error
+	SetStatus(ctx context.Context, id int64, input *AccountStatusInput) error
+	ListSignInAttempts(ctx context.Context, username, ip string, count, start int) ([]SignInAttemptOutput, error)
+}
+
+type Access interface {
+	Principal(ctx context.Context, userID int64) (*Principal, error)
 }
 
-type TransportInput struct {
-	CanBeRented   bool    `json:"canBeRented"`
-	TransportType string  `json:"transportType"`
-	Model         string  `json:"model"`
-	Color         string  `json:"color"`
-	Identifier    string  `json:"identifier"`
-	Description   *string `json:"description"`
-	Latitude      float64 `json:"latitude"`
-	Longitude     float64 `json:"longitude"`
-	MinutePrice   float64 `json:"minutePrice"`
-	DayPrice      float64 `json:"dayPrice"`
-}
-
-type TransportOutput struct {
-	ID            int64     `json:"id"`
-	OwnerID       int64     `json:"ownerId"`
-	CanBeRented   bool      `json:"canBeRented"`
-	TransportType string    `json:"transportType"`
-	Model         string    `json:"model"`
-	Color         string    `json:"color"`
-	Identifier    string    `json:"identifier"`
-	Description   *string   `json:"description,omitempty"`
-	Latitude      float64   `json:"latitude"`
-	Longitude     float64   `json:"longitude"`
-	MinutePrice   float64   `json:"minutePrice"`
-	DayPrice      float64   `json:"dayPrice"`
-	CreatedAt     time.Time `json:"createdAt"`
-	UpdatedAt     time.Time `json:"updatedAt"`
+type AdminRole interface {
+	ListRoles(ctx context.Context) ([]RoleOutput, error)
+	ListAccountRoles(ctx context.Context, accountID int64) ([]string, error)
+	AssignRole(ctx context.Context, accountID int64, role string) error
+	UnassignRole(ctx context.Context, accountID int64, role string) error
 }
 
 type Transport interface {
@@ -91,55 +172,24 @@ type Transport interface {
 	ListTransportByOwner(ctx context.Context, ownerID int64, count, start int) ([]TransportOutput, error)
 	ListTransportByAvailability(ctx context.Context, lat, long, radius float64, transportType string) ([]TransportOutput, error)
 	UpdateTransport(ctx context.Context, userID, id int64, input *TransportInput) error
+	UpdatePosition(ctx context.Context, userID, id int64, input *PositionInput) error
 	DeleteTransport(ctx context.Context, userID, id int64) error
 }
 
-type AdminTransportInput struct {
-	OwnerID       int64   `json:"ownerId"`
-	CanBeRented   bool    `json:"canBeRented"`
-	TransportType string  `json:"transportType"`
-	Model         string  `json:"model"`
-	Color         string  `json:"color"`
-	Identifier    string  `json:"identifier"`
-	Description   *string `json:"description,omitempty"`
-	Latitude      float64 `json:"latitude"`
-	Longitude     float64 `json:"longitude"`
-	MinutePrice   float64 `json:"minutePrice"`
-	DayPrice      float64 `json:"dayPrice"`
-}
-
 type AdminTransport interface {
 	CreateTransport(ctx context.Context, input *AdminTransportInput) (int64, error)
+	GetTransport(ctx context.Context, id int64) (*TransportOutput, error)
+	ListTransport(ctx context.Context, transportType string, count, start int) ([]TransportOutput, error)
 	UpdateTransport(ctx context.Context, id int64, input *AdminTransportInput) error
 	DeleteTransport(ctx context.Context, id int64) error
 }
 
-type RentOutput struct {
-	ID           int64      `json:"id"`
-	TransportID  int64      `json:"transportId"`
-	UserID       int64      `json:"userId"`
-	TimeStart    time.Time  `json:"timeStart"`
-	TimeEnd      *time.Time `json:"timeEnd,omitempty"`
-	PriceOfUnit  int64      `json:"priceOfUnit"`
-	PriceType    string     `json:"priceType"`
-	FinalPrice   *int64     `json:"finalPrice,omitempty"`
-	LastBilledAt time.Time  `json:"lastBilledAt"`
-}
-
 type Rent interface {
 	StartRent(ctx context.Context, userID, transportID int64, rentType string) (int64, error)
 	EndRent(ctx context.Context, userID, id int64, lat, long float64) error
-	GetRent(ctx context.Context, id int64) (*RentOutput, error)
+	GetRent(ctx context.Context, userID, id int64) (*RentOutput, error)
 	ListRentsByAccount(ctx context.Context, accountID int64) ([]RentOutput, error)
-	ListRentsByTransport(ctx context.Context, transportID int64) ([]RentOutput, error)
-}
-
-type AdminRentInput struct {
-	TransportID int64     `json:"transportId"`
-	UserID      int64     `json:"userId"`
-	TimeStart   time.Time `json:"timeStart"`
-	PriceOfUnit float64   `json:"priceOfUnit"`
-	PriceType   string    `json:"priceType"`
+	ListRentsByTransport(ctx context.Context, userID, transportID int64) ([]RentOutput, error)
 }
 
 type AdminRent interface {
@@ -152,6 +202,10 @@ type AdminRent interface {
 	// Update? breaks logic
 }
 
+type AdminAudit interface {
+	ListAuditLog(ctx context.Context, input *AuditLogQuery, count, start int) ([]AuditEntryOutput, error)
+}
+
 type Payment interface {
 	UpdateBalance(ctx context.Context, accountID int64, amount float64) error
 	BillingWorker(ctx context.Context)
@@ -159,30 +213,78 @@ type Payment interface {
 }
 
 type ServicesDependencies struct {
-	Repos    *repository.Repositories
-	Hasher   hasher.PasswordHasher
-	SignKey  string
-	TokenTTL time.Duration
+	Repos      *repository.Repositories
+	Hasher     hasher.PasswordHasher
+	Validation ValidationRules
+	Lockout    LockoutPolicy
+	Mailer     mailer.Sender
+	// MailLinkBaseURL prefixes links in mails, e.g. the web app URL
+	MailLinkBaseURL      string
+	EmailVerificationTTL time.Duration
+	PasswordResetTTL     time.Duration
+	Keys                 *jwtkeys.KeySet
+	TokenTTL             time.Duration
+	RefreshTokenTTL      time.Duration
+	// TwoFactorIssuer names the service in authenticator apps
+	TwoFactorIssuer    string
+	SignInChallengeTTL time.Duration
+	// OIDC is the external sign in provider, nil disables it
+	OIDC         *oidc.Provider
+	OIDCStateTTL time.Duration
+	// Storage keeps uploaded license documents
+	Storage storage.Store
+	KYC     KYCPolicy
 }
 
 type Services struct {
 	Account        Account
 	AdminAccount   AdminAccount
+	Email          Email
+	OIDC           OIDC
+	Privacy        Privacy
+	Profile        Profile
+	APIKey         APIKey
+	Access         Access
+	AdminRole      AdminRole
 	Transport      Transport
 	AdminTransport AdminTransport
 	Rent           Rent
 	AdminRent      AdminRent
 	Payment        Payment
+	AdminAudit     AdminAudit
+	AdminLicense   AdminLicense
 }
 
 func NewServices(deps ServicesDependencies) *Services {
+	validator := NewValidator(deps.Validation)
+	guard := NewSignInGuard(deps.Repos.SignInFailure, deps.Repos.SignInAttempt, deps.Lockout)
+	auditor := NewAuditor(deps.Repos.AuditLog)
+	rider := NewRiderChecker(deps.Repos.License, deps.KYC)
+
+	accountService := NewAccountService(deps.Repos.Account, deps.Repos.Token, deps.Repos.RefreshToken, deps.Repos.Session, deps.Repos.TwoFactor, deps.Repos.SignInChallenge, deps.Hasher, validator, guard, deps.Keys, deps.TokenTTL, deps.RefreshTokenTTL, deps.TwoFactorIssuer, deps.SignInChallengeTTL)
+	oidcService := NewOIDCService(deps.Repos.Account, deps.Repos.ExternalIdentity, deps.Repos.OIDCLoginState, accountService, deps.Hasher, validator, deps.OIDC, deps.OIDCStateTTL)
+	transportService := NewTransportService(deps.Repos.Transport, validator)
+	rentService := NewRentService(deps.Repos.Account, deps.Repos.Transport, deps.Repos.Rent, validator, rider, auditor)
+	paymentService := NewPaymentService(deps.Repos.Account, deps.Repos.Payment, deps.Repos.Transport, deps.Repos.Rent, auditor)
+	profileService := NewProfileService(deps.Repos.Account, deps.Repos.License, deps.Storage, validator, deps.KYC)
+	privacyService := NewPrivacyService(deps.Repos.Account, deps.Repos.Session, deps.Repos.TwoFactor, deps.Repos.Rent, deps.Repos.AuditLog, accountService, rentService, transportService, oidcService, profileService, guard, auditor)
+
 	return &Services{
-		Account:        NewAccountService(deps.Repos.Account, deps.Repos.Token, deps.Hasher, deps.SignKey, deps.TokenTTL),
-		AdminAccount:   NewAdminAccountService(deps.Repos.Account, deps.Hasher),
-		Transport:      NewTransportService(deps.Repos.Transport),
-		AdminTransport: NewAdminTransportService(deps.Repos.Transport),
-		Rent:           NewRentService(deps.Repos.Account, deps.Repos.Payment, deps.Repos.Transport, deps.Repos.Rent),
-		AdminRent:      NewAdminRentService(deps.Repos.Account, deps.Repos.Payment, deps.Repos.Transport, deps.Repos.Rent),
-		Payment:        NewPaymentService(deps.Repos.Account, deps.Repos.Payment, deps.Repos.Transport, deps.Repos.Rent),
+		Account:        accountService,
+		AdminAccount:   NewAdminAccountService(deps.Repos.Account, deps.Repos.Session, deps.Hasher, validator, guard, auditor, privacyService, paymentService),
+		Email:          NewEmailService(deps.Repos.Account, deps.Repos.Token, deps.Repos.Session, deps.Repos.EmailToken, deps.Hasher, validator, guard, deps.Mailer, deps.MailLinkBaseURL, deps.EmailVerificationTTL, deps.PasswordResetTTL),
+		OIDC:           oidcService,
+		Privacy:        privacyService,
+		Profile:        profileService,
+		APIKey:         NewAPIKeyService(deps.Repos.APIKey, deps.Repos.Account, validator),
+		Access:         NewAccessService(deps.Repos.Account, deps.Repos.Role, deps.Repos.TwoFactor),
+		AdminRole:      NewAdminRoleService(deps.Repos.Account, deps.Repos.Role, auditor),
+		Transport:      transportService,
+		AdminTransport: NewAdminTransportService(deps.Repos.Transport, validator, auditor),
+		Rent:           rentService,
+		AdminRent:      NewAdminRentService(deps.Repos.Account, deps.Repos.Transport, deps.Repos.Rent, validator, rider, auditor),
+		Payment:        paymentService,
+		AdminAudit:     NewAdminAuditService(deps.Repos.AuditLog),
+		AdminLicense:   NewAdminLicenseService(deps.Repos.License, deps.Storage, validator, auditor),
 	}
 }
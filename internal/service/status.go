@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+)
+
+const (
+	AccountStatusActive    = "active"
+	AccountStatusSuspended = "suspended"
+	AccountStatusBanned    = "banned"
+)
+
+var accountStatuses = []string{AccountStatusActive, AccountStatusSuspended, AccountStatusBanned}
+
+// BlockedError refuses an account an admin suspended or banned. It matches
+// ErrAccountSuspended or ErrAccountBanned with errors.Is.
+type BlockedError struct {
+	Status string
+	Reason string
+	// Until is nil for a block without expiry
+	Until *time.Time
+}
+
+func (e *BlockedError) Error() string {
+	msg := e.Unwrap().Error()
+	if e.Until != nil {
+		msg += " until " + e.Until.UTC().Format(time.RFC3339)
+	}
+
+	if e.Reason == "" {
+		return msg
+	}
+
+	return fmt.Sprintf("%s: %s", msg, e.Reason)
+}
+
+func (e *BlockedError) Unwrap() error {
+	if e.Status == AccountStatusBanned {
+		return ErrAccountBanned
+	}
+
+	return ErrAccountSuspended
+}
+
+// accountStatus is the status in effect, a block ends on its own once its
+// expiry passed.
+func accountStatus(account *entity.Account) string {
+	if account.StatusUntil != nil && !time.Now().Before(*account.StatusUntil) {
+		return AccountStatusActive
+	}
+
+	return account.Status
+}
+
+// checkStatus refuses accounts that are blocked right now.
+func checkStatus(account *entity.Account) error {
+	status := accountStatus(account)
+	if status == AccountStatusActive {
+		return nil
+	}
+
+	blocked := &BlockedError{Status: status, Until: account.StatusUntil}
+	if account.StatusReason != nil {
+		blocked.Reason = *account.StatusReason
+	}
+
+	return blocked
+}
+
+func isAccountStatus(status string) bool {
+	for _, known := range accountStatuses {
+		if status == known {
+			return true
+		}
+	}
+
+	return false
+}
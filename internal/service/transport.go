@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"math"
 	"time"
 
 	"github.com/realdanielursul/simbir-go/internal/entity"
@@ -10,16 +11,20 @@ import (
 
 type TransportService struct {
 	transportRepo repository.Transport
+	validator     *Validator
 }
 
-func NewTransportService(transportRepo repository.Transport) *TransportService {
+func NewTransportService(transportRepo repository.Transport, validator *Validator) *TransportService {
 	return &TransportService{
 		transportRepo: transportRepo,
+		validator:     validator,
 	}
 }
 
 func (s *TransportService) CreateTransport(ctx context.Context, userID int64, input *TransportInput) (int64, error) {
-	// validate data
+	if err := s.validator.Transport(input); err != nil {
+		return -1, err
+	}
 
 	// check identifier uniqueness
 	transport, err := s.transportRepo.GetByIdentifier(ctx, input.Identifier)
@@ -80,6 +85,10 @@ func (s *TransportService) GetTransport(ctx context.Context, id int64) (*Transpo
 }
 
 func (s *TransportService) ListTransport(ctx context.Context, transportType string, count, start int) ([]TransportOutput, error) {
+	if err := s.validator.TransportTypeFilter(transportType); err != nil {
+		return nil, err
+	}
+
 	transports, err := s.transportRepo.ListByType(ctx, transportType, count, start)
 	if err != nil {
 		return nil, err
@@ -141,7 +150,13 @@ func (s *TransportService) ListTransportByOwner(ctx context.Context, ownerID int
 	return transportsOutput, nil
 }
 
+// ListTransportByAvailability searches transport that can be rented within
+// radius meters of the point, nearest first.
 func (s *TransportService) ListTransportByAvailability(ctx context.Context, lat, long, radius float64, transportType string) ([]TransportOutput, error) {
+	if err := s.validator.Search(lat, long, radius, transportType); err != nil {
+		return nil, err
+	}
+
 	transports, err := s.transportRepo.ListByAvailability(ctx, lat, long, radius, transportType)
 	if err != nil {
 		return nil, err
@@ -166,6 +181,10 @@ func (s *TransportService) ListTransportByAvailability(ctx context.Context, lat,
 			UpdatedAt:     transport.UpdatedAt,
 		}
 
+		// whole meters, the positions are not more precise anyway
+		distance := math.Round(transport.Distance)
+		transportOutput.Distance = &distance
+
 		transportsOutput = append(transportsOutput, transportOutput)
 	}
 
@@ -173,16 +192,17 @@ func (s *TransportService) ListTransportByAvailability(ctx context.Context, lat,
 }
 
 func (s *TransportService) UpdateTransport(ctx context.Context, userID, id int64, input *TransportInput) error {
-	// validate data
+	if err := s.validator.Transport(input); err != nil {
+		return err
+	}
 
-	// check identifier uniqueness
-	transport, err := s.transportRepo.GetByIdentifier(ctx, input.Identifier)
+	transport, err := s.transportRepo.GetByID(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	if transport != nil {
-		return ErrIdentifierAlreadyExists
+	if transport == nil {
+		return ErrTransportNotFound
 	}
 
 	// check if user is owner
@@ -190,19 +210,30 @@ func (s *TransportService) UpdateTransport(ctx context.Context, userID, id int64
 		return ErrAccessDenied
 	}
 
+	// check identifier uniqueness
+	existing, err := s.transportRepo.GetByIdentifier(ctx, input.Identifier)
+	if err != nil {
+		return err
+	}
+
+	if existing != nil && existing.ID != id {
+		return ErrIdentifierAlreadyExists
+	}
+
 	err = s.transportRepo.Update(ctx, &entity.Transport{
-		ID:          id,
-		OwnerID:     userID,
-		CanBeRented: input.CanBeRented,
-		Model:       input.Model,
-		Color:       input.Color,
-		Identifier:  input.Identifier,
-		Description: input.Description,
-		Latitude:    input.Latitude,
-		Longitude:   input.Longitude,
-		MinutePrice: int64(input.MinutePrice * 100),
-		DayPrice:    int64(input.DayPrice * 100),
-		UpdatedAt:   time.Now().UTC(),
+		ID:            id,
+		OwnerID:       userID,
+		CanBeRented:   input.CanBeRented,
+		TransportType: transport.TransportType,
+		Model:         input.Model,
+		Color:         input.Color,
+		Identifier:    input.Identifier,
+		Description:   input.Description,
+		Latitude:      input.Latitude,
+		Longitude:     input.Longitude,
+		MinutePrice:   int64(input.MinutePrice * 100),
+		DayPrice:      int64(input.DayPrice * 100),
+		UpdatedAt:     time.Now().UTC(),
 	})
 	if err != nil {
 		return err
@@ -211,6 +242,8 @@ func (s *TransportService) UpdateTransport(ctx context.Context, userID, id int64
 	return nil
 }
 
+// DeleteTransport withdraws own transport for good, the rents taken on it
+// are kept.
 func (s *TransportService) DeleteTransport(ctx context.Context, userID, id int64) error {
 	transport, err := s.transportRepo.GetByID(ctx, id)
 	if err != nil {
@@ -226,10 +259,38 @@ func (s *TransportService) DeleteTransport(ctx context.Context, userID, id int64
 		return ErrAccessDenied
 	}
 
-	err = s.transportRepo.Delete(ctx, id)
+	deleted, err := s.transportRepo.Delete(ctx, id)
 	if err != nil {
 		return err
 	}
 
+	if !deleted {
+		return ErrTransportRented
+	}
+
 	return nil
 }
+
+// UpdatePosition moves own transport, it is meant for telemetry pushed by
+// vehicles through an API key.
+func (s *TransportService) UpdatePosition(ctx context.Context, userID, id int64, input *PositionInput) error {
+	if err := s.validator.Position(input.Latitude, input.Longitude); err != nil {
+		return err
+	}
+
+	transport, err := s.transportRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if transport == nil {
+		return ErrTransportNotFound
+	}
+
+	// check if user is owner
+	if userID != transport.OwnerID {
+		return ErrAccessDenied
+	}
+
+	return s.transportRepo.UpdatePosition(ctx, id, input.Latitude, input.Longitude)
+}
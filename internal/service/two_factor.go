@@ -0,0 +1,359 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/realdanielursul/simbir-go/internal/entity"
+	"github.com/realdanielursul/simbir-go/pkg/totp"
+)
+
+const (
+	recoveryCodeCount = 10
+	// codes submitted for one sign in challenge before it is discarded
+	maxChallengeAttempts = 5
+	// time steps a code may be off by, to tolerate clock drift
+	totpSkew = 1
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// VerifySignIn completes a sign in challenged for a second factor.
+func (s *AccountService) VerifySignIn(ctx context.Context, input *TwoFactorSignInInput, client *ClientInfo) (*TokenOutput, error) {
+	challenge, err := s.challengeRepo.GetByHash(ctx, hashToken(input.ChallengeToken))
+	if err != nil {
+		return nil, err
+	}
+
+	if challenge == nil || challenge.UsedAt != nil || time.Now().After(challenge.ExpiresAt) {
+		return nil, ErrInvalidChallenge
+	}
+
+	ok, err := s.challengeRepo.Attempt(ctx, challenge.ID, maxChallengeAttempts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrInvalidChallenge
+	}
+
+	account, err := s.accountRepo.GetByID(ctx, challenge.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, challenge.AccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil || twoFactor.EnabledAt == nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	// a fresh challenge per sign in must not allow guessing more codes than
+	// the lockout does
+	if err := s.confirmCode(ctx, account, twoFactor, input.Code, true, client); err != nil {
+		return nil, err
+	}
+
+	used, err := s.challengeRepo.Use(ctx, challenge.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !used {
+		return nil, ErrInvalidChallenge
+	}
+
+	if err := checkStatus(account); err != nil {
+		return nil, err
+	}
+
+	if err := s.guard.Succeed(ctx, account.Username, client); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, account, client)
+}
+
+func (s *AccountService) TwoFactorStatus(ctx context.Context, userID int64) (*TwoFactorStatus, error) {
+	account, err := s.accountRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &TwoFactorStatus{
+		Enabled:  twoFactor != nil && twoFactor.EnabledAt != nil,
+		Required: account.IsAdmin || staffCaller(ctx),
+	}
+
+	if status.Enabled {
+		status.RecoveryCodesLeft, err = s.twoFactorRepo.CountRecoveryCodes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+// EnrollTwoFactor generates a new secret. It takes effect once a code of it
+// is confirmed with ConfirmTwoFactor.
+func (s *AccountService) EnrollTwoFactor(ctx context.Context, userID int64) (*TwoFactorEnrollment, error) {
+	account, err := s.accountRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor != nil && twoFactor.EnabledAt != nil {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.SetSecret(ctx, userID, secret); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorEnrollment{
+		Secret: secret,
+		URI:    totp.URI(s.twoFactorIssuer, account.Username, secret),
+	}, nil
+}
+
+func (s *AccountService) ConfirmTwoFactor(ctx context.Context, userID int64, code string) (*RecoveryCodesOutput, error) {
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	if twoFactor.EnabledAt != nil {
+		return nil, ErrTwoFactorEnabled
+	}
+
+	step, ok := totp.Validate(twoFactor.Secret, normalizeCode(code), time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.Enable(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesOutput{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code, used or not.
+func (s *AccountService) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string, client *ClientInfo) (*RecoveryCodesOutput, error) {
+	account, err := s.accountRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if twoFactor == nil || twoFactor.EnabledAt == nil {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	if err := s.confirmCode(ctx, account, twoFactor, code, false, client); err != nil {
+		return nil, err
+	}
+
+	if err := s.guard.Pass(ctx, account.Username, client); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesOutput{RecoveryCodes: codes}, nil
+}
+
+func (s *AccountService) DisableTwoFactor(ctx context.Context, userID int64, code string, client *ClientInfo) error {
+	account, err := s.accountRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if account == nil {
+		return ErrAccountNotFound
+	}
+
+	// staff must keep a second factor
+	if account.IsAdmin || staffCaller(ctx) {
+		return ErrTwoFactorRequired
+	}
+
+	twoFactor, err := s.twoFactorRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if twoFactor == nil || twoFactor.EnabledAt == nil {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if err := s.confirmCode(ctx, account, twoFactor, code, true, client); err != nil {
+		return err
+	}
+
+	if err := s.guard.Pass(ctx, account.Username, client); err != nil {
+		return err
+	}
+
+	return s.twoFactorRepo.Delete(ctx, userID)
+}
+
+// createChallenge starts the second step of a sign in.
+func (s *AccountService) createChallenge(ctx context.Context, accountID int64) (*SignInChallengeOutput, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.challengeRepo.Create(ctx, &entity.SignInChallenge{
+		AccountID: accountID,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.challengeTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignInChallengeOutput{
+		ChallengeToken: token,
+		ExpiresIn:      int64(s.challengeTTL.Seconds()),
+	}, nil
+}
+
+// confirmCode checks a second factor code of the account. It is guarded like
+// a password, a wrong code counts against the username and the IP address
+// and a right one is left to the caller to settle with the guard.
+func (s *AccountService) confirmCode(ctx context.Context, account *entity.Account, twoFactor *entity.TwoFactor, code string, allowRecovery bool, client *ClientInfo) error {
+	if err := s.guard.Check(ctx, account.Username, client); err != nil {
+		return err
+	}
+
+	err := s.verifyCode(ctx, twoFactor, code, allowRecovery)
+	if errors.Is(err, ErrInvalidTwoFactorCode) {
+		if err := s.guard.Fail(ctx, account.Username, &account.ID, client, SignInInvalidCode); err != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+// verifyCode accepts a TOTP code that was not used yet or, if allowed, an
+// unused recovery code.
+func (s *AccountService) verifyCode(ctx context.Context, twoFactor *entity.TwoFactor, code string, allowRecovery bool) error {
+	code = normalizeCode(code)
+
+	if step, ok := totp.Validate(twoFactor.Secret, code, time.Now(), totpSkew); ok {
+		fresh, err := s.twoFactorRepo.UseStep(ctx, twoFactor.AccountID, step)
+		if err != nil {
+			return err
+		}
+
+		if !fresh {
+			return ErrInvalidTwoFactorCode
+		}
+
+		return nil
+	}
+
+	if !allowRecovery {
+		return ErrInvalidTwoFactorCode
+	}
+
+	used, err := s.twoFactorRepo.UseRecoveryCode(ctx, twoFactor.AccountID, hashToken(code))
+	if err != nil {
+		return err
+	}
+
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+
+	return nil
+}
+
+// generateRecoveryCodes returns codes to show once and the hashes to store.
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(b))
+		codes = append(codes, code[:4]+"-"+code[4:])
+		hashes = append(hashes, hashToken(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeCode strips what users type around codes, recovery codes are
+// hashed without their dash.
+func normalizeCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, " ", "")
+	return strings.ReplaceAll(code, "-", "")
+}
@@ -0,0 +1,335 @@
+package service
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// dateLayout formats dates without a time, such as birth dates.
+const dateLayout = "2006-01-02"
+
+// phonePattern matches numbers in E.164 format.
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+type ValidationRules struct {
+	UsernameMinLength int
+	UsernameMaxLength int
+	PasswordMinLength int
+	PasswordMaxLength int
+	TextMaxLength     int
+	MaxPrice          float64
+	// MaxSearchRadius limits transport searches, in meters
+	MaxSearchRadius float64
+}
+
+type FieldError struct {
+	Field   string
+	Message string
+	Err     error
+}
+
+// ValidationError lists every invalid field of an input. It matches
+// ErrValidation and the errors of its fields with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		messages = append(messages, field.Field+": "+field.Message)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(messages, "; "))
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Fields)+1)
+	errs = append(errs, ErrValidation)
+	for _, field := range e.Fields {
+		errs = append(errs, field.Err)
+	}
+
+	return errs
+}
+
+type Validator struct {
+	rules ValidationRules
+}
+
+func NewValidator(rules ValidationRules) *Validator {
+	return &Validator{rules: rules}
+}
+
+func (v *Validator) Account(input *AccountInput) error {
+	var fields fieldErrors
+	v.checkUsername(&fields, input.Username)
+	v.checkPassword(&fields, input.Password)
+
+	return fields.err()
+}
+
+func (v *Validator) AdminAccount(input *AdminAccountInput) error {
+	var fields fieldErrors
+	v.checkUsername(&fields, input.Username)
+	v.checkPassword(&fields, input.Password)
+	fields.check(input.Balance >= 0, "balance", "must not be negative", ErrInvalidAmount)
+
+	return fields.err()
+}
+
+func (v *Validator) Username(username string) error {
+	var fields fieldErrors
+	v.checkUsername(&fields, username)
+
+	return fields.err()
+}
+
+func (v *Validator) Password(password string) error {
+	var fields fieldErrors
+	v.checkPassword(&fields, password)
+
+	return fields.err()
+}
+
+func (v *Validator) Email(email string) error {
+	var fields fieldErrors
+	if strings.TrimSpace(email) == "" {
+		fields.add("email", "is required", ErrRequiredField)
+		return fields.err()
+	}
+
+	// a bare address only, no display name or surrounding spaces
+	address, err := mail.ParseAddress(email)
+	fields.check(err == nil && address.Address == email && address.Name == "", "email", "must be an email address", ErrInvalidEmail)
+	fields.check(utf8.RuneCountInString(email) <= v.rules.TextMaxLength, "email",
+		fmt.Sprintf("must be at most %d characters long", v.rules.TextMaxLength), ErrInvalidEmail)
+
+	return fields.err()
+}
+
+func (v *Validator) Balance(balance float64) error {
+	var fields fieldErrors
+	fields.check(balance >= 0, "balance", "must not be negative", ErrInvalidAmount)
+
+	return fields.err()
+}
+
+func (v *Validator) APIKey(input *APIKeyInput) error {
+	var fields fieldErrors
+	v.checkText(&fields, "name", input.Name, true)
+	if len(input.Scopes) == 0 {
+		fields.add("scopes", "must not be empty", ErrRequiredField)
+	}
+
+	for _, scope := range input.Scopes {
+		if !isScope(scope) {
+			fields.add("scopes", fmt.Sprintf("must be one of %s", strings.Join(allScopes, ", ")), ErrInvalidScope)
+			break
+		}
+	}
+
+	if input.ExpiresAt != nil {
+		fields.check(input.ExpiresAt.After(time.Now()), "expiresAt", "must be in the future", ErrInvalidValue)
+	}
+
+	return fields.err()
+}
+
+func (v *Validator) AccountStatus(input *AccountStatusInput) error {
+	var fields fieldErrors
+	fields.check(isAccountStatus(input.Status), "status", fmt.Sprintf("must be one of %s", strings.Join(accountStatuses, ", ")), ErrInvalidAccountStatus)
+	// only blocks carry a reason and an expiry
+	if !isAccountStatus(input.Status) || input.Status == AccountStatusActive {
+		return fields.err()
+	}
+
+	v.checkText(&fields, "reason", input.Reason, true)
+	if input.Until != nil {
+		fields.check(input.Until.After(time.Now()), "until", "must be in the future", ErrInvalidValue)
+	}
+
+	return fields.err()
+}
+
+func (v *Validator) Profile(input *ProfileInput) error {
+	var fields fieldErrors
+	if input.FullName != nil {
+		v.checkText(&fields, "fullName", *input.FullName, true)
+	}
+
+	if input.Phone != nil {
+		fields.check(phonePattern.MatchString(*input.Phone), "phone", "must be in international format, e.g. +79001234567", ErrInvalidValue)
+	}
+
+	if input.BirthDate != nil {
+		birthDate, err := time.Parse(dateLayout, *input.BirthDate)
+		fields.check(err == nil && birthDate.Year() >= 1900 && birthDate.Before(time.Now()), "birthDate", "must be a past date formatted as YYYY-MM-DD", ErrInvalidValue)
+	}
+
+	return fields.err()
+}
+
+func (v *Validator) LicenseReject(input *LicenseRejectInput) error {
+	var fields fieldErrors
+	v.checkText(&fields, "reason", input.Reason, true)
+
+	return fields.err()
+}
+
+func (v *Validator) LicenseStatusFilter(status string) error {
+	var fields fieldErrors
+	fields.check(status == "" || isLicenseStatus(status), "status", fmt.Sprintf("must be one of %s", strings.Join(licenseStatuses, ", ")), ErrInvalidValue)
+
+	return fields.err()
+}
+
+func (v *Validator) Transport(input *TransportInput) error {
+	var fields fieldErrors
+	v.checkTransport(&fields, input.TransportType, input.Model, input.Color, input.Identifier, input.Description, input.Latitude, input.Longitude, input.MinutePrice, input.DayPrice)
+
+	return fields.err()
+}
+
+func (v *Validator) AdminTransport(input *AdminTransportInput) error {
+	var fields fieldErrors
+	fields.check(input.OwnerID > 0, "ownerId", "must be positive", ErrInvalidValue)
+	v.checkTransport(&fields, input.TransportType, input.Model, input.Color, input.Identifier, input.Description, input.Latitude, input.Longitude, input.MinutePrice, input.DayPrice)
+
+	return fields.err()
+}
+
+func (v *Validator) AdminRent(input *AdminRentInput) error {
+	var fields fieldErrors
+	fields.check(input.TransportID > 0, "transportId", "must be positive", ErrInvalidValue)
+	fields.check(input.UserID > 0, "userId", "must be positive", ErrInvalidValue)
+	checkRentType(&fields, "priceType", input.PriceType)
+
+	return fields.err()
+}
+
+func (v *Validator) RentType(rentType string) error {
+	var fields fieldErrors
+	checkRentType(&fields, "rentType", rentType)
+
+	return fields.err()
+}
+
+func (v *Validator) Position(lat, long float64) error {
+	var fields fieldErrors
+	checkPosition(&fields, lat, long)
+
+	return fields.err()
+}
+
+func (v *Validator) Search(lat, long, radius float64, transportType string) error {
+	var fields fieldErrors
+	checkPosition(&fields, lat, long)
+	fields.check(radius > 0 && radius <= v.rules.MaxSearchRadius, "radius",
+		fmt.Sprintf("must be more than 0 and at most %g meters", v.rules.MaxSearchRadius), ErrInvalidRadius)
+	checkTransportTypeFilter(&fields, "type", transportType)
+
+	return fields.err()
+}
+
+func (v *Validator) TransportTypeFilter(transportType string) error {
+	var fields fieldErrors
+	checkTransportTypeFilter(&fields, "transportType", transportType)
+
+	return fields.err()
+}
+
+func (v *Validator) checkUsername(fields *fieldErrors, username string) {
+	length := utf8.RuneCountInString(username)
+	if length < v.rules.UsernameMinLength || length > v.rules.UsernameMaxLength {
+		fields.add("username", fmt.Sprintf("must be %d to %d characters long", v.rules.UsernameMinLength, v.rules.UsernameMaxLength), ErrInvalidUsername)
+		return
+	}
+
+	for _, r := range username {
+		if !isUsernameRune(r) {
+			fields.add("username", "may contain only latin letters, digits, '_', '-' and '.'", ErrInvalidUsername)
+			return
+		}
+	}
+}
+
+func (v *Validator) checkPassword(fields *fieldErrors, password string) {
+	length := utf8.RuneCountInString(password)
+	fields.check(length >= v.rules.PasswordMinLength && length <= v.rules.PasswordMaxLength, "password",
+		fmt.Sprintf("must be %d to %d characters long", v.rules.PasswordMinLength, v.rules.PasswordMaxLength), ErrInvalidPassword)
+}
+
+func (v *Validator) checkTransport(fields *fieldErrors, transportType, model, color, identifier string, description *string, lat, long, minutePrice, dayPrice float64) {
+	fields.check(isTransportType(transportType), "transportType", "must be one of Car, Bike, Scooter", ErrInvalidTransportType)
+	v.checkText(fields, "model", model, true)
+	v.checkText(fields, "color", color, true)
+	v.checkText(fields, "identifier", identifier, true)
+	if description != nil {
+		v.checkText(fields, "description", *description, false)
+	}
+
+	checkPosition(fields, lat, long)
+	v.checkPrice(fields, "minutePrice", minutePrice)
+	v.checkPrice(fields, "dayPrice", dayPrice)
+}
+
+func (v *Validator) checkText(fields *fieldErrors, field, value string, required bool) {
+	if required && strings.TrimSpace(value) == "" {
+		fields.add(field, "is required", ErrRequiredField)
+		return
+	}
+
+	fields.check(utf8.RuneCountInString(value) <= v.rules.TextMaxLength, field,
+		fmt.Sprintf("must be at most %d characters long", v.rules.TextMaxLength), ErrInvalidValue)
+}
+
+func (v *Validator) checkPrice(fields *fieldErrors, field string, price float64) {
+	fields.check(price >= 0 && price <= v.rules.MaxPrice, field,
+		fmt.Sprintf("must be between 0 and %g", v.rules.MaxPrice), ErrInvalidPrice)
+}
+
+func checkPosition(fields *fieldErrors, lat, long float64) {
+	fields.check(lat >= -90 && lat <= 90, "latitude", "must be between -90 and 90", ErrInvalidCoordinates)
+	fields.check(long >= -180 && long <= 180, "longitude", "must be between -180 and 180", ErrInvalidCoordinates)
+}
+
+func checkRentType(fields *fieldErrors, field, rentType string) {
+	fields.check(rentType == "Minutes" || rentType == "Days", field, "must be one of Minutes, Days", ErrInvalidRentType)
+}
+
+func checkTransportTypeFilter(fields *fieldErrors, field, transportType string) {
+	fields.check(transportType == "All" || isTransportType(transportType), field, "must be one of All, Car, Bike, Scooter", ErrInvalidTransportType)
+}
+
+func isTransportType(transportType string) bool {
+	return transportType == "Car" || transportType == "Bike" || transportType == "Scooter"
+}
+
+func isUsernameRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.'
+}
+
+type fieldErrors []FieldError
+
+func (f *fieldErrors) add(field, message string, err error) {
+	*f = append(*f, FieldError{Field: field, Message: message, Err: err})
+}
+
+func (f *fieldErrors) check(ok bool, field, message string, err error) {
+	if !ok {
+		f.add(field, message, err)
+	}
+}
+
+func (f fieldErrors) err() error {
+	if len(f) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Fields: f}
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testValidationRules are the limits of config/local.yaml.
+var testValidationRules = ValidationRules{
+	UsernameMinLength: 3,
+	UsernameMaxLength: 32,
+	PasswordMinLength: 8,
+	PasswordMaxLength: 72,
+	TextMaxLength:     255,
+	MaxPrice:          1000000,
+	MaxSearchRadius:   50000,
+}
+
+// checkValidation fails the test unless err lists exactly the fields, each
+// matching ErrValidation and want with errors.Is.
+func checkValidation(t *testing.T, err error, want error, fields ...string) {
+	t.Helper()
+
+	if len(fields) == 0 {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		return
+	}
+
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("got %v, want a validation error for %v", err, fields)
+	}
+
+	if !errors.Is(err, ErrValidation) || (want != nil && !errors.Is(err, want)) {
+		t.Errorf("%v does not match %v and %v", err, ErrValidation, want)
+	}
+
+	got := make([]string, 0, len(validation.Fields))
+	for _, field := range validation.Fields {
+		got = append(got, field.Field)
+	}
+
+	if !slices.Equal(got, fields) {
+		t.Errorf("invalid fields %v, want %v", got, fields)
+	}
+}
+
+func TestValidateUsername(t *testing.T) {
+	validator := NewValidator(testValidationRules)
+
+	tests := []struct {
+		name     string
+		username string
+		valid    bool
+	}{
+		{name: "empty", username: ""},
+		{name: "too short", username: "ab"},
+		{name: "shortest", username: "abc", valid: true},
+		{name: "longest", username: strings.Repeat("a", 32), valid: true},
+		{name: "too long", username: strings.Repeat("a", 33)},
+		{name: "punctuation", username: "jane_doe-1.x", valid: true},
+		{name: "space", username: "jane doe"},
+		{name: "non-latin", username: "жанна"},
+		{name: "at sign", username: "jane@example"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Username(tt.username)
+			if tt.valid {
+				checkValidation(t, err, nil)
+			} else {
+				checkValidation(t, err, ErrInvalidUsername, "username")
+			}
+		})
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	validator := NewValidator(testValidationRules)
+
+	tests := []struct {
+		name     string
+		password string
+		valid    bool
+	}{
+		{name: "empty", password: ""},
+		{name: "too short", password: strings.Repeat("p", 7)},
+		{name: "shortest", password: strings.Repeat("p", 8), valid: true},
+		{name: "longest", password: strings.Repeat("p", 72), valid: true},
+		{name: "too long", password: strings.Repeat("p", 73)},
+		// the limits count characters, not bytes
+		{name: "multibyte shortest", password: strings.Repeat("ж", 8), valid: true},
+		{name: "multibyte longest", password: strings.Repeat("ж", 72), valid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Password(tt.password)
+			if tt.valid {
+				checkValidation(t, err, nil)
+			} else {
+				checkValidation(t, err, ErrInvalidPassword, "password")
+			}
+		})
+	}
+}
+
+func TestValidateEmail(t *testing.T) {
+	validator := NewValidator(testValidationRules)
+	long := strings.Repeat("a", 244) + "@example.com"
+
+	tests := []struct {
+		name  string
+		email string
+		want  error
+	}{
+		{name: "valid", email: "jane@example.com"},
+		{name: "longest", email: long[1:]},
+		{name: "too long", email: long, want: ErrInvalidEmail},
+		{name: "empty", email: "", want: ErrRequiredField},
+		{name: "blank", email: "  ", want: ErrRequiredField},
+		{name: "no domain", email: "jane", want: ErrInvalidEmail},
+		{name: "display name", email: "Jane <jane@example.com>", want: ErrInvalidEmail},
+		{name: "surrounding spaces", email: " jane@example.com ", want: ErrInvalidEmail},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validator.Email(tt.email)
+			if tt.want == nil {
+				checkValidation(t, err, nil)
+			} else {
+				checkValidation(t, err, tt.want, "email")
+			}
+		})
+	}
+}
+
+func validTransport() *TransportInput {
+	description := "blue hatchback"
+	return &TransportInput{
+		CanBeRented:   true,
+		TransportType: "Car",
+		Model:         "Lada Vesta",
+		Color:         "blue",
+		Identifier:    "A123BC73",
+		Description:   &description,
+		Latitude:      54.3142,
+		Longitude:     48.4031,
+		MinutePrice:   15,
+		DayPrice:      1500,
+	}
+}
+
+func TestValidateTransport(t *testing.T) {
+	validator := NewValidator(testValidationRules)
+
+	tests := []struct {
+		name   string
+		change func(input *TransportInput)
+		want   error
+		field  string
+	}{
+		{name: "valid", change: func(*TransportInput) {}},
+		{name: "unknown type", change: func(input *TransportInput) { input.TransportType = "Truck" }, want: ErrInvalidTransportType, field: "transportType"},
+		{name: "type filter", change: func(input *TransportInput) { input.TransportType = "All" }, want: ErrInvalidTransportType, field: "transportType"},
+		{name: "blank model", change: func(input *TransportInput) { input.Model = " " }, want: ErrRequiredField, field: "model"},
+		{name: "longest color", change: func(input *TransportInput) { input.Color = strings.Repeat("c", 255) }},
+		{name: "too long color", change: func(input *TransportInput) { input.Color = strings.Repeat("c", 256) }, want: ErrInvalidValue, field: "color"},
+		{name: "empty description", change: func(input *TransportInput) { input.Description = new(string) }},
+		{name: "no description", change: func(input *TransportInput) { input.Description = nil }},
+		{name: "too long description", change: func(input *TransportInput) { *input.Description = strings.Repeat("d", 256) }, want: ErrInvalidValue, field: "description"},
+		{name: "north pole", change: func(input *TransportInput) { input.Latitude = 90 }},
+		{name: "past the pole", change: func(input *TransportInput) { input.Latitude = 90.0001 }, want: ErrInvalidCoordinates, field: "latitude"},
+		{name: "antimeridian", change: func(input *TransportInput) { input.Longitude = -180 }},
+		{name: "past the antimeridian", change: func(input *TransportInput) { input.Longitude = 180.0001 }, want: ErrInvalidCoordinates, field: "longitude"},
+		{name: "free", change: func(input *TransportInput) { input.MinutePrice = 0 }},
+		{name: "negative price", change: func(input *TransportInput) { input.MinutePrice = -0.01 }, want: ErrInvalidPrice, field: "minutePrice"},
+		{name: "highest price", change: func(input *TransportInput) { input.DayPrice = 1000000 }},
+		{name: "too high price", change: func(input *TransportInput) { input.DayPrice = 1000000.01 }, want: ErrInvalidPrice, field: "dayPrice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := validTransport()
+			tt.change(input)
+
+			err := validator.Transport(input)
+			if tt.want == nil {
+				checkValidation(t, err, nil)
+			} else {
+				checkValidation(t, err, tt.want, tt.field)
+			}
+		})
+	}
+}
+
+func TestValidateSearch(t *testing.T) {
+	validator := NewValidator(testValidationRules)
+
+	tests := []struct {
+		name          string
+		radius        float64
+		transportType string
+		want          error
+		fields        []string
+	}{
+		{name: "valid", radius: 1000, transportType: "All"},
+		{name: "widest", radius: 50000, transportType: "Scooter"},
+		{name: "too wide", radius: 50000.1, transportType: "All", want: ErrInvalidRadius, fields: []string{"radius"}},
+		{name: "zero radius", radius: 0, transportType: "All", want: ErrInvalidRadius, fields: []string{"radius"}},
+		{name: "unknown type", radius: 1000, transportType: "Boat", want: ErrInvalidTransportType, fields: []string{"type"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidation(t, validator.Search(54.3, 48.4, tt.radius, tt.transportType), tt.want, tt.fields...)
+		})
+	}
+}
+
+func TestValidationRulesAreConfigurable(t *testing.T) {
+	validator := NewValidator(ValidationRules{
+		UsernameMinLength: 5,
+		UsernameMaxLength: 8,
+		PasswordMinLength: 12,
+		PasswordMaxLength: 16,
+		TextMaxLength:     10,
+		MaxPrice:          100,
+		MaxSearchRadius:   1000,
+	})
+
+	tests := []struct {
+		name   string
+		err    error
+		want   error
+		fields []string
+	}{
+		{name: "username below configured minimum", err: validator.Username("jane"), want: ErrInvalidUsername, fields: []string{"username"}},
+		{name: "username at configured minimum", err: validator.Username("janed")},
+		{name: "username above configured maximum", err: validator.Username("jane_doe1"), want: ErrInvalidUsername, fields: []string{"username"}},
+		{name: "password below configured minimum", err: validator.Password(strings.Repeat("p", 11)), want: ErrInvalidPassword, fields: []string{"password"}},
+		{name: "password at configured maximum", err: validator.Password(strings.Repeat("p", 16))},
+		{name: "email above configured text limit", err: validator.Email("jane@example.com"), want: ErrInvalidEmail, fields: []string{"email"}},
+		{name: "radius above configured maximum", err: validator.Search(0, 0, 1001, "All"), want: ErrInvalidRadius, fields: []string{"radius"}},
+		{name: "radius at configured maximum", err: validator.Search(0, 0, 1000, "All")},
+		{name: "price above configured maximum", err: validator.Transport(&TransportInput{
+			TransportType: "Bike", Model: "Stels", Color: "red", Identifier: "B1", MinutePrice: 100.5, DayPrice: 100,
+		}), want: ErrInvalidPrice, fields: []string{"minutePrice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkValidation(t, tt.err, tt.want, tt.fields...)
+		})
+	}
+}
+
+func TestValidationReportsEveryField(t *testing.T) {
+	validator := NewValidator(testValidationRules)
+
+	description := strings.Repeat("d", 256)
+	err := validator.AdminTransport(&AdminTransportInput{
+		OwnerID:       0,
+		TransportType: "Truck",
+		Model:         "",
+		Color:         "",
+		Identifier:    "",
+		Description:   &description,
+		Latitude:      91,
+		Longitude:     -181,
+		MinutePrice:   -1,
+		DayPrice:      2000000,
+	})
+
+	checkValidation(t, err, nil, "ownerId", "transportType", "model", "color", "identifier", "description", "latitude", "longitude", "minutePrice", "dayPrice")
+	for _, want := range []error{ErrInvalidValue, ErrInvalidTransportType, ErrRequiredField, ErrInvalidCoordinates, ErrInvalidPrice} {
+		if !errors.Is(err, want) {
+			t.Errorf("%v does not match %v", err, want)
+		}
+	}
+
+	checkValidation(t, validator.Account(&AccountInput{Username: "j", Password: "short"}), nil, "username", "password")
+}
@@ -0,0 +1,5 @@
+DROP TABLE refresh_tokens;
+
+DROP INDEX IF EXISTS tokens_family_id_idx;
+
+ALTER TABLE tokens DROP COLUMN family_id;
@@ -0,0 +1,17 @@
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN session_id,
+    ADD COLUMN family_id TEXT NOT NULL;
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+ALTER TABLE tokens
+    DROP COLUMN id,
+    DROP COLUMN session_id,
+    DROP COLUMN created_at,
+    ADD COLUMN family_id TEXT;
+
+CREATE INDEX IF NOT EXISTS tokens_family_id_idx ON tokens (family_id);
+
+DROP TABLE sessions;
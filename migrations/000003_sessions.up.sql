@@ -0,0 +1,28 @@
+CREATE TABLE IF NOT EXISTS sessions (
+    id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS sessions_user_id_idx ON sessions (user_id);
+
+ALTER TABLE tokens
+    ADD COLUMN id BIGSERIAL PRIMARY KEY,
+    ADD COLUMN session_id BIGINT REFERENCES sessions(id) ON DELETE CASCADE,
+    ADD COLUMN created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    DROP COLUMN family_id;
+
+CREATE INDEX IF NOT EXISTS tokens_session_id_idx ON tokens (session_id);
+
+-- refresh tokens issued before sessions cannot be attributed to a device
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN session_id BIGINT NOT NULL REFERENCES sessions(id) ON DELETE CASCADE,
+    DROP COLUMN family_id;
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_session_id_idx ON refresh_tokens (session_id);
@@ -0,0 +1,7 @@
+DROP TABLE account_roles;
+
+DROP TABLE role_permissions;
+
+DROP TABLE roles;
+
+DROP TABLE permissions;
@@ -0,0 +1,56 @@
+CREATE TABLE IF NOT EXISTS permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS account_roles (
+    account_id BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (account_id, role)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('accounts:read', 'View accounts and their sessions'),
+    ('accounts:write', 'Create, update and delete accounts, revoke sessions'),
+    ('accounts:balance', 'Change account balances'),
+    ('transports:read', 'View any transport'),
+    ('transports:write', 'Create, update and delete any transport'),
+    ('rents:read', 'View any rent and rent history'),
+    ('rents:write', 'Start, end and delete any rent'),
+    ('roles:manage', 'Assign roles and grant admin rights')
+ON CONFLICT (name) DO NOTHING;
+
+-- accounts with is_admin keep every permission
+INSERT INTO roles (name, description) VALUES
+    ('support', 'Read-only access to accounts, transport and rents'),
+    ('fleet_manager', 'Manages transport'),
+    ('finance', 'Balance operations'),
+    ('superadmin', 'Every permission')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('support', 'accounts:read'),
+    ('support', 'transports:read'),
+    ('support', 'rents:read'),
+    ('fleet_manager', 'transports:read'),
+    ('fleet_manager', 'transports:write'),
+    ('finance', 'accounts:read'),
+    ('finance', 'accounts:balance'),
+    ('finance', 'rents:read')
+ON CONFLICT DO NOTHING;
+
+INSERT INTO role_permissions (role, permission)
+SELECT 'superadmin', name FROM permissions
+ON CONFLICT DO NOTHING;
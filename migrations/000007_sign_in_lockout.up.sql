@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS sign_in_failures (
+    key TEXT PRIMARY KEY,
+    failures INT NOT NULL,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS sign_in_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    username TEXT NOT NULL,
+    account_id BIGINT REFERENCES accounts(id) ON DELETE SET NULL,
+    ip TEXT NOT NULL,
+    user_agent TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS sign_in_attempts_username_idx ON sign_in_attempts (username, created_at DESC);
+CREATE INDEX IF NOT EXISTS sign_in_attempts_ip_idx ON sign_in_attempts (ip, created_at DESC);
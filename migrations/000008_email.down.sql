@@ -0,0 +1,5 @@
+DROP TABLE email_tokens;
+
+DROP INDEX IF EXISTS accounts_email_idx;
+ALTER TABLE accounts DROP COLUMN email_verified_at;
+ALTER TABLE accounts DROP COLUMN email;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS external_identities (
+    id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS external_identities_account_id_idx ON external_identities (account_id);
+
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+    id BIGSERIAL PRIMARY KEY,
+    state_hash TEXT NOT NULL UNIQUE,
+    code_verifier TEXT NOT NULL,
+    nonce TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
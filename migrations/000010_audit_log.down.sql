@@ -0,0 +1,4 @@
+DELETE FROM permissions WHERE name = 'audit:read';
+
+DROP TABLE audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
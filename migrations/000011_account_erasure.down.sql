@@ -0,0 +1,7 @@
+ALTER TABLE transports DROP CONSTRAINT IF EXISTS transports_owner_id_fkey;
+ALTER TABLE transports ADD CONSTRAINT transports_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES accounts(id) ON DELETE CASCADE;
+
+ALTER TABLE rents DROP CONSTRAINT IF EXISTS rents_user_id_fkey;
+ALTER TABLE rents ADD CONSTRAINT rents_user_id_fkey FOREIGN KEY (user_id) REFERENCES accounts(id) ON DELETE CASCADE;
+
+ALTER TABLE accounts DROP COLUMN erased_at;
@@ -0,0 +1,9 @@
+ALTER TABLE accounts ADD COLUMN erased_at TIMESTAMPTZ;
+
+-- rents are financial records that must outlive the rider and the owner, so
+-- accounts are anonymized instead of deleted and may no longer take them along
+ALTER TABLE rents DROP CONSTRAINT IF EXISTS rents_user_id_fkey;
+ALTER TABLE rents ADD CONSTRAINT rents_user_id_fkey FOREIGN KEY (user_id) REFERENCES accounts(id) ON DELETE RESTRICT;
+
+ALTER TABLE transports DROP CONSTRAINT IF EXISTS transports_owner_id_fkey;
+ALTER TABLE transports ADD CONSTRAINT transports_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES accounts(id) ON DELETE RESTRICT;
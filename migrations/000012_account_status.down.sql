@@ -0,0 +1,5 @@
+ALTER TABLE accounts DROP COLUMN status_set_at;
+ALTER TABLE accounts DROP COLUMN status_set_by;
+ALTER TABLE accounts DROP COLUMN status_until;
+ALTER TABLE accounts DROP COLUMN status_reason;
+ALTER TABLE accounts DROP COLUMN status;
@@ -0,0 +1,5 @@
+ALTER TABLE accounts ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended', 'banned'));
+ALTER TABLE accounts ADD COLUMN status_reason TEXT;
+ALTER TABLE accounts ADD COLUMN status_until TIMESTAMPTZ;
+ALTER TABLE accounts ADD COLUMN status_set_by BIGINT REFERENCES accounts(id) ON DELETE SET NULL;
+ALTER TABLE accounts ADD COLUMN status_set_at TIMESTAMPTZ;
@@ -0,0 +1,7 @@
+DELETE FROM permissions WHERE name = 'kyc:review';
+
+DROP TABLE license_documents;
+
+ALTER TABLE accounts DROP COLUMN birth_date;
+ALTER TABLE accounts DROP COLUMN phone;
+ALTER TABLE accounts DROP COLUMN full_name;
@@ -0,0 +1,31 @@
+ALTER TABLE accounts ADD COLUMN full_name TEXT;
+ALTER TABLE accounts ADD COLUMN phone TEXT;
+ALTER TABLE accounts ADD COLUMN birth_date DATE;
+
+-- the files themselves live in the file storage under storage_key
+CREATE TABLE IF NOT EXISTS license_documents (
+    id BIGSERIAL PRIMARY KEY,
+    account_id BIGINT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL UNIQUE,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    reject_reason TEXT,
+    reviewed_by BIGINT REFERENCES accounts(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS license_documents_account_id_idx ON license_documents (account_id, created_at);
+CREATE INDEX IF NOT EXISTS license_documents_status_idx ON license_documents (status, created_at);
+
+-- an account waits for one review at a time
+CREATE UNIQUE INDEX IF NOT EXISTS license_documents_pending_idx ON license_documents (account_id) WHERE status = 'pending';
+
+INSERT INTO permissions (name, description) VALUES
+    ('kyc:review', 'Review driver license documents')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('superadmin', 'kyc:review')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS transports_available_type_position_idx;
+DROP INDEX IF EXISTS transports_available_position_idx;
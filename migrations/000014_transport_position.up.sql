@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS transports_available_position_idx ON transports (latitude, longitude) WHERE can_be_rented = TRUE;
+CREATE INDEX IF NOT EXISTS transports_available_type_position_idx ON transports (transport_type, latitude, longitude) WHERE can_be_rented = TRUE;
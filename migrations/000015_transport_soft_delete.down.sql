@@ -0,0 +1,22 @@
+-- deleted transport cannot be brought back or removed without losing the
+-- rents kept on it, so it has to be dealt with by hand first
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM transports WHERE deleted_at IS NOT NULL) THEN
+        RAISE EXCEPTION 'transports has deleted rows, resolve them before migrating down';
+    END IF;
+END;
+$$;
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP INDEX IF EXISTS transports_identifier_idx;
+ALTER TABLE transports ADD CONSTRAINT transports_identifier_key UNIQUE (identifier);
+ALTER TABLE transports DROP COLUMN deleted_at;
+
+ALTER TABLE rents DROP CONSTRAINT IF EXISTS rents_transport_id_fkey;
+ALTER TABLE rents ADD CONSTRAINT rents_transport_id_fkey FOREIGN KEY (transport_id) REFERENCES transports(id) ON DELETE CASCADE;
@@ -0,0 +1,22 @@
+-- rents are kept for accounting, so deleted transport is only marked so and
+-- its identifier may be used again
+ALTER TABLE rents DROP CONSTRAINT IF EXISTS rents_transport_id_fkey;
+ALTER TABLE rents ADD CONSTRAINT rents_transport_id_fkey FOREIGN KEY (transport_id) REFERENCES transports(id) ON DELETE RESTRICT;
+
+ALTER TABLE transports ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE transports DROP CONSTRAINT IF EXISTS transports_identifier_key;
+CREATE UNIQUE INDEX IF NOT EXISTS transports_identifier_idx ON transports (identifier) WHERE deleted_at IS NULL;
+
+-- erasure redacts personal data from the audit log within its transaction,
+-- entries stay append-only otherwise
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND current_setting('audit_log.redact', TRUE) = 'on'
+        AND (NEW.id, NEW.actor_id, NEW.actor_type, NEW.action, NEW.target_type, NEW.target_id, NEW.created_at)
+            IS NOT DISTINCT FROM (OLD.id, OLD.actor_id, OLD.actor_type, OLD.action, OLD.target_type, OLD.target_id, OLD.created_at) THEN
+        RETURN NEW;
+    END IF;
+
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
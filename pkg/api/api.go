@@ -0,0 +1,339 @@
+// Package api defines the request and response bodies of the HTTP API. The
+// server and pkg/client share it, so it must not import either of them.
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type AccountInput struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type AccountOutput struct {
+	ID            int64     `json:"id"`
+	Username      string    `json:"username"`
+	Email         *string   `json:"email,omitempty"`
+	EmailVerified bool      `json:"emailVerified"`
+	Balance       float64   `json:"balance"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type TokenOutput struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+	ExpiresIn    int64  `json:"expiresIn"`
+}
+
+// SignInOutput holds the tokens or, for accounts with two-factor
+// authentication, a challenge to complete with a code.
+type SignInOutput struct {
+	Token        string                 `json:"token,omitempty"`
+	RefreshToken string                 `json:"refreshToken,omitempty"`
+	ExpiresIn    int64                  `json:"expiresIn,omitempty"`
+	Challenge    *SignInChallengeOutput `json:"challenge,omitempty"`
+}
+
+type SignInChallengeOutput struct {
+	ChallengeToken string `json:"challengeToken"`
+	ExpiresIn      int64  `json:"expiresIn"`
+}
+
+type TwoFactorSignInInput struct {
+	ChallengeToken string `json:"challengeToken"`
+	// Code is a TOTP code or an unused recovery code
+	Code string `json:"code"`
+}
+
+type TwoFactorCodeInput struct {
+	Code string `json:"code"`
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"`
+	RecoveryCodesLeft int  `json:"recoveryCodesLeft"`
+}
+
+type TwoFactorEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type RecoveryCodesOutput struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type RefreshInput struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type SessionOutput struct {
+	ID         int64      `json:"id"`
+	UserAgent  string     `json:"userAgent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt time.Time  `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	Current    bool       `json:"current"`
+}
+
+type APIKeyInput struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+type APIKeyOutput struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	// Key is only returned once, when the key is created
+	Key string `json:"key,omitempty"`
+}
+
+type EmailInput struct {
+	Email string `json:"email"`
+}
+
+type EmailTokenInput struct {
+	Token string `json:"token"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type OIDCLoginOutput struct {
+	AuthorizationURL string `json:"authorizationUrl"`
+}
+
+type ExternalIdentityOutput struct {
+	ID          int64     `json:"id"`
+	Issuer      string    `json:"issuer"`
+	Subject     string    `json:"subject"`
+	Email       *string   `json:"email,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	LastLoginAt time.Time `json:"lastLoginAt"`
+}
+
+// ProfileInput replaces the profile, nil fields are cleared.
+type ProfileInput struct {
+	FullName *string `json:"fullName"`
+	Phone    *string `json:"phone"`
+	// BirthDate is formatted as YYYY-MM-DD
+	BirthDate *string `json:"birthDate"`
+}
+
+type ProfileOutput struct {
+	FullName  *string `json:"fullName,omitempty"`
+	Phone     *string `json:"phone,omitempty"`
+	BirthDate *string `json:"birthDate,omitempty"`
+	// LicenseStatus is approved once any license was, otherwise the status
+	// of the last upload or none
+	LicenseStatus string `json:"licenseStatus"`
+}
+
+type LicenseOutput struct {
+	ID           int64      `json:"id"`
+	AccountID    int64      `json:"accountId"`
+	ContentType  string     `json:"contentType"`
+	Size         int64      `json:"size"`
+	Status       string     `json:"status"`
+	RejectReason *string    `json:"rejectReason,omitempty"`
+	ReviewedBy   *int64     `json:"reviewedBy,omitempty"`
+	ReviewedAt   *time.Time `json:"reviewedAt,omitempty"`
+	CreatedAt    time.Time  `json:"createdAt"`
+}
+
+type LicenseRejectInput struct {
+	Reason string `json:"reason"`
+}
+
+type EraseAccountInput struct {
+	Password string `json:"password"`
+	// Code is required if two-factor authentication is enabled
+	Code string `json:"code"`
+}
+
+// PaymentOutput is a change of the balance, Amount is negative for charges.
+type PaymentOutput struct {
+	Type      string    `json:"type"`
+	Amount    float64   `json:"amount"`
+	Balance   float64   `json:"balance"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AccountExport holds the metadata of uploaded license documents, the files
+// themselves are not exported.
+type AccountExport struct {
+	Account    AccountOutput            `json:"account"`
+	Profile    ProfileOutput            `json:"profile"`
+	Licenses   []LicenseOutput          `json:"licenses"`
+	Rents      []RentOutput             `json:"rents"`
+	Payments   []PaymentOutput          `json:"payments"`
+	Sessions   []SessionOutput          `json:"sessions"`
+	Transports []TransportOutput        `json:"transports"`
+	Identities []ExternalIdentityOutput `json:"identities"`
+	ExportedAt time.Time                `json:"exportedAt"`
+}
+
+type AdminAccountInput struct {
+	Username string  `json:"username"`
+	Password string  `json:"password"`
+	IsAdmin  bool    `json:"isAdmin"`
+	Balance  float64 `json:"balance"`
+}
+
+type AdminAccountOutput struct {
+	ID            int64      `json:"id"`
+	Username      string     `json:"username"`
+	Email         *string    `json:"email,omitempty"`
+	EmailVerified bool       `json:"emailVerified"`
+	IsAdmin       bool       `json:"isAdmin"`
+	Balance       float64    `json:"balance"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	ErasedAt      *time.Time `json:"erasedAt,omitempty"`
+	Status        string     `json:"status"`
+	StatusReason  *string    `json:"statusReason,omitempty"`
+	StatusUntil   *time.Time `json:"statusUntil,omitempty"`
+	StatusSetBy   *int64     `json:"statusSetBy,omitempty"`
+	StatusSetAt   *time.Time `json:"statusSetAt,omitempty"`
+	FullName      *string    `json:"fullName,omitempty"`
+	Phone         *string    `json:"phone,omitempty"`
+	BirthDate     *string    `json:"birthDate,omitempty"`
+}
+
+// AccountStatusInput blocks an account with a reason, until a time or for
+// good, or makes it active again.
+type AccountStatusInput struct {
+	Status string     `json:"status"`
+	Reason string     `json:"reason"`
+	Until  *time.Time `json:"until"`
+}
+
+type AdminBalanceInput struct {
+	Balance float64 `json:"balance"`
+}
+
+type SignInAttemptOutput struct {
+	ID        int64     `json:"id"`
+	Username  string    `json:"username"`
+	AccountID *int64    `json:"accountId,omitempty"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type RoleOutput struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleInput struct {
+	Role string `json:"role"`
+}
+
+type TransportInput struct {
+	CanBeRented   bool    `json:"canBeRented"`
+	TransportType string  `json:"transportType"`
+	Model         string  `json:"model"`
+	Color         string  `json:"color"`
+	Identifier    string  `json:"identifier"`
+	Description   *string `json:"description"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	MinutePrice   float64 `json:"minutePrice"`
+	DayPrice      float64 `json:"dayPrice"`
+}
+
+type PositionInput struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type TransportOutput struct {
+	ID            int64     `json:"id"`
+	OwnerID       int64     `json:"ownerId"`
+	CanBeRented   bool      `json:"canBeRented"`
+	TransportType string    `json:"transportType"`
+	Model         string    `json:"model"`
+	Color         string    `json:"color"`
+	Identifier    string    `json:"identifier"`
+	Description   *string   `json:"description,omitempty"`
+	Latitude      float64   `json:"latitude"`
+	Longitude     float64   `json:"longitude"`
+	MinutePrice   float64   `json:"minutePrice"`
+	DayPrice      float64   `json:"dayPrice"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+	// Distance from the searched point in meters, only set by searches
+	Distance *float64 `json:"distance,omitempty"`
+}
+
+type AdminTransportInput struct {
+	OwnerID       int64   `json:"ownerId"`
+	CanBeRented   bool    `json:"canBeRented"`
+	TransportType string  `json:"transportType"`
+	Model         string  `json:"model"`
+	Color         string  `json:"color"`
+	Identifier    string  `json:"identifier"`
+	Description   *string `json:"description,omitempty"`
+	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude"`
+	MinutePrice   float64 `json:"minutePrice"`
+	DayPrice      float64 `json:"dayPrice"`
+}
+
+type RentOutput struct {
+	ID           int64      `json:"id"`
+	TransportID  int64      `json:"transportId"`
+	UserID       int64      `json:"userId"`
+	TimeStart    time.Time  `json:"timeStart"`
+	TimeEnd      *time.Time `json:"timeEnd,omitempty"`
+	PriceOfUnit  int64      `json:"priceOfUnit"`
+	PriceType    string     `json:"priceType"`
+	FinalPrice   *int64     `json:"finalPrice,omitempty"`
+	LastBilledAt time.Time  `json:"lastBilledAt"`
+}
+
+type AdminRentInput struct {
+	TransportID int64     `json:"transportId"`
+	UserID      int64     `json:"userId"`
+	TimeStart   time.Time `json:"timeStart"`
+	PriceOfUnit float64   `json:"priceOfUnit"`
+	PriceType   string    `json:"priceType"`
+}
+
+type AuditLogQuery struct {
+	ActorID    *int64
+	Action     string
+	TargetType string
+	TargetID   *int64
+	From       *time.Time
+	To         *time.Time
+}
+
+type AuditEntryOutput struct {
+	ID         int64           `json:"id"`
+	ActorID    *int64          `json:"actorId"`
+	ActorType  string          `json:"actorType"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"targetType"`
+	TargetID   *int64          `json:"targetId"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	IP         string          `json:"ip"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
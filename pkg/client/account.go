@@ -0,0 +1,330 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+func (c *Client) SignUp(ctx context.Context, input *AccountInput) (int64, error) {
+	var resp idResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Account/SignUp", body: input}, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.ID, nil
+}
+
+// SignIn stores the issued tokens. Accounts with two-factor authentication
+// get a *ChallengeError to complete with VerifySignIn instead.
+func (c *Client) SignIn(ctx context.Context, input *AccountInput) (string, error) {
+	var resp SignInOutput
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Account/SignIn", body: input}, &resp); err != nil {
+		return "", err
+	}
+
+	if resp.Challenge != nil {
+		return "", &ChallengeError{Challenge: *resp.Challenge}
+	}
+
+	err := c.storeTokens(ctx, &TokenOutput{
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Token, nil
+}
+
+// VerifySignIn completes a sign in challenge with a TOTP or recovery code and
+// stores the issued tokens.
+func (c *Client) VerifySignIn(ctx context.Context, challengeToken, code string) (string, error) {
+	var resp TokenOutput
+	input := TwoFactorSignInInput{ChallengeToken: challengeToken, Code: code}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Account/SignIn/TwoFactor", body: input}, &resp); err != nil {
+		return "", err
+	}
+
+	if err := c.storeTokens(ctx, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.Token, nil
+}
+
+// OIDCLoginURL returns the provider URL to send the user to. The provider
+// redirects back with the code and state to pass to CompleteOIDCLogin.
+func (c *Client) OIDCLoginURL(ctx context.Context) (string, error) {
+	var resp OIDCLoginOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/OIDC/Login"}, &resp); err != nil {
+		return "", err
+	}
+
+	return resp.AuthorizationURL, nil
+}
+
+// CompleteOIDCLogin stores the issued tokens like SignIn, including the
+// *ChallengeError for accounts with two-factor authentication.
+func (c *Client) CompleteOIDCLogin(ctx context.Context, code, state string) (string, error) {
+	var resp SignInOutput
+	query := url.Values{"code": {code}, "state": {state}}
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/OIDC/Callback", query: query}, &resp); err != nil {
+		return "", err
+	}
+
+	if resp.Challenge != nil {
+		return "", &ChallengeError{Challenge: *resp.Challenge}
+	}
+
+	err := c.storeTokens(ctx, &TokenOutput{
+		Token:        resp.Token,
+		RefreshToken: resp.RefreshToken,
+		ExpiresIn:    resp.ExpiresIn,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Token, nil
+}
+
+func (c *Client) ExternalIdentities(ctx context.Context) ([]ExternalIdentityOutput, error) {
+	var identities []ExternalIdentityOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/Identities", auth: true}, &identities); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+// Refresh exchanges the stored refresh token for a new pair of tokens.
+func (c *Client) Refresh(ctx context.Context) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	return c.refreshTokens(ctx)
+}
+
+func (c *Client) refreshTokens(ctx context.Context) error {
+	refreshToken, err := c.tokens.RefreshToken(ctx)
+	if err != nil {
+		return fmt.Errorf("get refresh token: %w", err)
+	}
+
+	if refreshToken == "" {
+		return ErrNotSignedIn
+	}
+
+	var resp TokenOutput
+	if err := c.send(ctx, request{method: http.MethodPost, path: "/api/Account/Refresh", body: RefreshInput{RefreshToken: refreshToken}}, &resp); err != nil {
+		return err
+	}
+
+	return c.storeTokens(ctx, &resp)
+}
+
+func (c *Client) SignOut(ctx context.Context) error {
+	if err := c.send(ctx, request{method: http.MethodPost, path: "/api/Account/SignOut", auth: true}, nil); err != nil {
+		return err
+	}
+
+	return c.storeTokens(ctx, &TokenOutput{})
+}
+
+// ExportAccount returns the data kept about the account.
+func (c *Client) ExportAccount(ctx context.Context) (*AccountExport, error) {
+	var export AccountExport
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/Export", auth: true}, &export); err != nil {
+		return nil, err
+	}
+
+	return &export, nil
+}
+
+// ExportAccountArchive returns the export as a ZIP archive of JSON files.
+func (c *Client) ExportAccountArchive(ctx context.Context) ([]byte, error) {
+	var archive []byte
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/Export", query: url.Values{"format": {"zip"}}, auth: true}, &archive); err != nil {
+		return nil, err
+	}
+
+	return archive, nil
+}
+
+// EraseAccount anonymizes the account for good and forgets its tokens, code
+// is only needed with two-factor authentication.
+func (c *Client) EraseAccount(ctx context.Context, password, code string) error {
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Account/Erase", body: EraseAccountInput{Password: password, Code: code}, auth: true}, nil); err != nil {
+		return err
+	}
+
+	return c.storeTokens(ctx, &TokenOutput{})
+}
+
+func (c *Client) Profile(ctx context.Context) (*ProfileOutput, error) {
+	var profile ProfileOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/Profile", auth: true}, &profile); err != nil {
+		return nil, err
+	}
+
+	return &profile, nil
+}
+
+// UpdateProfile replaces the profile, nil fields are cleared.
+func (c *Client) UpdateProfile(ctx context.Context, input *ProfileInput) error {
+	return c.do(ctx, request{method: http.MethodPut, path: "/api/Account/Profile", body: input, auth: true}, nil)
+}
+
+// License returns the driver license uploaded last with its review status.
+func (c *Client) License(ctx context.Context) (*LicenseOutput, error) {
+	var license LicenseOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/License", auth: true}, &license); err != nil {
+		return nil, err
+	}
+
+	return &license, nil
+}
+
+// UploadLicense sends a JPEG, PNG or PDF of the driver license for review.
+func (c *Client) UploadLicense(ctx context.Context, filename string, content []byte) (*LicenseOutput, error) {
+	var license LicenseOutput
+	file := &formFile{field: "file", name: filename, data: content}
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Account/License", file: file, auth: true}, &license); err != nil {
+		return nil, err
+	}
+
+	return &license, nil
+}
+
+func (c *Client) Me(ctx context.Context) (*AccountOutput, error) {
+	var account AccountOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/Me", auth: true}, &account); err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// UpdateAccount invalidates all tokens of the account, sign in again with the
+// updated credentials afterwards.
+func (c *Client) UpdateAccount(ctx context.Context, input *AccountInput) error {
+	return c.do(ctx, request{method: http.MethodPut, path: "/api/Account/Update", body: input, auth: true}, nil)
+}
+
+func (c *Client) Sessions(ctx context.Context) ([]SessionOutput, error) {
+	var sessions []SessionOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/Sessions", auth: true}, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+func (c *Client) RevokeSession(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/api/Account/Sessions/%d", id), auth: true}, nil)
+}
+
+// SetEmail changes the email of the account, it has to be verified again
+// with the link mailed to it.
+func (c *Client) SetEmail(ctx context.Context, email string) error {
+	return c.do(ctx, request{method: http.MethodPut, path: "/api/Account/Email", body: EmailInput{Email: email}, auth: true}, nil)
+}
+
+func (c *Client) ResendEmailVerification(ctx context.Context) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/api/Account/Email/Resend", auth: true}, nil)
+}
+
+func (c *Client) VerifyEmail(ctx context.Context, token string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/api/Account/Email/Verify", body: EmailTokenInput{Token: token}}, nil)
+}
+
+// ForgotPassword asks for a reset link. It succeeds whether or not an account
+// has the email verified.
+func (c *Client) ForgotPassword(ctx context.Context, email string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/api/Account/ForgotPassword", body: EmailInput{Email: email}}, nil)
+}
+
+func (c *Client) ResetPassword(ctx context.Context, input *ResetPasswordInput) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/api/Account/ResetPassword", body: input}, nil)
+}
+
+func (c *Client) TwoFactorStatus(ctx context.Context) (*TwoFactorStatus, error) {
+	var status TwoFactorStatus
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/TwoFactor", auth: true}, &status); err != nil {
+		return nil, err
+	}
+
+	return &status, nil
+}
+
+// EnrollTwoFactor returns a new TOTP secret, two-factor authentication is
+// enabled once a code of it is passed to ConfirmTwoFactor.
+func (c *Client) EnrollTwoFactor(ctx context.Context) (*TwoFactorEnrollment, error) {
+	var enrollment TwoFactorEnrollment
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Account/TwoFactor/Enroll", auth: true}, &enrollment); err != nil {
+		return nil, err
+	}
+
+	return &enrollment, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication and returns the
+// recovery codes.
+func (c *Client) ConfirmTwoFactor(ctx context.Context, code string) ([]string, error) {
+	return c.recoveryCodes(ctx, "/api/Account/TwoFactor/Confirm", code)
+}
+
+func (c *Client) RegenerateRecoveryCodes(ctx context.Context, code string) ([]string, error) {
+	return c.recoveryCodes(ctx, "/api/Account/TwoFactor/RecoveryCodes", code)
+}
+
+func (c *Client) DisableTwoFactor(ctx context.Context, code string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: "/api/Account/TwoFactor/Disable", body: TwoFactorCodeInput{Code: code}, auth: true}, nil)
+}
+
+func (c *Client) recoveryCodes(ctx context.Context, path, code string) ([]string, error) {
+	var resp RecoveryCodesOutput
+	if err := c.do(ctx, request{method: http.MethodPost, path: path, body: TwoFactorCodeInput{Code: code}, auth: true}, &resp); err != nil {
+		return nil, err
+	}
+
+	return resp.RecoveryCodes, nil
+}
+
+func (c *Client) APIKeys(ctx context.Context) ([]APIKeyOutput, error) {
+	var keys []APIKeyOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Account/ApiKeys", auth: true}, &keys); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// CreateAPIKey returns the new key in APIKeyOutput.Key, it cannot be read
+// again later.
+func (c *Client) CreateAPIKey(ctx context.Context, input *APIKeyInput) (*APIKeyOutput, error) {
+	var key APIKeyOutput
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Account/ApiKeys", body: input, auth: true}, &key); err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (c *Client) RevokeAPIKey(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/api/Account/ApiKeys/%d", id), auth: true}, nil)
+}
+
+// JWKS returns the public keys access tokens are verified with.
+func (c *Client) JWKS(ctx context.Context) (*JWKS, error) {
+	var jwks JWKS
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/.well-known/jwks.json"}, &jwks); err != nil {
+		return nil, err
+	}
+
+	return &jwks, nil
+}
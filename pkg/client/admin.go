@@ -0,0 +1,290 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type ListParams struct {
+	Start int
+	Count int
+}
+
+func (p ListParams) query() url.Values {
+	query := url.Values{}
+	if p.Start > 0 {
+		query.Set("start", strconv.Itoa(p.Start))
+	}
+
+	if p.Count > 0 {
+		query.Set("count", strconv.Itoa(p.Count))
+	}
+
+	return query
+}
+
+func (c *Client) AdminListAccounts(ctx context.Context, params ListParams) ([]AdminAccountOutput, error) {
+	var accounts []AdminAccountOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Admin/Account", query: params.query(), auth: true}, &accounts); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+func (c *Client) AdminGetAccount(ctx context.Context, id int64) (*AdminAccountOutput, error) {
+	var account AdminAccountOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Admin/Account/%d", id), auth: true}, &account); err != nil {
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+func (c *Client) AdminCreateAccount(ctx context.Context, input *AdminAccountInput) (int64, error) {
+	var resp idResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Admin/Account", body: input, auth: true}, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.ID, nil
+}
+
+func (c *Client) AdminUpdateAccount(ctx context.Context, id int64, input *AdminAccountInput) error {
+	return c.do(ctx, request{method: http.MethodPut, path: idPath("/api/Admin/Account/%d", id), body: input, auth: true}, nil)
+}
+
+func (c *Client) AdminDeleteAccount(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/api/Admin/Account/%d", id), auth: true}, nil)
+}
+
+func (c *Client) AdminListSessions(ctx context.Context, id int64) ([]SessionOutput, error) {
+	var sessions []SessionOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Admin/Account/%d/Sessions", id), auth: true}, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// AdminRevokeSessions signs the account out on every device.
+func (c *Client) AdminRevokeSessions(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/api/Admin/Account/%d/Sessions", id), auth: true}, nil)
+}
+
+func (c *Client) AdminUpdateBalance(ctx context.Context, id int64, balance float64) error {
+	return c.do(ctx, request{method: http.MethodPut, path: idPath("/api/Admin/Account/%d/Balance", id), body: AdminBalanceInput{Balance: balance}, auth: true}, nil)
+}
+
+func (c *Client) AdminListRoles(ctx context.Context) ([]RoleOutput, error) {
+	var roles []RoleOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Admin/Role", auth: true}, &roles); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (c *Client) AdminListAccountRoles(ctx context.Context, id int64) ([]string, error) {
+	var roles []string
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Admin/Account/%d/Roles", id), auth: true}, &roles); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (c *Client) AdminAssignRole(ctx context.Context, id int64, role string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: idPath("/api/Admin/Account/%d/Roles", id), body: RoleInput{Role: role}, auth: true}, nil)
+}
+
+func (c *Client) AdminUnassignRole(ctx context.Context, id int64, role string) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/api/Admin/Account/%d/Roles/", id) + url.PathEscape(role), auth: true}, nil)
+}
+
+func (c *Client) AdminUnlockAccount(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodPost, path: idPath("/api/Admin/Account/%d/Unlock", id), auth: true}, nil)
+}
+
+// AdminSetAccountStatus suspends, bans or reactivates an account.
+func (c *Client) AdminSetAccountStatus(ctx context.Context, id int64, input *AccountStatusInput) error {
+	return c.do(ctx, request{method: http.MethodPut, path: idPath("/api/Admin/Account/%d/Status", id), body: input, auth: true}, nil)
+}
+
+// AdminListSignInAttempts lists refused sign ins, username and ip filter
+// them if not empty.
+func (c *Client) AdminListSignInAttempts(ctx context.Context, username, ip string, params ListParams) ([]SignInAttemptOutput, error) {
+	query := params.query()
+	if username != "" {
+		query.Set("username", username)
+	}
+
+	if ip != "" {
+		query.Set("ip", ip)
+	}
+
+	var attempts []SignInAttemptOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Admin/SignInAttempts", query: query, auth: true}, &attempts); err != nil {
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// AdminListTransport lists transport of transportType, TransportTypeAll if empty.
+func (c *Client) AdminListTransport(ctx context.Context, transportType string, params ListParams) ([]TransportOutput, error) {
+	query := params.query()
+	if transportType != "" {
+		query.Set("transportType", transportType)
+	}
+
+	var transports []TransportOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Admin/Transport", query: query, auth: true}, &transports); err != nil {
+		return nil, err
+	}
+
+	return transports, nil
+}
+
+func (c *Client) AdminGetTransport(ctx context.Context, id int64) (*TransportOutput, error) {
+	var transport TransportOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Admin/Transport/%d", id), auth: true}, &transport); err != nil {
+		return nil, err
+	}
+
+	return &transport, nil
+}
+
+func (c *Client) AdminCreateTransport(ctx context.Context, input *AdminTransportInput) (int64, error) {
+	var resp idResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Admin/Transport", body: input, auth: true}, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.ID, nil
+}
+
+func (c *Client) AdminUpdateTransport(ctx context.Context, id int64, input *AdminTransportInput) error {
+	return c.do(ctx, request{method: http.MethodPut, path: idPath("/api/Admin/Transport/%d", id), body: input, auth: true}, nil)
+}
+
+func (c *Client) AdminDeleteTransport(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/api/Admin/Transport/%d", id), auth: true}, nil)
+}
+
+func (c *Client) AdminGetRent(ctx context.Context, id int64) (*RentOutput, error) {
+	var rent RentOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Admin/Rent/%d", id), auth: true}, &rent); err != nil {
+		return nil, err
+	}
+
+	return &rent, nil
+}
+
+func (c *Client) AdminUserHistory(ctx context.Context, userID int64) ([]RentOutput, error) {
+	var rents []RentOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Admin/UserHistory/%d", userID), auth: true}, &rents); err != nil {
+		return nil, err
+	}
+
+	return rents, nil
+}
+
+func (c *Client) AdminTransportHistory(ctx context.Context, transportID int64) ([]RentOutput, error) {
+	var rents []RentOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Admin/TransportHistory/%d", transportID), auth: true}, &rents); err != nil {
+		return nil, err
+	}
+
+	return rents, nil
+}
+
+func (c *Client) AdminStartRent(ctx context.Context, input *AdminRentInput) (int64, error) {
+	var resp idResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Admin/Rent", body: input, auth: true}, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.ID, nil
+}
+
+func (c *Client) AdminEndRent(ctx context.Context, rentID int64, lat, long float64) error {
+	return c.do(ctx, request{method: http.MethodPost, path: idPath("/api/Admin/Rent/End/%d", rentID), query: positionQuery(lat, long), auth: true}, nil)
+}
+
+func (c *Client) AdminDeleteRent(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/api/Admin/Rent/%d", id), auth: true}, nil)
+}
+
+// AdminListAuditLog searches the audit log, zero fields of the query match
+// every entry.
+func (c *Client) AdminListAuditLog(ctx context.Context, filter *AuditLogQuery, params ListParams) ([]AuditEntryOutput, error) {
+	query := params.query()
+	if filter.ActorID != nil {
+		query.Set("actorId", strconv.FormatInt(*filter.ActorID, 10))
+	}
+
+	if filter.Action != "" {
+		query.Set("action", filter.Action)
+	}
+
+	if filter.TargetType != "" {
+		query.Set("targetType", filter.TargetType)
+	}
+
+	if filter.TargetID != nil {
+		query.Set("targetId", strconv.FormatInt(*filter.TargetID, 10))
+	}
+
+	if filter.From != nil {
+		query.Set("from", filter.From.Format(time.RFC3339))
+	}
+
+	if filter.To != nil {
+		query.Set("to", filter.To.Format(time.RFC3339))
+	}
+
+	var entries []AuditEntryOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Admin/AuditLog", query: query, auth: true}, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// AdminListLicenses lists uploaded driver licenses in the status, or in any
+// if it is empty.
+func (c *Client) AdminListLicenses(ctx context.Context, status string, params ListParams) ([]LicenseOutput, error) {
+	query := params.query()
+	if status != "" {
+		query.Set("status", status)
+	}
+
+	var licenses []LicenseOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Admin/License", query: query, auth: true}, &licenses); err != nil {
+		return nil, err
+	}
+
+	return licenses, nil
+}
+
+// AdminLicenseFile downloads an uploaded driver license.
+func (c *Client) AdminLicenseFile(ctx context.Context, id int64) ([]byte, error) {
+	var file []byte
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Admin/License/%d/File", id), auth: true}, &file); err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (c *Client) AdminApproveLicense(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodPost, path: idPath("/api/Admin/License/%d/Approve", id), auth: true}, nil)
+}
+
+func (c *Client) AdminRejectLicense(ctx context.Context, id int64, reason string) error {
+	return c.do(ctx, request{method: http.MethodPost, path: idPath("/api/Admin/License/%d/Reject", id), body: LicenseRejectInput{Reason: reason}, auth: true}, nil)
+}
@@ -0,0 +1,232 @@
+// Package client is a typed Go client for the Simbir.GO HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultTimeout = 10 * time.Second
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	tokens     TokenStore
+	apiKey     string
+
+	// refreshMu lets one request at a time use the single-use refresh token
+	refreshMu sync.Mutex
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the default http.Client.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTokenStore replaces the default in-memory token storage.
+func WithTokenStore(tokens TokenStore) Option {
+	return func(c *Client) {
+		c.tokens = tokens
+	}
+}
+
+// WithAPIKey authenticates requests with an API key instead of signing in.
+// Only routes allowed by the scopes of the key can be called.
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: defaultTimeout},
+		tokens:     NewMemoryTokenStore(),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	// file is sent as a multipart form instead of a JSON body
+	file *formFile
+	auth bool
+}
+
+type formFile struct {
+	field string
+	name  string
+	data  []byte
+}
+
+// do sends the request and decodes a JSON response into out. Authorized
+// requests rejected with an invalid token are retried once after refreshing
+// the tokens. If that fails too, ErrInvalidToken is returned and the caller
+// has to sign in again.
+func (c *Client) do(ctx context.Context, req request, out any) error {
+	// the token the request goes out with, to tell whether another request
+	// refreshed it in the meantime
+	var token string
+	if req.auth && c.apiKey == "" {
+		token, _ = c.tokens.Token(ctx)
+	}
+
+	err := c.send(ctx, req, out)
+	if !req.auth || c.apiKey != "" || !errors.Is(err, ErrInvalidToken) {
+		return err
+	}
+
+	if refreshErr := c.refresh(ctx, token); refreshErr != nil {
+		return err
+	}
+
+	return c.send(ctx, req, out)
+}
+
+func (c *Client) send(ctx context.Context, req request, out any) error {
+	u := c.baseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	var body io.Reader
+	contentType := ""
+	if req.body != nil {
+		data, err := json.Marshal(req.body)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+
+		body = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	if req.file != nil {
+		var buf bytes.Buffer
+		w := multipart.NewWriter(&buf)
+		part, err := w.CreateFormFile(req.file.field, req.file.name)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+
+		if _, err := part.Write(req.file.data); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+
+		if err := w.Close(); err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+
+		body = &buf
+		contentType = w.FormDataContentType()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	httpReq.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+
+	if req.auth && c.apiKey != "" {
+		httpReq.Header.Set("X-API-Key", c.apiKey)
+	} else if req.auth {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return fmt.Errorf("get token: %w", err)
+		}
+
+		if token == "" {
+			return ErrNotSignedIn
+		}
+
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	// raw bodies such as archives and files are returned as they are
+	if raw, ok := out.(*[]byte); ok {
+		if *raw, err = io.ReadAll(resp.Body); err != nil {
+			return fmt.Errorf("read response: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	return nil
+}
+
+// refresh renews the tokens after a request sent with stale was rejected.
+// The server takes a refresh token presented twice for a stolen one and
+// revokes the session, so requests failing at the same time wait for a
+// single refresh and go on with its token.
+func (c *Client) refresh(ctx context.Context, stale string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("get token: %w", err)
+	}
+
+	if token != "" && token != stale {
+		return nil
+	}
+
+	return c.refreshTokens(ctx)
+}
+
+func (c *Client) storeTokens(ctx context.Context, tokens *TokenOutput) error {
+	if err := c.tokens.SetToken(ctx, tokens.Token); err != nil {
+		return err
+	}
+
+	return c.tokens.SetRefreshToken(ctx, tokens.RefreshToken)
+}
+
+func idPath(format string, id int64) string {
+	return fmt.Sprintf(format, id)
+}
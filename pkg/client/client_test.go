@@ -0,0 +1,158 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// refreshServer issues single-use refresh tokens and, like the real API,
+// revokes the session once one is presented again.
+type refreshServer struct {
+	mu           sync.Mutex
+	generation   int
+	refreshes    int
+	reused       bool
+	revoked      bool
+	meRequests   int
+	refreshToken string
+}
+
+func (s *refreshServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/api/Account/Refresh":
+		var input RefreshInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			writeTestProblem(w, http.StatusBadRequest, "invalid_input")
+			return
+		}
+
+		if s.revoked || input.RefreshToken != s.refreshToken {
+			s.reused = true
+			s.revoked = true
+			writeTestProblem(w, http.StatusUnauthorized, "invalid_refresh_token")
+			return
+		}
+
+		s.refreshes++
+		s.generation++
+		s.refreshToken = fmt.Sprintf("refresh-%d", s.generation)
+		json.NewEncoder(w).Encode(TokenOutput{
+			Token:        fmt.Sprintf("token-%d", s.generation),
+			RefreshToken: s.refreshToken,
+		})
+	case "/api/Account/Me":
+		s.meRequests++
+		if s.revoked || r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", s.generation) {
+			writeTestProblem(w, http.StatusUnauthorized, "invalid_token")
+			return
+		}
+
+		json.NewEncoder(w).Encode(AccountOutput{ID: 1, Username: "rider"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func writeTestProblem(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]any{"status": status, "code": code})
+}
+
+func TestConcurrentExpiredRequestsRefreshOnce(t *testing.T) {
+	fake := &refreshServer{generation: 1, refreshToken: "refresh-1"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	tokens := NewMemoryTokenStore()
+	tokens.SetToken(ctx, "expired")
+	tokens.SetRefreshToken(ctx, "refresh-1")
+
+	c := New(server.URL, WithTokenStore(tokens))
+
+	const requests = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Me(ctx); err != nil {
+				errs <- err
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("Me() error = %v", err)
+	}
+
+	if fake.reused {
+		t.Error("a refresh token was presented twice")
+	}
+
+	if fake.refreshes != 1 {
+		t.Errorf("refreshes = %d, want 1", fake.refreshes)
+	}
+}
+
+func TestRefreshAfterTokenReplaced(t *testing.T) {
+	fake := &refreshServer{generation: 1, refreshToken: "refresh-1"}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	tokens := NewMemoryTokenStore()
+	tokens.SetToken(ctx, "token-1")
+	tokens.SetRefreshToken(ctx, "refresh-1")
+
+	c := New(server.URL, WithTokenStore(tokens))
+
+	// a request rejected with a token another request already replaced
+	// goes on with the new one
+	if err := c.Refresh(ctx); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if err := c.refresh(ctx, "token-1"); err != nil {
+		t.Fatalf("refresh() error = %v", err)
+	}
+
+	if fake.refreshes != 1 {
+		t.Errorf("refreshes = %d, want 1", fake.refreshes)
+	}
+}
+
+func TestFailedRefreshReturnsInvalidToken(t *testing.T) {
+	fake := &refreshServer{generation: 1, refreshToken: "refresh-1", revoked: true}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	ctx := context.Background()
+	tokens := NewMemoryTokenStore()
+	tokens.SetToken(ctx, "token-1")
+	tokens.SetRefreshToken(ctx, "refresh-1")
+
+	c := New(server.URL, WithTokenStore(tokens))
+
+	if _, err := c.Me(ctx); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Me() error = %v, want %v", err, ErrInvalidToken)
+	}
+
+	if fake.meRequests != 1 {
+		t.Errorf("Me requests = %d, want 1", fake.meRequests)
+	}
+}
@@ -0,0 +1,215 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Errors of the API, told apart by the code of the response. Match them
+// with errors.Is.
+var (
+	ErrUsernameAlreadyExists   = errors.New("username already exists")
+	ErrIdentifierAlreadyExists = errors.New("identifier already exists")
+	ErrEmailAlreadyExists      = errors.New("email already exists")
+	ErrInvalidUsername         = errors.New("invalid username")
+	ErrInvalidPassword         = errors.New("invalid password")
+	ErrInvalidEmail            = errors.New("invalid email")
+	ErrValidation              = errors.New("validation failed")
+	ErrRequiredField           = errors.New("required field is empty")
+	ErrInvalidValue            = errors.New("invalid value")
+	ErrInvalidTransportType    = errors.New("invalid transport type")
+	ErrInvalidCoordinates      = errors.New("invalid coordinates")
+	ErrInvalidPrice            = errors.New("invalid price")
+	ErrInvalidAmount           = errors.New("invalid amount")
+	ErrInvalidRadius           = errors.New("invalid radius")
+	ErrInvalidCredentials      = errors.New("invalid username or password")
+	ErrCannotSignToken         = errors.New("cannot sign token")
+	ErrInvalidToken            = errors.New("invalid token")
+	ErrInvalidRefreshToken     = errors.New("invalid refresh token")
+	ErrTooManyAttempts         = errors.New("too many failed sign in attempts")
+	ErrInvalidEmailToken       = errors.New("invalid or expired email token")
+	ErrEmailNotSet             = errors.New("email not set")
+	ErrEmailAlreadyVerified    = errors.New("email already verified")
+	ErrOIDCNotConfigured       = errors.New("external sign in is not configured")
+	ErrOIDCProviderUnavailable = errors.New("external sign in provider unavailable")
+	ErrInvalidOIDCState        = errors.New("invalid or expired login state")
+	ErrOIDCLoginFailed         = errors.New("external sign in failed")
+	ErrInvalidChallenge        = errors.New("invalid or expired sign in challenge")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+	ErrTwoFactorRequired       = errors.New("two-factor authentication required")
+	ErrTwoFactorEnabled        = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication not enabled")
+	ErrInvalidAPIKey           = errors.New("invalid api key")
+	ErrInvalidScope            = errors.New("invalid scope")
+	ErrAccountNotFound         = errors.New("account not found")
+	ErrSessionNotFound         = errors.New("session not found")
+	ErrAPIKeyNotFound          = errors.New("api key not found")
+	ErrRoleNotFound            = errors.New("role not found")
+	ErrTransportNotFound       = errors.New("transport not found")
+	ErrAccessDenied            = errors.New("access denied")
+	ErrNotEnoughMoney          = errors.New("not enough money")
+	ErrInvalidRentType         = errors.New("invalid rent type")
+	ErrRentNotFound            = errors.New("rent not found")
+	ErrRentAlreadyEnded        = errors.New("rent already ended")
+	ErrTransportUnavailable    = errors.New("transport cannot be rented")
+	ErrActiveRent              = errors.New("account has a rent in progress")
+	ErrTransportRented         = errors.New("transport has a rent in progress")
+	ErrAccountSuspended        = errors.New("account is suspended")
+	ErrAccountBanned           = errors.New("account is banned")
+	ErrInvalidAccountStatus    = errors.New("invalid account status")
+	ErrBirthDateLocked         = errors.New("birth date cannot change once a license is approved")
+	ErrLicenseNotFound         = errors.New("license not found")
+	ErrLicensePending          = errors.New("a license is already waiting for review")
+	ErrLicenseReviewed         = errors.New("license already reviewed")
+	ErrLicenseTooLarge         = errors.New("license file too large")
+	ErrInvalidLicenseFile      = errors.New("license must be a JPEG, PNG or PDF file")
+	ErrLicenseRequired         = errors.New("an approved driver license is required")
+	ErrBirthDateRequired       = errors.New("birth date required in the profile")
+	ErrAgeRequirement          = errors.New("rider is too young for this transport")
+
+	ErrNotSignedIn = errors.New("client is not signed in")
+)
+
+var codeErrors = map[string]error{
+	"username_already_exists":   ErrUsernameAlreadyExists,
+	"identifier_already_exists": ErrIdentifierAlreadyExists,
+	"email_already_exists":      ErrEmailAlreadyExists,
+	"invalid_username":          ErrInvalidUsername,
+	"invalid_password":          ErrInvalidPassword,
+	"invalid_email":             ErrInvalidEmail,
+	"validation_failed":         ErrValidation,
+	"required_field":            ErrRequiredField,
+	"invalid_value":             ErrInvalidValue,
+	"invalid_transport_type":    ErrInvalidTransportType,
+	"invalid_coordinates":       ErrInvalidCoordinates,
+	"invalid_price":             ErrInvalidPrice,
+	"invalid_amount":            ErrInvalidAmount,
+	"invalid_radius":            ErrInvalidRadius,
+	"invalid_credentials":       ErrInvalidCredentials,
+	"cannot_sign_token":         ErrCannotSignToken,
+	"invalid_token":             ErrInvalidToken,
+	"invalid_refresh_token":     ErrInvalidRefreshToken,
+	"too_many_attempts":         ErrTooManyAttempts,
+	"invalid_email_token":       ErrInvalidEmailToken,
+	"email_not_set":             ErrEmailNotSet,
+	"email_already_verified":    ErrEmailAlreadyVerified,
+	"oidc_not_configured":       ErrOIDCNotConfigured,
+	"oidc_provider_unavailable": ErrOIDCProviderUnavailable,
+	"invalid_oidc_state":        ErrInvalidOIDCState,
+	"oidc_login_failed":         ErrOIDCLoginFailed,
+	"invalid_challenge":         ErrInvalidChallenge,
+	"invalid_two_factor_code":   ErrInvalidTwoFactorCode,
+	"two_factor_required":       ErrTwoFactorRequired,
+	"two_factor_enabled":        ErrTwoFactorEnabled,
+	"two_factor_not_enabled":    ErrTwoFactorNotEnabled,
+	"invalid_api_key":           ErrInvalidAPIKey,
+	"invalid_scope":             ErrInvalidScope,
+	"account_not_found":         ErrAccountNotFound,
+	"session_not_found":         ErrSessionNotFound,
+	"api_key_not_found":         ErrAPIKeyNotFound,
+	"role_not_found":            ErrRoleNotFound,
+	"transport_not_found":       ErrTransportNotFound,
+	"access_denied":             ErrAccessDenied,
+	"not_enough_money":          ErrNotEnoughMoney,
+	"invalid_rent_type":         ErrInvalidRentType,
+	"rent_not_found":            ErrRentNotFound,
+	"rent_already_ended":        ErrRentAlreadyEnded,
+	"transport_unavailable":     ErrTransportUnavailable,
+	"active_rent":               ErrActiveRent,
+	"transport_rented":          ErrTransportRented,
+	"account_suspended":         ErrAccountSuspended,
+	"account_banned":            ErrAccountBanned,
+	"invalid_account_status":    ErrInvalidAccountStatus,
+	"birth_date_locked":         ErrBirthDateLocked,
+	"license_not_found":         ErrLicenseNotFound,
+	"license_pending":           ErrLicensePending,
+	"license_already_reviewed":  ErrLicenseReviewed,
+	"license_too_large":         ErrLicenseTooLarge,
+	"invalid_license_file":      ErrInvalidLicenseFile,
+	"license_required":          ErrLicenseRequired,
+	"birth_date_required":       ErrBirthDateRequired,
+	"age_requirement":           ErrAgeRequirement,
+}
+
+// ChallengeError is returned by SignIn for accounts with two-factor
+// authentication. It matches ErrTwoFactorRequired with errors.Is.
+type ChallengeError struct {
+	Challenge SignInChallenge
+}
+
+func (e *ChallengeError) Error() string {
+	return "simbir-go: two-factor authentication required"
+}
+
+func (e *ChallengeError) Is(target error) bool {
+	return target == ErrTwoFactorRequired
+}
+
+// APIError is a problem+json response returned by the API.
+type APIError struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail"`
+	Instance string       `json:"instance"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors"`
+	// RetryAfter is set from the Retry-After header, e.g. for a locked sign in
+	RetryAfter time.Duration `json:"-"`
+}
+
+// FieldError describes an invalid field of a validation_failed response.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+func (e *APIError) Error() string {
+	if e.Detail != "" {
+		return fmt.Sprintf("simbir-go: %d %s: %s", e.Status, e.Code, e.Detail)
+	}
+
+	return fmt.Sprintf("simbir-go: %d %s", e.Status, e.Code)
+}
+
+// Unwrap returns the errors matching the error code and the codes of
+// invalid fields, if any.
+func (e *APIError) Unwrap() []error {
+	var errs []error
+	if err, ok := codeErrors[e.Code]; ok {
+		errs = append(errs, err)
+	}
+
+	for _, field := range e.Errors {
+		if err, ok := codeErrors[field.Code]; ok {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{Status: resp.StatusCode}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil || json.Unmarshal(data, apiErr) != nil {
+		apiErr.Title = http.StatusText(resp.StatusCode)
+	}
+
+	if apiErr.Status == 0 {
+		apiErr.Status = resp.StatusCode
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return apiErr
+}
@@ -0,0 +1,11 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Hesoyam adds 250 000 to the balance. Only admins can top up other accounts.
+func (c *Client) Hesoyam(ctx context.Context, accountID int64) error {
+	return c.do(ctx, request{method: http.MethodPost, path: idPath("/api/Payment/Hesoyam/%d", accountID), auth: true}, nil)
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+func (c *Client) GetRent(ctx context.Context, id int64) (*RentOutput, error) {
+	var rent RentOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Rent/%d", id), auth: true}, &rent); err != nil {
+		return nil, err
+	}
+
+	return &rent, nil
+}
+
+func (c *Client) MyHistory(ctx context.Context) ([]RentOutput, error) {
+	var rents []RentOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Rent/MyHistory", auth: true}, &rents); err != nil {
+		return nil, err
+	}
+
+	return rents, nil
+}
+
+func (c *Client) TransportHistory(ctx context.Context, transportID int64) ([]RentOutput, error) {
+	var rents []RentOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Rent/TransportHistory/%d", transportID), auth: true}, &rents); err != nil {
+		return nil, err
+	}
+
+	return rents, nil
+}
+
+// StartRent rents transport, rentType is RentTypeMinutes or RentTypeDays.
+func (c *Client) StartRent(ctx context.Context, transportID int64, rentType string) (int64, error) {
+	query := url.Values{"rentType": {rentType}}
+
+	var resp idResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: idPath("/api/Rent/New/%d", transportID), query: query, auth: true}, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.ID, nil
+}
+
+func (c *Client) EndRent(ctx context.Context, rentID int64, lat, long float64) error {
+	return c.do(ctx, request{method: http.MethodPost, path: idPath("/api/Rent/End/%d", rentID), query: positionQuery(lat, long), auth: true}, nil)
+}
+
+func positionQuery(lat, long float64) url.Values {
+	return url.Values{
+		"lat":  {strconv.FormatFloat(lat, 'f', -1, 64)},
+		"long": {strconv.FormatFloat(long, 'f', -1, 64)},
+	}
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"sync"
+)
+
+// TokenStore keeps the access and refresh tokens between requests, e.g. to
+// share them between several clients or persist them.
+type TokenStore interface {
+	Token(ctx context.Context) (string, error)
+	SetToken(ctx context.Context, token string) error
+	RefreshToken(ctx context.Context) (string, error)
+	SetRefreshToken(ctx context.Context, token string) error
+}
+
+type MemoryTokenStore struct {
+	mu           sync.RWMutex
+	token        string
+	refreshToken string
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+func (s *MemoryTokenStore) Token(_ context.Context) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.token, nil
+}
+
+func (s *MemoryTokenStore) SetToken(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = token
+	return nil
+}
+
+func (s *MemoryTokenStore) RefreshToken(_ context.Context) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.refreshToken, nil
+}
+
+func (s *MemoryTokenStore) SetRefreshToken(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refreshToken = token
+	return nil
+}
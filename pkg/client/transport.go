@@ -0,0 +1,68 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type SearchParams struct {
+	Latitude  float64
+	Longitude float64
+	// Radius is in meters
+	Radius float64
+	// Type is one of TransportType* constants, TransportTypeAll if empty.
+	Type string
+}
+
+func (c *Client) GetTransport(ctx context.Context, id int64) (*TransportOutput, error) {
+	var transport TransportOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: idPath("/api/Transport/%d", id)}, &transport); err != nil {
+		return nil, err
+	}
+
+	return &transport, nil
+}
+
+func (c *Client) CreateTransport(ctx context.Context, input *TransportInput) (int64, error) {
+	var resp idResponse
+	if err := c.do(ctx, request{method: http.MethodPost, path: "/api/Transport", body: input, auth: true}, &resp); err != nil {
+		return 0, err
+	}
+
+	return resp.ID, nil
+}
+
+func (c *Client) UpdateTransport(ctx context.Context, id int64, input *TransportInput) error {
+	return c.do(ctx, request{method: http.MethodPut, path: idPath("/api/Transport/%d", id), body: input, auth: true}, nil)
+}
+
+// UpdateTransportPosition reports where own transport is, e.g. from a
+// telemetry gateway using an API key with the transport:position scope.
+func (c *Client) UpdateTransportPosition(ctx context.Context, id int64, input *PositionInput) error {
+	return c.do(ctx, request{method: http.MethodPut, path: idPath("/api/Transport/%d/Position", id), body: input, auth: true}, nil)
+}
+
+func (c *Client) DeleteTransport(ctx context.Context, id int64) error {
+	return c.do(ctx, request{method: http.MethodDelete, path: idPath("/api/Transport/%d", id), auth: true}, nil)
+}
+
+// SearchTransport returns transport available within the radius, nearest
+// first.
+func (c *Client) SearchTransport(ctx context.Context, params SearchParams) ([]TransportOutput, error) {
+	query := url.Values{}
+	query.Set("lat", strconv.FormatFloat(params.Latitude, 'f', -1, 64))
+	query.Set("long", strconv.FormatFloat(params.Longitude, 'f', -1, 64))
+	query.Set("radius", strconv.FormatFloat(params.Radius, 'f', -1, 64))
+	if params.Type != "" {
+		query.Set("type", params.Type)
+	}
+
+	var transports []TransportOutput
+	if err := c.do(ctx, request{method: http.MethodGet, path: "/api/Rent/Transport", query: query}, &transports); err != nil {
+		return nil, err
+	}
+
+	return transports, nil
+}
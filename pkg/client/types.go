@@ -0,0 +1,86 @@
+package client
+
+import (
+	"github.com/realdanielursul/simbir-go/pkg/api"
+	"github.com/realdanielursul/simbir-go/pkg/jwtkeys"
+)
+
+// Request and response bodies are shared with the server through pkg/api.
+type (
+	AccountInput           = api.AccountInput
+	AccountOutput          = api.AccountOutput
+	TokenOutput            = api.TokenOutput
+	RefreshInput           = api.RefreshInput
+	SignInOutput           = api.SignInOutput
+	OIDCLoginOutput        = api.OIDCLoginOutput
+	ExternalIdentityOutput = api.ExternalIdentityOutput
+	AccountExport          = api.AccountExport
+	PaymentOutput          = api.PaymentOutput
+	EraseAccountInput      = api.EraseAccountInput
+	ProfileInput           = api.ProfileInput
+	ProfileOutput          = api.ProfileOutput
+	LicenseOutput          = api.LicenseOutput
+	LicenseRejectInput     = api.LicenseRejectInput
+	EmailInput             = api.EmailInput
+	EmailTokenInput        = api.EmailTokenInput
+	ResetPasswordInput     = api.ResetPasswordInput
+	SignInChallenge        = api.SignInChallengeOutput
+	TwoFactorSignInInput   = api.TwoFactorSignInInput
+	TwoFactorCodeInput     = api.TwoFactorCodeInput
+	TwoFactorStatus        = api.TwoFactorStatus
+	TwoFactorEnrollment    = api.TwoFactorEnrollment
+	RecoveryCodesOutput    = api.RecoveryCodesOutput
+	SessionOutput          = api.SessionOutput
+	APIKeyInput            = api.APIKeyInput
+	APIKeyOutput           = api.APIKeyOutput
+	AdminBalanceInput      = api.AdminBalanceInput
+	AccountStatusInput     = api.AccountStatusInput
+	RoleOutput             = api.RoleOutput
+	SignInAttemptOutput    = api.SignInAttemptOutput
+	RoleInput              = api.RoleInput
+	AdminAccountInput      = api.AdminAccountInput
+	AdminAccountOutput     = api.AdminAccountOutput
+	TransportInput         = api.TransportInput
+	TransportOutput        = api.TransportOutput
+	PositionInput          = api.PositionInput
+	AdminTransportInput    = api.AdminTransportInput
+	RentOutput             = api.RentOutput
+	AdminRentInput         = api.AdminRentInput
+	AuditLogQuery          = api.AuditLogQuery
+	AuditEntryOutput       = api.AuditEntryOutput
+	JWKS                   = jwtkeys.JWKS
+)
+
+const (
+	TransportTypeAll     = "All"
+	TransportTypeCar     = "Car"
+	TransportTypeBike    = "Bike"
+	TransportTypeScooter = "Scooter"
+
+	RentTypeMinutes = "Minutes"
+	RentTypeDays    = "Days"
+
+	AccountStatusActive    = "active"
+	AccountStatusSuspended = "suspended"
+	AccountStatusBanned    = "banned"
+
+	ScopeAccountRead       = "account:read"
+	ScopeTransportWrite    = "transport:write"
+	ScopeTransportPosition = "transport:position"
+	ScopeRentRead          = "rent:read"
+	ScopeRentWrite         = "rent:write"
+
+	AuditTargetAccount   = "account"
+	AuditTargetTransport = "transport"
+	AuditTargetRent      = "rent"
+	AuditTargetLicense   = "license"
+
+	LicenseStatusNone     = "none"
+	LicenseStatusPending  = "pending"
+	LicenseStatusApproved = "approved"
+	LicenseStatusRejected = "rejected"
+)
+
+type idResponse struct {
+	ID int64 `json:"id"`
+}
@@ -0,0 +1,71 @@
+// Package geo measures distances on the Earth's surface.
+package geo
+
+import "math"
+
+// EarthRadius is the mean radius of the Earth in meters.
+const EarthRadius = 6371008.8
+
+// Distance returns the great-circle distance in meters between two points
+// given in degrees, using the haversine formula. Treating the Earth as a
+// sphere is off by at most about 0.5%, plenty for finding nearby transport.
+func Distance(lat1, long1, lat2, long2 float64) float64 {
+	phi1 := radians(lat1)
+	phi2 := radians(lat2)
+	dPhi := radians(lat2 - lat1)
+	dLambda := radians(long2 - long1)
+
+	h := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+
+	// rounding may push h just past 1 for antipodal points
+	return 2 * EarthRadius * math.Asin(math.Sqrt(math.Min(h, 1)))
+}
+
+// Box is the area between two parallels and two meridians, in degrees. A box
+// crossing the antimeridian has MinLong greater than MaxLong.
+type Box struct {
+	MinLat, MaxLat   float64
+	MinLong, MaxLong float64
+}
+
+// BoundingBox returns the smallest box holding every point within radius
+// meters of the given one. It lets an index on latitude and longitude narrow
+// down the candidates before Distance is computed for them.
+func BoundingBox(lat, long, radius float64) Box {
+	delta := degrees(radius / EarthRadius)
+	box := Box{
+		MinLat:  lat - delta,
+		MaxLat:  lat + delta,
+		MinLong: -180,
+		MaxLong: 180,
+	}
+
+	// a circle around a pole covers every meridian
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat = math.Max(box.MinLat, -90)
+		box.MaxLat = math.Min(box.MaxLat, 90)
+		return box
+	}
+
+	// the meridians tangent to the circle
+	deltaLong := degrees(math.Asin(math.Sin(radius/EarthRadius) / math.Cos(radians(lat))))
+	box.MinLong = long - deltaLong
+	if box.MinLong < -180 {
+		box.MinLong += 360
+	}
+
+	box.MaxLong = long + deltaLong
+	if box.MaxLong > 180 {
+		box.MaxLong -= 360
+	}
+
+	return box
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
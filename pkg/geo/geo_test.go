@@ -0,0 +1,139 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name                     string
+		lat1, long1, lat2, long2 float64
+		want, tolerance          float64
+	}{
+		{"same point", 54.3142, 48.4031, 54.3142, 48.4031, 0, 0},
+		{"degree of longitude at the equator", 0, 0, 0, 1, 111195, 1},
+		{"degree of latitude", 10, 20, 11, 20, 111195, 1},
+		{"moscow to saint petersburg", 55.7558, 37.6173, 59.9343, 30.3351, 633000, 2000},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111195, 1},
+		{"over the pole", 89.5, 0, 89.5, 180, 111195, 1},
+		{"antipodes", 0, 0, 0, 180, math.Pi * EarthRadius, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Distance(tt.lat1, tt.long1, tt.lat2, tt.long2)
+			if math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("Distance() = %f, want %f ± %f", got, tt.want, tt.tolerance)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	// a degree of latitude is about 111195 meters
+	const degree = 111195
+
+	tests := []struct {
+		name      string
+		lat, long float64
+		radius    float64
+		want      Box
+	}{
+		{
+			name: "equator",
+			lat:  0, long: 0, radius: degree,
+			want: Box{MinLat: -1, MaxLat: 1, MinLong: -1, MaxLong: 1},
+		},
+		{
+			name: "meridians converge away from the equator",
+			lat:  60, long: 10, radius: degree,
+			want: Box{MinLat: 59, MaxLat: 61, MinLong: 7.9997, MaxLong: 12.0003},
+		},
+		{
+			name: "across the antimeridian to the east",
+			lat:  0, long: 179.5, radius: degree,
+			want: Box{MinLat: -1, MaxLat: 1, MinLong: 178.5, MaxLong: -179.5},
+		},
+		{
+			name: "across the antimeridian to the west",
+			lat:  0, long: -179.5, radius: degree,
+			want: Box{MinLat: -1, MaxLat: 1, MinLong: 179.5, MaxLong: -178.5},
+		},
+		{
+			name: "north pole",
+			lat:  89.5, long: 30, radius: degree,
+			want: Box{MinLat: 88.5, MaxLat: 90, MinLong: -180, MaxLong: 180},
+		},
+		{
+			name: "south pole",
+			lat:  -89.5, long: 30, radius: degree,
+			want: Box{MinLat: -90, MaxLat: -88.5, MinLong: -180, MaxLong: 180},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BoundingBox(tt.lat, tt.long, tt.radius)
+			if !boxNear(got, tt.want, 1e-3) {
+				t.Errorf("BoundingBox() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBoundingBoxHoldsCircle checks that points on the circle fall within the
+// box, the search relies on it not to miss transport.
+func TestBoundingBoxHoldsCircle(t *testing.T) {
+	centers := []struct{ lat, long float64 }{
+		{54.3142, 48.4031},
+		{0, 179.99},
+		{-45, -179.99},
+		{89.9, 0},
+		{-89.9, 120},
+	}
+
+	for _, center := range centers {
+		for _, radius := range []float64{10, 1000, 50000} {
+			box := BoundingBox(center.lat, center.long, radius)
+			for bearing := 0.0; bearing < 360; bearing += 5 {
+				lat, long := destination(center.lat, center.long, bearing, radius*0.999)
+				if !box.contains(lat, long) {
+					t.Errorf("box %+v around (%g, %g) with radius %g misses (%g, %g)", box, center.lat, center.long, radius, lat, long)
+				}
+			}
+		}
+	}
+}
+
+func (b Box) contains(lat, long float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+
+	if b.MinLong <= b.MaxLong {
+		return long >= b.MinLong && long <= b.MaxLong
+	}
+
+	return long >= b.MinLong || long <= b.MaxLong
+}
+
+// destination returns the point distance meters away from the given one in
+// the direction of bearing, in degrees clockwise from north.
+func destination(lat, long, bearing, distance float64) (float64, float64) {
+	phi := radians(lat)
+	theta := radians(bearing)
+	delta := distance / EarthRadius
+
+	phi2 := math.Asin(math.Sin(phi)*math.Cos(delta) + math.Cos(phi)*math.Sin(delta)*math.Cos(theta))
+	lambda2 := radians(long) + math.Atan2(math.Sin(theta)*math.Sin(delta)*math.Cos(phi), math.Cos(delta)-math.Sin(phi)*math.Sin(phi2))
+
+	return degrees(phi2), math.Remainder(degrees(lambda2), 360)
+}
+
+func boxNear(a, b Box, tolerance float64) bool {
+	return math.Abs(a.MinLat-b.MinLat) <= tolerance &&
+		math.Abs(a.MaxLat-b.MaxLat) <= tolerance &&
+		math.Abs(a.MinLong-b.MinLong) <= tolerance &&
+		math.Abs(a.MaxLong-b.MaxLong) <= tolerance
+}
@@ -0,0 +1,110 @@
+package hasher
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+type Argon2idParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Argon2idHasher stores hashes in PHC string format, so every hash carries
+// its own salt and parameters. Hashes it does not recognise are checked with
+// the legacy hasher, if any, and reported as needing a rehash.
+type Argon2idHasher struct {
+	params Argon2idParams
+	legacy PasswordHasher
+}
+
+func NewArgon2idHasher(params Argon2idParams, legacy PasswordHasher) *Argon2idHasher {
+	return &Argon2idHasher{
+		params: params,
+		legacy: legacy,
+	}
+}
+
+func (h *Argon2idHasher) Hash(password string) string {
+	salt := make([]byte, h.params.SaltLength)
+	rand.Read(salt)
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func (h *Argon2idHasher) Verify(password, hash string) (bool, bool) {
+	if !strings.HasPrefix(hash, argon2idPrefix) {
+		if h.legacy == nil {
+			return false, false
+		}
+
+		ok, _ := h.legacy.Verify(password, hash)
+		return ok, ok
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		return false, false
+	}
+
+	other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false
+	}
+
+	// parameters were raised since the hash was computed
+	params.SaltLength = uint32(len(salt))
+	return true, params != h.params
+}
+
+func decodeArgon2id(hash string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errors.New("unsupported argon2 version")
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	// argon2 panics on these
+	if params.Iterations < 1 || params.Parallelism < 1 {
+		return params, nil, nil, errors.New("invalid argon2id parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("invalid argon2id key")
+	}
+
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
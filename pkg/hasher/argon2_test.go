@@ -0,0 +1,151 @@
+package hasher
+
+import (
+	"strings"
+	"testing"
+)
+
+var testParams = Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	h := NewArgon2idHasher(testParams, nil)
+
+	hash := h.Hash("correct horse")
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("hash %q is not a PHC string of the parameters", hash)
+	}
+
+	params, salt, key, err := decodeArgon2id(hash)
+	if err != nil {
+		t.Fatalf("decode own hash: %v", err)
+	}
+
+	params.SaltLength = uint32(len(salt))
+	if params != testParams || len(key) != int(testParams.KeyLength) {
+		t.Errorf("decoded %+v with a %d byte key, want %+v", params, len(key), testParams)
+	}
+
+	if ok, rehash := h.Verify("correct horse", hash); !ok || rehash {
+		t.Errorf("Verify(right password) = %v, %v, want true, false", ok, rehash)
+	}
+
+	if ok, _ := h.Verify("correct horse ", hash); ok {
+		t.Error("Verify accepted a wrong password")
+	}
+
+	if other := h.Hash("correct horse"); other == hash {
+		t.Error("two hashes of a password are equal, the salt is not random")
+	}
+}
+
+func TestArgon2idRejectsMalformedHashes(t *testing.T) {
+	h := NewArgon2idHasher(testParams, nil)
+	hash := h.Hash("correct horse")
+	parts := strings.Split(hash, "$")
+
+	replace := func(i int, value string) string {
+		changed := append([]string(nil), parts...)
+		changed[i] = value
+		return strings.Join(changed, "$")
+	}
+
+	// change the first character of the key, the last one carries unused bits
+	key := parts[5]
+	tampered := "A" + key[1:]
+	if key[0] == 'A' {
+		tampered = "B" + key[1:]
+	}
+
+	tests := []struct {
+		name string
+		hash string
+	}{
+		{name: "empty", hash: ""},
+		{name: "prefix only", hash: "$argon2id$"},
+		{name: "missing key", hash: strings.Join(parts[:5], "$")},
+		{name: "extra part", hash: hash + "$AAAA"},
+		{name: "other version", hash: replace(2, "v=16")},
+		{name: "garbled version", hash: replace(2, "version")},
+		{name: "garbled parameters", hash: replace(3, "m=64;t=1;p=1")},
+		{name: "no iterations", hash: replace(3, "m=64,t=0,p=1")},
+		{name: "no parallelism", hash: replace(3, "m=64,t=1,p=0")},
+		{name: "salt not base64", hash: replace(4, "not base64!")},
+		{name: "key not base64", hash: replace(5, "not base64!")},
+		{name: "empty key", hash: replace(5, "")},
+		{name: "tampered key", hash: replace(5, tampered)},
+		{name: "tampered salt", hash: replace(4, strings.Repeat("A", len(parts[4])))},
+		{name: "tampered memory", hash: replace(3, "m=128,t=1,p=1")},
+		{name: "tampered iterations", hash: replace(3, "m=64,t=2,p=1")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ok, rehash := h.Verify("correct horse", tt.hash); ok || rehash {
+				t.Errorf("Verify(%q) = %v, %v, want false, false", tt.hash, ok, rehash)
+			}
+		})
+	}
+}
+
+func TestArgon2idRehashOnParameterChange(t *testing.T) {
+	hash := NewArgon2idHasher(testParams, nil).Hash("correct horse")
+
+	tests := []struct {
+		name   string
+		change func(params *Argon2idParams)
+		rehash bool
+	}{
+		{name: "unchanged", change: func(*Argon2idParams) {}},
+		{name: "memory", change: func(params *Argon2idParams) { params.Memory *= 2 }, rehash: true},
+		{name: "iterations", change: func(params *Argon2idParams) { params.Iterations++ }, rehash: true},
+		{name: "parallelism", change: func(params *Argon2idParams) { params.Parallelism++ }, rehash: true},
+		{name: "salt length", change: func(params *Argon2idParams) { params.SaltLength = 32 }, rehash: true},
+		{name: "key length", change: func(params *Argon2idParams) { params.KeyLength = 64 }, rehash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := testParams
+			tt.change(&params)
+
+			ok, rehash := NewArgon2idHasher(params, nil).Verify("correct horse", hash)
+			if !ok || rehash != tt.rehash {
+				t.Errorf("Verify = %v, %v, want true, %v", ok, rehash, tt.rehash)
+			}
+		})
+	}
+}
+
+func TestArgon2idLegacyHashes(t *testing.T) {
+	legacy := NewSHA1Hasher("salt")
+	legacyHash := legacy.Hash("correct horse")
+
+	if ok, rehash := legacy.Verify("correct horse", legacyHash); !ok || rehash {
+		t.Fatalf("SHA1Hasher.Verify = %v, %v, want true, false", ok, rehash)
+	}
+
+	h := NewArgon2idHasher(testParams, legacy)
+	if ok, rehash := h.Verify("correct horse", legacyHash); !ok || !rehash {
+		t.Errorf("Verify(legacy hash) = %v, %v, want true and an upgrade", ok, rehash)
+	}
+
+	if ok, rehash := h.Verify("wrong horse", legacyHash); ok || rehash {
+		t.Errorf("Verify(wrong password, legacy hash) = %v, %v, want false, false", ok, rehash)
+	}
+
+	// without a legacy hasher old hashes are refused
+	if ok, _ := NewArgon2idHasher(testParams, nil).Verify("correct horse", legacyHash); ok {
+		t.Error("legacy hash accepted without a legacy hasher")
+	}
+
+	// an upgraded hash no longer asks for one
+	if ok, rehash := h.Verify("correct horse", h.Hash("correct horse")); !ok || rehash {
+		t.Errorf("Verify(upgraded hash) = %v, %v, want true, false", ok, rehash)
+	}
+}
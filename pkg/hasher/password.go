@@ -2,13 +2,18 @@ package hasher
 
 import (
 	"crypto/sha1"
+	"crypto/subtle"
 	"fmt"
 )
 
 type PasswordHasher interface {
 	Hash(password string) string
+	// Verify reports whether password matches hash and whether hash should
+	// be replaced with a fresh one.
+	Verify(password, hash string) (bool, bool)
 }
 
+// SHA1Hasher is kept to verify hashes created before argon2id was introduced.
 type SHA1Hasher struct {
 	salt string
 }
@@ -23,3 +28,7 @@ func (h SHA1Hasher) Hash(password string) string {
 
 	return fmt.Sprintf("%x", hash.Sum([]byte(h.salt)))
 }
+
+func (h SHA1Hasher) Verify(password, hash string) (bool, bool) {
+	return subtle.ConstantTimeCompare([]byte(h.Hash(password)), []byte(hash)) == 1, false
+}
@@ -3,14 +3,17 @@ package httpserver
 import (
 	"context"
 	"net/http"
+	"sync"
 	"time"
 )
 
 type Server struct {
+	mu         sync.Mutex
 	httpServer *http.Server
 }
 
 func (s *Server) Run(port string, handler http.Handler) error {
+	s.mu.Lock()
 	s.httpServer = &http.Server{
 		Addr:           ":" + port,
 		Handler:        handler,
@@ -18,10 +21,19 @@ func (s *Server) Run(port string, handler http.Handler) error {
 		ReadTimeout:    10 * time.Second,
 		WriteTimeout:   10 * time.Second,
 	}
+	s.mu.Unlock()
 
 	return s.httpServer.ListenAndServe()
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// server was never started
+	if s.httpServer == nil {
+		return nil
+	}
+
 	return s.httpServer.Shutdown(ctx)
 }
@@ -0,0 +1,57 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+)
+
+// JWKS is a JSON Web Key Set (RFC 7517) of the published public keys.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+func (s *KeySet) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	jwks := JWKS{Keys: make([]JWK, 0, len(s.keys))}
+	for _, key := range s.keys {
+		jwk := JWK{
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Algorithm,
+		}
+
+		switch pub := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encode(pub.N.Bytes())
+			jwk.E = encode(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = encode(pub)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,281 @@
+// Package jwtkeys keeps the asymmetric keys tokens are signed with. Keys are
+// PKCS#8 PEM files in a directory, the file name without extension is the
+// key id. Dropping a new file into the directory schedules a rotation and
+// deleting one stops publishing it. The creation time of a key, which orders
+// the keys and delays signing with a new one, is read from the Created header
+// of the PEM block in RFC 3339 format, or else from the time at the end of
+// the ids Generate gives, such as eddsa-20240101T120000Z.
+package jwtkeys
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	keyExt  = ".pem"
+	rsaBits = 2048
+
+	createdHeader = "Created"
+	idTimeLayout  = "20060102T150405Z"
+)
+
+var ErrNoKeys = errors.New("no signing keys")
+
+type Key struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+	CreatedAt  time.Time
+}
+
+type KeySet struct {
+	dir string
+	// a new key signs tokens only after verifiers had time to fetch it
+	activationDelay time.Duration
+
+	mu   sync.RWMutex
+	keys []*Key // oldest first
+}
+
+func NewKeySet(dir string, activationDelay time.Duration) (*KeySet, error) {
+	s := &KeySet{
+		dir:             dir,
+		activationDelay: activationDelay,
+	}
+
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reload reads the key directory again.
+func (s *KeySet) Reload() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("read key dir: %w", err)
+	}
+
+	keys := make([]*Key, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != keyExt {
+			continue
+		}
+
+		key, err := readKey(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return fmt.Errorf("read key %s: %w", entry.Name(), err)
+		}
+
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the key directory every interval until ctx is done. Failed
+// reloads keep the previous keys.
+func (s *KeySet) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// SigningKey returns the newest key past its activation delay, or the oldest
+// key if none is.
+func (s *KeySet) SigningKey() (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	activeBefore := time.Now().Add(-s.activationDelay)
+	for i := len(s.keys) - 1; i >= 0; i-- {
+		if !s.keys[i].CreatedAt.After(activeBefore) {
+			return s.keys[i], nil
+		}
+	}
+
+	return s.keys[0], nil
+}
+
+// Key returns a published key by id.
+func (s *KeySet) Key(id string) (*Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range s.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+
+	return nil, false
+}
+
+func (s *KeySet) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.keys)
+}
+
+// Generate writes a new key of the algorithm to the key directory and
+// reloads it.
+func (s *KeySet) Generate(algorithm string) (*Key, error) {
+	var signer crypto.Signer
+	switch algorithm {
+	case AlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaBits)
+		if err != nil {
+			return nil, err
+		}
+
+		signer = key
+	case AlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+
+		signer = key
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", algorithm)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt := time.Now().UTC().Truncate(time.Second)
+	id := strings.ToLower(algorithm) + "-" + createdAt.Format(idTimeLayout)
+	path := filepath.Join(s.dir, id+keyExt)
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	block := &pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{createdHeader: createdAt.Format(time.RFC3339)},
+		Bytes:   der,
+	}
+
+	if err := pem.Encode(file, block); err != nil {
+		return nil, err
+	}
+
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+
+	key, _ := s.Key(id)
+	return key, nil
+}
+
+func readKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	id := strings.TrimSuffix(filepath.Base(path), keyExt)
+	createdAt, err := keyCreatedAt(block, id)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{
+		ID:        id,
+		CreatedAt: createdAt,
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Algorithm = AlgorithmRS256
+		key.PrivateKey = k
+		key.PublicKey = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Algorithm = AlgorithmEdDSA
+		key.PrivateKey = k
+		key.PublicKey = k.Public()
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+
+	return key, nil
+}
+
+// keyCreatedAt does not fall back to the modification time of the file,
+// copying or restoring the key directory changes it and would reorder the
+// keys.
+func keyCreatedAt(block *pem.Block, id string) (time.Time, error) {
+	if created, ok := block.Headers[createdHeader]; ok {
+		createdAt, err := time.Parse(time.RFC3339, created)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s header: %w", createdHeader, err)
+		}
+
+		return createdAt, nil
+	}
+
+	if i := strings.LastIndex(id, "-"); i >= 0 {
+		if createdAt, err := time.Parse(idTimeLayout, id[i+1:]); err == nil {
+			return createdAt, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("no creation time, add a %s header to the PEM block", createdHeader)
+}
@@ -0,0 +1,145 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeKey stores a new Ed25519 key as id, with a Created header unless
+// created is zero.
+func writeKey(t *testing.T, dir, id string, created time.Time) {
+	t.Helper()
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	if !created.IsZero() {
+		block.Headers = map[string]string{createdHeader: created.Format(time.RFC3339)}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, id+keyExt), pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateRecordsCreationTime(t *testing.T) {
+	dir := t.TempDir()
+	keys, err := NewKeySet(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := keys.Generate(AlgorithmEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, key.ID+keyExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), createdHeader+": ") {
+		t.Fatalf("generated key has no %s header:\n%s", createdHeader, data)
+	}
+
+	// a restored backup has new file times
+	if err := os.Chtimes(filepath.Join(dir, key.ID+keyExt), time.Now(), time.Now().Add(48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := keys.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, _ := keys.Key(key.ID)
+	if !reloaded.CreatedAt.Equal(key.CreatedAt) {
+		t.Errorf("CreatedAt = %v after touching the file, want %v", reloaded.CreatedAt, key.CreatedAt)
+	}
+}
+
+func TestKeyCreationTime(t *testing.T) {
+	created := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		id      string
+		header  time.Time
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "header", id: "manual", header: created, want: created},
+		{name: "header wins over id", id: "eddsa-20200101T000000Z", header: created, want: created},
+		{name: "generated id", id: "eddsa-20240101T120000Z", want: created},
+		{name: "none", id: "manual", wantErr: true},
+		{name: "id without time", id: "eddsa-backup", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeKey(t, dir, tt.id, tt.header)
+
+			keys, err := NewKeySet(dir, time.Hour)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("loaded a key without a creation time")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key, ok := keys.Key(tt.id)
+			if !ok {
+				t.Fatalf("key %s not loaded", tt.id)
+			}
+
+			if !key.CreatedAt.Equal(tt.want) {
+				t.Errorf("CreatedAt = %v, want %v", key.CreatedAt, tt.want)
+			}
+		})
+	}
+}
+
+func TestSigningKeyAfterActivationDelay(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// written newest first, so file times would order them the wrong way
+	writeKey(t, dir, "new", now.Add(-time.Minute))
+	writeKey(t, dir, "current", now.Add(-24*time.Hour))
+	writeKey(t, dir, "old", now.Add(-48*time.Hour))
+
+	keys, err := NewKeySet(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := keys.SigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if key.ID != "current" {
+		t.Errorf("signing with %s, want current until new is past the activation delay", key.ID)
+	}
+}
@@ -0,0 +1,109 @@
+// Package mailer delivers plain text mail. Besides SMTP it has sinks that
+// write messages to the log or to files, for development and tests.
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// LogSender writes messages to the log instead of delivering them.
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg *Message) error {
+	logrus.WithFields(logrus.Fields{
+		"to":      msg.To,
+		"subject": msg.Subject,
+	}).Info(msg.Body)
+
+	return nil
+}
+
+// FileSender writes every message to its own .eml file in a directory.
+type FileSender struct {
+	dir  string
+	from string
+}
+
+func NewFileSender(dir, from string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create mail dir: %w", err)
+	}
+
+	return &FileSender{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+func (s *FileSender) Send(ctx context.Context, msg *Message) error {
+	name := time.Now().UTC().Format("20060102T150405.000000000Z") + "-" + unsafeFileChars.ReplaceAllString(msg.To, "_") + ".eml"
+	return os.WriteFile(filepath.Join(s.dir, name), encode(s.from, msg), 0o600)
+}
+
+// SMTPSender delivers messages through an SMTP server, authenticating with
+// PLAIN auth if a username is set.
+type SMTPSender struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+func NewSMTPSender(host, port, username, password, from string) *SMTPSender {
+	s := &SMTPSender{
+		addr: host + ":" + port,
+		from: from,
+	}
+
+	if username != "" {
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return s
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg *Message) error {
+	from := s.from
+	if start, end := strings.LastIndex(from, "<"), strings.LastIndex(from, ">"); start >= 0 && end > start {
+		from = from[start+1 : end]
+	}
+
+	return smtp.SendMail(s.addr, s.auth, from, []string{msg.To}, encode(s.from, msg))
+}
+
+func encode(from string, msg *Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	return []byte(b.String())
+}
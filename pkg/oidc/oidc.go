@@ -0,0 +1,424 @@
+// Package oidc is a relying party for OpenID Connect providers. It signs users
+// in with the authorization code flow and PKCE (RFC 7636) and verifies the
+// returned ID tokens against the keys the provider publishes.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/realdanielursul/simbir-go/pkg/jwtkeys"
+)
+
+const (
+	discoveryPath = "/.well-known/openid-configuration"
+	// tolerated clock difference to the provider
+	leeway = time.Minute
+	// longest response body read from the provider
+	maxBodySize = 1 << 20
+)
+
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+type Config struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Metadata is the part of the provider configuration document the flow needs.
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Claims are the ID token claims used to identify and provision accounts.
+type Claims struct {
+	Issuer            string   `json:"iss"`
+	Subject           string   `json:"sub"`
+	Audience          audience `json:"aud"`
+	AuthorizedParty   string   `json:"azp,omitempty"`
+	ExpiresAt         int64    `json:"exp"`
+	IssuedAt          int64    `json:"iat"`
+	Nonce             string   `json:"nonce,omitempty"`
+	Email             string   `json:"email,omitempty"`
+	EmailVerified     bool     `json:"email_verified,omitempty"`
+	PreferredUsername string   `json:"preferred_username,omitempty"`
+	Name              string   `json:"name,omitempty"`
+}
+
+// Valid is called by the jwt parser, the claims specific to OpenID Connect
+// are checked by Verify.
+func (c *Claims) Valid() error {
+	now := time.Now()
+	if c.ExpiresAt == 0 || now.After(time.Unix(c.ExpiresAt, 0).Add(leeway)) {
+		return fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	}
+
+	if now.Add(leeway).Before(time.Unix(c.IssuedAt, 0)) {
+		return fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	}
+
+	return nil
+}
+
+// audience is a single string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+
+	*a = multiple
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, v := range a {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Provider discovers its endpoints on first use, so the application can start
+// while the provider is unreachable.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mu       sync.RWMutex
+	metadata *Metadata
+	keys     map[string]crypto.PublicKey
+}
+
+func NewProvider(config Config, client *http.Client) *Provider {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	config.Issuer = strings.TrimRight(config.Issuer, "/")
+	return &Provider{
+		config: config,
+		client: client,
+	}
+}
+
+func (p *Provider) Issuer() string {
+	return p.config.Issuer
+}
+
+// AuthCodeURL returns the URL to send the user to. The state and nonce are
+// checked when the user comes back, the challenge is derived from the code
+// verifier passed to Exchange.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientID)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange trades the authorization code for tokens.
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (*TokenResponse, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+
+	var token TokenResponse
+	if err := p.do(req, &token); err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("exchange code: %w: missing in response", ErrInvalidIDToken)
+	}
+
+	return &token, nil
+}
+
+// Verify checks the signature and the claims of an ID token issued for this
+// client with the nonce of the login.
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.key(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key.(type) {
+		case *rsa.PublicKey:
+			if token.Method.Alg() != jwtkeys.AlgorithmRS256 {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		case ed25519.PublicKey:
+			if token.Method.Alg() != jwtkeys.AlgorithmEdDSA {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+		}
+
+		return key, nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidIDToken) {
+			return nil, err
+		}
+
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	switch {
+	case claims.Issuer != metadata.Issuer:
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claims.Issuer)
+	case !claims.Audience.contains(p.config.ClientID):
+		return nil, fmt.Errorf("%w: not issued for this client", ErrInvalidIDToken)
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientID:
+		return nil, fmt.Errorf("%w: unexpected authorized party %q", ErrInvalidIDToken, claims.AuthorizedParty)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	case claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// discover fetches the provider configuration once.
+func (p *Provider) discover(ctx context.Context) (*Metadata, error) {
+	p.mu.RLock()
+	metadata := p.metadata
+	p.mu.RUnlock()
+
+	if metadata != nil {
+		return metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.Issuer+discoveryPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata = &Metadata{}
+	if err := p.do(req, metadata); err != nil {
+		return nil, fmt.Errorf("discover provider: %w", err)
+	}
+
+	if strings.TrimRight(metadata.Issuer, "/") != p.config.Issuer {
+		return nil, fmt.Errorf("discover provider: issuer %q does not match %q", metadata.Issuer, p.config.Issuer)
+	}
+
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("discover provider: incomplete configuration")
+	}
+
+	p.mu.Lock()
+	p.metadata = metadata
+	p.mu.Unlock()
+
+	return metadata, nil
+}
+
+// key returns the public key with the id, refetching the key set once when
+// the provider rotated to a key not seen yet.
+func (p *Provider) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	p.mu.RLock()
+	key, ok := p.keys[kid]
+	p.mu.RUnlock()
+
+	if ok {
+		return key, nil
+	}
+
+	if err := p.fetchKeys(ctx); err != nil {
+		return nil, err
+	}
+
+	p.mu.RLock()
+	key, ok = p.keys[kid]
+	p.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown key id: %q", kid)
+	}
+
+	return key, nil
+}
+
+func (p *Provider) fetchKeys(ctx context.Context) error {
+	metadata, err := p.discover(ctx)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadata.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+
+	var jwks jwtkeys.JWKS
+	if err := p.do(req, &jwks); err != nil {
+		return fmt.Errorf("fetch keys: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := parseJWK(jwk)
+		if err != nil {
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	return nil
+}
+
+func (p *Provider) do(req *http.Request, v any) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// parseJWK supports the key types jwtkeys publishes.
+func parseJWK(jwk jwtkeys.JWK) (crypto.PublicKey, error) {
+	switch jwk.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+	}
+}
+
+// NewCodeVerifier returns a random PKCE code verifier.
+func NewCodeVerifier() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// CodeChallenge derives the S256 challenge sent with the authorization request.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
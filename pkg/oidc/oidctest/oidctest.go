@@ -0,0 +1,274 @@
+// Package oidctest is a minimal OpenID Connect provider for local runs and
+// integration tests. It signs users in without asking for credentials: the
+// user is picked with the login_hint parameter or is the first one added.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/realdanielursul/simbir-go/pkg/jwtkeys"
+	"github.com/realdanielursul/simbir-go/pkg/oidc"
+)
+
+const (
+	keyID   = "oidctest"
+	codeTTL = time.Minute
+	idTTL   = 5 * time.Minute
+)
+
+type User struct {
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+}
+
+type authorization struct {
+	user          User
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+type Issuer struct {
+	url          string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu    sync.Mutex
+	users []User
+	codes map[string]*authorization
+}
+
+// NewIssuer creates a provider reachable at issuerURL, which is what it puts
+// into the iss claim, with a single registered client.
+func NewIssuer(issuerURL, clientID, clientSecret string) (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Issuer{
+		url:          strings.TrimRight(issuerURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		key:          key,
+		codes:        make(map[string]*authorization),
+	}, nil
+}
+
+func (i *Issuer) AddUser(user User) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.users = append(i.users, user)
+}
+
+func (i *Issuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		i.configuration(w, r)
+	case "/jwks":
+		i.jwks(w, r)
+	case "/authorize":
+		i.authorize(w, r)
+	case "/token":
+		i.token(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (i *Issuer) configuration(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                i.url,
+		"authorization_endpoint":                i.url + "/authorize",
+		"token_endpoint":                        i.url + "/token",
+		"jwks_uri":                              i.url + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{jwtkeys.AlgorithmRS256},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "profile", "email"},
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, _ *http.Request) {
+	pub := i.key.PublicKey
+	writeJSON(w, http.StatusOK, jwtkeys.JWKS{Keys: []jwtkeys.JWK{{
+		Kty: "RSA",
+		Kid: keyID,
+		Use: "sig",
+		Alg: jwtkeys.AlgorithmRS256,
+		N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+func (i *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	redirectURI := query.Get("redirect_uri")
+	if query.Get("client_id") != i.clientID || redirectURI == "" {
+		http.Error(w, "unknown client or missing redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	params := redirect.Query()
+	params.Set("state", query.Get("state"))
+
+	user, ok := i.user(query.Get("login_hint"))
+	switch {
+	case query.Get("response_type") != "code":
+		params.Set("error", "unsupported_response_type")
+	case query.Get("code_challenge") == "" || query.Get("code_challenge_method") != "S256":
+		params.Set("error", "invalid_request")
+		params.Set("error_description", "PKCE with S256 is required")
+	case !ok:
+		params.Set("error", "access_denied")
+	default:
+		code := randomString()
+
+		i.mu.Lock()
+		i.codes[code] = &authorization{
+			user:          user,
+			redirectURI:   redirectURI,
+			nonce:         query.Get("nonce"),
+			codeChallenge: query.Get("code_challenge"),
+			expiresAt:     time.Now().Add(codeTTL),
+		}
+		i.mu.Unlock()
+
+		params.Set("code", code)
+	}
+
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	if clientID != i.clientID || clientSecret != i.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	// codes are single use
+	code := r.PostForm.Get("code")
+	i.mu.Lock()
+	auth := i.codes[code]
+	delete(i.codes, code)
+	i.mu.Unlock()
+
+	if auth == nil || time.Now().After(auth.expiresAt) || auth.redirectURI != r.PostForm.Get("redirect_uri") ||
+		oidc.CodeChallenge(r.PostForm.Get("code_verifier")) != auth.codeChallenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	idToken, err := i.sign(auth)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, oidc.TokenResponse{
+		AccessToken: randomString(),
+		TokenType:   "Bearer",
+		IDToken:     idToken,
+		ExpiresIn:   int64(idTTL.Seconds()),
+	})
+}
+
+func (i *Issuer) sign(auth *authorization) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                i.url,
+		"sub":                auth.user.Subject,
+		"aud":                i.clientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(idTTL).Unix(),
+		"nonce":              auth.nonce,
+		"email":              auth.user.Email,
+		"email_verified":     auth.user.EmailVerified,
+		"preferred_username": auth.user.Username,
+	})
+	token.Header["kid"] = keyID
+
+	return token.SignedString(i.key)
+}
+
+func (i *Issuer) user(hint string) (User, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if hint == "" && len(i.users) > 0 {
+		return i.users[0], true
+	}
+
+	for _, user := range i.users {
+		if user.Subject == hint || user.Username == hint {
+			return user, true
+		}
+	}
+
+	return User{}, false
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
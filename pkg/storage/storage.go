@@ -0,0 +1,112 @@
+// Package storage keeps uploaded files under keys such as
+// "licenses/42/3f9c.pdf". Keys are chosen by the server, never by clients.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+var (
+	ErrNotFound   = errors.New("file not found")
+	ErrInvalidKey = errors.New("invalid file key")
+)
+
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// LocalStore keeps files in a directory of the local file system. Replicas
+// must share the directory.
+type LocalStore struct {
+	dir string
+}
+
+func NewLocalStore(dir string) (*LocalStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create storage dir: %w", err)
+	}
+
+	return &LocalStore{dir: dir}, nil
+}
+
+// Put writes the file to a temporary name first, so a failed upload never
+// leaves a partial file under the key.
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return fmt.Errorf("create file dir: %w", err)
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("create file: %w", err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write file: %w", err)
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return fmt.Errorf("store file: %w", err)
+	}
+
+	return nil
+}
+
+func (s *LocalStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+
+	return f, nil
+}
+
+// Delete removes the file, files that do not exist are not an error.
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("delete file: %w", err)
+	}
+
+	return nil
+}
+
+// path maps the key into the directory, rejecting keys that would escape it.
+func (s *LocalStore) path(key string) (string, error) {
+	if !filepath.IsLocal(key) {
+		return "", ErrInvalidKey
+	}
+
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
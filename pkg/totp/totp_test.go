@@ -0,0 +1,178 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// base32 of the ASCII seed "12345678901234567890" of RFC 6238, Appendix B
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The SHA1 test vectors of RFC 6238, Appendix B. The RFC lists 8 digit
+// codes, these are their last 6 digits.
+var rfcVectors = []struct {
+	unix int64
+	step int64
+	code string
+}{
+	{unix: 59, step: 0x1, code: "287082"},
+	{unix: 1111111109, step: 0x23523EC, code: "081804"},
+	{unix: 1111111111, step: 0x23523ED, code: "050471"},
+	{unix: 1234567890, step: 0x273EF07, code: "005924"},
+	{unix: 2000000000, step: 0x3F940AA, code: "279037"},
+	{unix: 20000000000, step: 0x27BC86AA, code: "353130"},
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	for _, tt := range rfcVectors {
+		now := time.Unix(tt.unix, 0)
+
+		if step := Step(now); step != tt.step {
+			t.Errorf("Step(%d) = %#x, want %#x", tt.unix, step, tt.step)
+		}
+
+		code, err := Code(rfcSecret, tt.step)
+		if err != nil {
+			t.Fatalf("Code(%#x): %v", tt.step, err)
+		}
+
+		if code != tt.code {
+			t.Errorf("Code(%#x) = %s, want %s", tt.step, code, tt.code)
+		}
+
+		if step, ok := Validate(rfcSecret, tt.code, now, 0); !ok || step != tt.step {
+			t.Errorf("Validate(%s) at %d = %#x, %v, want %#x, true", tt.code, tt.unix, step, ok, tt.step)
+		}
+	}
+}
+
+func TestStepBoundaries(t *testing.T) {
+	tests := []struct {
+		unix int64
+		step int64
+	}{
+		{unix: 0, step: 0},
+		{unix: 29, step: 0},
+		{unix: 30, step: 1},
+		{unix: 59, step: 1},
+		{unix: 60, step: 2},
+	}
+
+	for _, tt := range tests {
+		if step := Step(time.Unix(tt.unix, 0)); step != tt.step {
+			t.Errorf("Step(%d) = %d, want %d", tt.unix, step, tt.step)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+
+	tests := []struct {
+		offset int64
+		skew   int64
+		ok     bool
+	}{
+		{offset: 0, skew: 0, ok: true},
+		{offset: -1, skew: 0, ok: false},
+		{offset: 1, skew: 0, ok: false},
+		{offset: -1, skew: 1, ok: true},
+		{offset: 1, skew: 1, ok: true},
+		{offset: -2, skew: 1, ok: false},
+		{offset: 2, skew: 1, ok: false},
+		{offset: 2, skew: 2, ok: true},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, current+tt.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		step, ok := Validate(rfcSecret, code, now, tt.skew)
+		if ok != tt.ok {
+			t.Errorf("code of step %+d with skew %d: ok = %v, want %v", tt.offset, tt.skew, ok, tt.ok)
+			continue
+		}
+
+		// the step the code belongs to, so it can be used only once
+		if ok && step != current+tt.offset {
+			t.Errorf("code of step %+d matched step %+d", tt.offset, step-current)
+		}
+	}
+
+	// the window moves with the clock, a code expires after its period
+	code, err := Code(rfcSecret, current)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := Validate(rfcSecret, code, now.Add(2*Period), 1); ok {
+		t.Error("code accepted two periods later with a skew of 1")
+	}
+}
+
+func TestValidateCodeFormat(t *testing.T) {
+	// 005924 is the code at this time, leading zeros are part of it
+	now := time.Unix(1234567890, 0)
+
+	tests := []struct {
+		name string
+		code string
+		ok   bool
+	}{
+		{name: "code", code: "005924", ok: true},
+		{name: "without leading zeros", code: "5924"},
+		{name: "empty", code: ""},
+		{name: "too short", code: "05924"},
+		{name: "too long", code: "0005924"},
+		{name: "eight digits of the RFC", code: "89005924"},
+		{name: "surrounded by spaces", code: " 005924 "},
+		{name: "not digits", code: "00592a"},
+		{name: "other digits", code: "005925"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(rfcSecret, tt.code, now, 1); ok != tt.ok {
+				t.Errorf("Validate(%q) = %v, want %v", tt.code, ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	// secrets are accepted in lower case as some apps show them
+	code, err := Code(strings.ToLower(rfcSecret), 1)
+	if err != nil || code != "287082" {
+		t.Errorf("Code(lower case secret) = %s, %v, want 287082", code, err)
+	}
+
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code accepted a secret that is not base32")
+	}
+
+	if _, ok := Validate("not base32!", "287082", time.Unix(59, 0), 1); ok {
+		t.Error("Validate accepted a code of a secret that is not base32")
+	}
+
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if secret == other {
+		t.Error("two generated secrets are equal")
+	}
+
+	if key, err := encoding.DecodeString(secret); err != nil || len(key) != secretSize {
+		t.Errorf("generated secret %q decodes to %d bytes, %v, want %d", secret, len(key), err, secretSize)
+	}
+}